@@ -0,0 +1,110 @@
+package main
+
+import (
+	"chordparser/internal/audit"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// runAudit implements the "audit" command: it queries the audit log and prints the matching
+// entries. It returns the exit code.
+func runAudit(args []string) int {
+	fs := flag.NewFlagSet("audit", flag.ContinueOnError)
+	logPath := fs.String("log", envOr("CHORDPARSER_AUDIT_LOG", "audit.jsonl"), "audit log file (default CHORDPARSER_AUDIT_LOG or audit.jsonl)")
+	song := fs.String("song", "", "only entries of this PCO song ID")
+	outcome := fs.String("outcome", "", `only entries with this outcome: "succeeded", "failed", "skipped" or "conflict"`)
+	from := fs.String("from", "", "only entries at or after this date (YYYY-MM-DD) or time (RFC 3339)")
+	to := fs.String("to", "", "only entries up to and including this date (YYYY-MM-DD), or before this time (RFC 3339)")
+	asJSON := fs.Bool("json", false, "print the entries as JSON Lines")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	filter := audit.Filter{SongID: *song, Outcome: *outcome}
+	var err error
+	if filter.From, err = parseAuditTime(*from, false); err != nil {
+		fmt.Fprintf(os.Stderr, "error: -from: %v\n", err)
+		return 2
+	}
+	if filter.To, err = parseAuditTime(*to, true); err != nil {
+		fmt.Fprintf(os.Stderr, "error: -to: %v\n", err)
+		return 2
+	}
+
+	entries, err := audit.QueryFile(*logPath, filter)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	enc := json.NewEncoder(os.Stdout)
+	for _, e := range entries {
+		if *asJSON {
+			if err := enc.Encode(e); err != nil {
+				fmt.Fprintf(os.Stderr, "json error: %v\n", err)
+				return 1
+			}
+			continue
+		}
+		fmt.Println(formatAuditEntry(e))
+	}
+	return 0
+}
+
+// parseAuditTime parses a date or RFC 3339 time. A date used as an upper bound (end)
+// includes the whole day. An empty value gives the zero time (no bound).
+func parseAuditTime(s string, end bool) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.DateOnly, s); err == nil {
+		if end {
+			t = t.AddDate(0, 0, 1)
+		}
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
+
+// formatAuditEntry renders an entry as one line of text.
+func formatAuditEntry(e audit.Entry) string {
+	parts := []string{
+		e.Time.UTC().Format(time.RFC3339),
+		e.Operator,
+		e.Action,
+		"song " + e.SongID + " arrangement " + e.ArrangementID,
+		e.Outcome,
+	}
+	if e.HTTPStatus != 0 {
+		parts = append(parts, fmt.Sprintf("HTTP %d", e.HTTPStatus))
+	}
+	if len(e.Rules) > 0 {
+		parts = append(parts, "rules: "+strings.Join(e.Rules, ","))
+	}
+	if e.BeforeHash != "" || e.AfterHash != "" {
+		parts = append(parts, "hash "+shortHash(e.BeforeHash)+" -> "+shortHash(e.AfterHash))
+	}
+	if e.Error != "" {
+		parts = append(parts, "error: "+e.Error)
+	}
+	return strings.Join(parts, "  ")
+}
+
+// shortHash abbreviates a content hash for display.
+func shortHash(h string) string {
+	if len(h) > 12 {
+		return h[:12]
+	}
+	return h
+}
+
+// envOr returns the environment variable, or def if it is not set.
+func envOr(name, def string) string {
+	if v := os.Getenv(name); v != "" {
+		return v
+	}
+	return def
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"chordparser/config"
+	"chordparser/internal/backup"
+	"chordparser/internal/batch"
+	"chordparser/internal/convert"
+	"chordparser/internal/daemon"
+	"chordparser/internal/pcosync"
+	"chordparser/internal/schedule"
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// runDaemon implements the "daemon" command: it runs the directory pipeline, or with -pco the
+// Planning Center sync, on a cron schedule until SIGINT or SIGTERM, never overlapping runs, and
+// keeps the last run summaries.
+// It returns the exit code.
+func runDaemon(args []string) int {
+	cfg, err := config.LoadDaemon()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 2
+	}
+	fs := flag.NewFlagSet("daemon", flag.ContinueOnError)
+	c := converterFlags(fs)
+	out := fs.String("o", "", "output directory (required)")
+	include := fs.String("include", "", `comma-separated glob patterns of files to process, e.g. "*.cho,*.txt" (default all files)`)
+	exclude := fs.String("exclude", "", `comma-separated glob patterns of files to skip, e.g. "drafts/*"`)
+	stateFile := fs.String("state", "", "state file so that every run only converts changed files (recommended)")
+	fs.StringVar(&cfg.Schedule, "schedule", cfg.Schedule, `cron expression, e.g. "*/15 * * * *" (default CHORDPARSER_SCHEDULE or @hourly)`)
+	fs.StringVar(&cfg.LockFile, "lock", cfg.LockFile, "lock file that prevents overlapping runs (default CHORDPARSER_LOCK_FILE)")
+	fs.StringVar(&cfg.HistoryFile, "history", cfg.HistoryFile, "file keeping the last run summaries (default CHORDPARSER_HISTORY_FILE)")
+	fs.IntVar(&cfg.HistoryKeep, "keep", cfg.HistoryKeep, "number of run summaries to keep (default CHORDPARSER_HISTORY_KEEP)")
+	pco := fs.Bool("pco", false, "sync the Planning Center library like \"cli sync\" instead of converting a directory")
+	var pcoFlags syncFlags
+	pcoFlags.addWriteFlags(fs)
+	once := fs.Bool("once", false, "run once now instead of on the schedule")
+	status := fs.Bool("status", false, "print the kept run summaries and exit")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	history := daemon.NewHistory(cfg.HistoryFile, cfg.HistoryKeep)
+	if *status {
+		summaries, err := history.Summaries()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			return 1
+		}
+		for _, s := range summaries {
+			fmt.Println(formatSummary(s))
+		}
+		return 0
+	}
+
+	sched, err := schedule.Parse(cfg.Schedule)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 2
+	}
+	ext, ok := convert.Extensions[c.Format]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "error: unsupported output format %q\n", c.Format)
+		return 2
+	}
+	if *pco && (fs.NArg() != 0 || *out != "") || !*pco && (fs.NArg() != 1 || *out == "") {
+		fmt.Fprintln(os.Stderr, "usage: cli daemon [flags] -o <output directory> <input directory>\n       cli daemon -pco [flags]")
+		return 2
+	}
+	pipeline, err := config.LoadPipeline()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 2
+	}
+
+	var job daemon.Job
+	if *pco {
+		pcoFlags.lang, pcoFlags.abbrev, pcoFlags.stateFile = c.Lang, c.Abbrev, *stateFile
+		opts, closeOpts, err := pcoFlags.options()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			return 2
+		}
+		defer closeOpts()
+		job = func(ctx context.Context) (daemon.Counts, error) {
+			// Every run is a backup run of its own, so one can be rolled back on its own
+			opts := opts
+			opts.RunID = backup.NewRunID(time.Now())
+			results, err := pcosync.Library(ctx, opts)
+			if err != nil {
+				return daemon.Counts{}, err
+			}
+			pcosync.WriteSummary(os.Stderr, results)
+			return countResults(results, func(r pcosync.Result) (bool, error) { return r.Skipped || r.Held, r.Err }), nil
+		}
+	} else {
+		root, opts := fs.Arg(0), batch.Options{Include: splitList(*include), Exclude: splitList(*exclude)}
+		job = func(ctx context.Context) (daemon.Counts, error) {
+			results, err := convertDir(ctx, *c, root, *out, ext, opts, pipeline, *stateFile)
+			if err != nil {
+				return daemon.Counts{}, err
+			}
+			return countResults(results, func(r batch.Result) (bool, error) { return r.Skipped, r.Err }), nil
+		}
+	}
+
+	// SIGINT or SIGTERM let a run in progress finish its started files, then stop
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	dopts := daemon.Options{
+		Schedule: sched,
+		LockFile: cfg.LockFile,
+		History:  history,
+		Log:      log.New(os.Stderr, "daemon: ", log.LstdFlags),
+	}
+	if *once {
+		if _, err := daemon.RunOnce(ctx, dopts, job); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+	if err := daemon.Run(ctx, dopts, job); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// countResults counts the results of a run by outcome.
+func countResults[R any](results []R, outcome func(R) (skipped bool, err error)) daemon.Counts {
+	counts := daemon.Counts{Processed: len(results)}
+	for _, r := range results {
+		switch skipped, err := outcome(r); {
+		case err != nil:
+			counts.Failed++
+		case skipped:
+			counts.Skipped++
+		default:
+			counts.Succeeded++
+		}
+	}
+	return counts
+}
+
+// formatSummary renders a run summary as one line.
+func formatSummary(s daemon.Summary) string {
+	line := fmt.Sprintf("%s  %-10s  %6s  %d processed, %d succeeded, %d skipped, %d failed",
+		s.Start.Format(time.RFC3339), s.Outcome, s.End.Sub(s.Start).Round(time.Second),
+		s.Processed, s.Succeeded, s.Skipped, s.Failed)
+	if s.Error != "" {
+		line += "  " + s.Error
+	}
+	return line
+}
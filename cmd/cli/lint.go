@@ -0,0 +1,109 @@
+package main
+
+import (
+	"chordparser/internal/batch"
+	"chordparser/internal/chord"
+	"chordparser/internal/langdetect"
+	"chordparser/internal/lint"
+	"chordparser/internal/parser"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+// runLint implements the "lint" command: it reports chart problems without changing the charts.
+// Arguments are files or directories (walked recursively); without arguments stdin is linted.
+// It returns the exit code: 1 if any warning or error was found.
+func runLint(args []string) int {
+	fs := flag.NewFlagSet("lint", flag.ContinueOnError)
+	format := fs.String("format", "text", `output format: "text", "json" or "sarif"`)
+	lang := fs.String("lang", "", `chart language for header keywords: "auto" to detect, a code such as "nl", or empty for all languages`)
+	abbrev := fs.Bool("abbrev", false, `also accept abbreviated headers such as "V1", "PC", "Br" or "C:" (whole line only); a bare note letter ("C", "B", "E", "C2") is a header only when all other headers are abbreviations`)
+	include := fs.String("include", "", `comma-separated glob patterns of files to lint in a directory, e.g. "*.cho,*.txt" (default all files)`)
+	key := fs.String("key", "", `song key (e.g. the PCO arrangement key) to check chords against; overrides {key:} in the chart`)
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *key != "" {
+		if _, err := chord.ParseKey(*key); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			return 2
+		}
+	}
+	if *lang != "" && *lang != "auto" && !slices.Contains(parser.Languages(), *lang) {
+		fmt.Fprintf(os.Stderr, "error: unsupported language %q (supported: %v)\n", *lang, parser.Languages())
+		return 2
+	}
+	write, ok := map[string]func(io.Writer, []lint.FileReport) error{
+		"text":  lint.WriteText,
+		"json":  lint.WriteJSON,
+		"sarif": lint.WriteSARIF,
+	}[*format]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "error: unsupported lint format %q\n", *format)
+		return 2
+	}
+
+	paths := fs.Args()
+	if len(paths) == 0 {
+		paths = []string{"-"}
+	}
+	files, err := lintFiles(paths, batch.Options{Include: splitList(*include)})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+
+	reports := make([]lint.FileReport, 0, len(files))
+	failed := false
+	for _, file := range files {
+		var data []byte
+		if file == "-" {
+			data, err = io.ReadAll(os.Stdin)
+		} else {
+			data, err = os.ReadFile(file)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "read error: %v\n", err)
+			return 1
+		}
+		opts := lint.Options{Parser: parser.Options{Language: *lang, Abbreviations: *abbrev}, Key: *key}
+		if opts.Parser.Language == "auto" {
+			opts.Parser.Language = langdetect.Detect(string(data))
+		}
+		findings := lint.Lint(string(data), opts)
+		failed = failed || lint.Failed(findings)
+		reports = append(reports, lint.FileReport{File: file, Findings: findings})
+	}
+
+	if err := write(os.Stdout, reports); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	if failed {
+		return 1
+	}
+	return 0
+}
+
+// lintFiles expands directories into the files they contain (selected by opts).
+func lintFiles(paths []string, opts batch.Options) ([]string, error) {
+	var files []string
+	for _, p := range paths {
+		if fi, err := os.Stat(p); err == nil && fi.IsDir() {
+			rel, err := batch.Files(p, opts)
+			if err != nil {
+				return nil, err
+			}
+			for _, r := range rel {
+				files = append(files, filepath.Join(p, filepath.FromSlash(r)))
+			}
+			continue
+		}
+		files = append(files, p)
+	}
+	return files, nil
+}
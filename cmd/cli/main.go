@@ -1,19 +1,87 @@
 package main
 
 import (
+	"bytes"
+	"chordparser/config"
+	"chordparser/internal/batch"
+	"chordparser/internal/convert"
+	"chordparser/internal/exporter"
 	"chordparser/internal/parser"
+	"chordparser/internal/pool"
+	"chordparser/internal/state"
+	"context"
 	"encoding/json"
+	"flag"
 	"fmt"
 	"io"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"golang.org/x/time/rate"
 )
 
 func main() {
-	fmt.Println("Running Chord Parser!")
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "audit":
+			os.Exit(runAudit(os.Args[2:]))
+		case "lint":
+			os.Exit(runLint(os.Args[2:]))
+		case "daemon":
+			os.Exit(runDaemon(os.Args[2:]))
+		case "webhook":
+			os.Exit(runWebhook(os.Args[2:]))
+		case "sync":
+			os.Exit(runSync(os.Args[2:]))
+		case "rollback":
+			os.Exit(runRollback(os.Args[2:]))
+		}
+	}
+
+	c := converterFlags(flag.CommandLine)
+	out := flag.String("o", "", "write the output to this file, or into this existing directory as <title><ext>, instead of stdout; required for a directory input")
+	include := flag.String("include", "", `comma-separated glob patterns of files to process in a directory, e.g. "*.cho,*.txt" (default all files)`)
+	exclude := flag.String("exclude", "", `comma-separated glob patterns of files to skip in a directory, e.g. "drafts/*"`)
+	stateFile := flag.String("state", "", "state file that makes directory runs incremental and resumable (unchanged files are skipped)")
+	workers := flag.Int("workers", 0, "concurrent workers for a directory input (default CHORDPARSER_WORKERS or the number of CPUs)")
+	flag.Parse()
+
+	// Status goes to stderr so stdout only carries the output document
+	fmt.Fprintln(os.Stderr, "Running Chord Parser!")
+
+	if c.Lang != "" && c.Lang != "auto" && !slices.Contains(parser.Languages(), c.Lang) {
+		fmt.Fprintf(os.Stderr, "error: unsupported language %q (supported: %v)\n", c.Lang, parser.Languages())
+		os.Exit(2)
+	}
+	ext, ok := convert.Extensions[c.Format]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "error: unsupported output format %q\n", c.Format)
+		os.Exit(2)
+	}
+
+	if flag.NArg() > 0 {
+		if fi, err := os.Stat(flag.Arg(0)); err == nil && fi.IsDir() {
+			cfg, err := config.LoadPipeline()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "error: %v\n", err)
+				os.Exit(2)
+			}
+			if *workers > 0 {
+				cfg.Workers = *workers
+			}
+			opts := batch.Options{Include: splitList(*include), Exclude: splitList(*exclude)}
+			os.Exit(runBatch(*c, flag.Arg(0), *out, ext, opts, cfg, *stateFile))
+		}
+	}
 
 	var r io.Reader
-	if len(os.Args) > 1 && os.Args[1] != "-" {
-		f, err := os.Open(os.Args[1])
+	name := ""
+	if flag.NArg() > 0 && flag.Arg(0) != "-" {
+		name = flag.Arg(0)
+		f, err := os.Open(flag.Arg(0))
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "error: %v\n", err)
 			os.Exit(1)
@@ -30,11 +98,122 @@ func main() {
 		os.Exit(1)
 	}
 
-	sections := parser.Parse(string(b))
-	enc := json.NewEncoder(os.Stdout)
-	enc.SetIndent("", "  ")
-	if err := enc.Encode(sections); err != nil {
-		fmt.Fprintf(os.Stderr, "json error: %v\n", err)
+	song, language, err := c.Read(name, b)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
 		os.Exit(1)
 	}
+
+	// Export to memory first, so a failed export never leaves a truncated file behind
+	var buf bytes.Buffer
+	if err := c.Write(&buf, song, language); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+	if *out == "" {
+		os.Stdout.Write(buf.Bytes())
+		return
+	}
+	path := *out
+	if fi, err := os.Stat(path); err == nil && fi.IsDir() {
+		// One file per song, named after its title (or the input file)
+		songTitle := song.Title
+		if songTitle == "" && name != "" {
+			songTitle = strings.TrimSuffix(filepath.Base(name), filepath.Ext(name))
+		}
+		path = filepath.Join(path, exporter.FileName(songTitle, ext))
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0o644); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// converterFlags defines the conversion flags on fs and returns the converter they configure.
+func converterFlags(fs *flag.FlagSet) *convert.Converter {
+	var c convert.Converter
+	fs.StringVar(&c.Lang, "lang", "", `chart language for header keywords: "auto" to detect, a code such as "nl", or empty for all languages`)
+	fs.StringVar(&c.From, "from", "auto", `input format: "auto" to detect, "chordpro", "onsong", "opensong" or "openlyrics"`)
+	fs.BoolVar(&c.Abbrev, "abbrev", false, `also accept abbreviated headers such as "V1", "PC", "Br" or "C:" (whole line only); a bare note letter ("C", "B", "E", "C2") is a header only when all other headers are abbreviations`)
+	fs.StringVar(&c.Format, "format", "json", `output format: "json" (parsed sections), "chordpro", "openlyrics", "propresenter" or "html" (cleaned song)`)
+	fs.BoolVar(&c.Columns, "columns", false, "two-column layout for -format html")
+	fs.IntVar(&c.SlideLines, "slide-lines", exporter.DefaultMaxSlideLines, "maximum lyric lines per slide for -format propresenter")
+	fs.StringVar(&c.Title, "title", "", "song title for exports (overrides imported metadata)")
+	fs.StringVar(&c.Author, "author", "", "song author(s) for exports, comma separated")
+	fs.StringVar(&c.Key, "key", "", "song key for exports")
+	fs.StringVar(&c.CCLI, "ccli", "", "CCLI song number for exports")
+	fs.StringVar(&c.Transpose, "transpose", "", `transpose the chords to this key, e.g. "A" (the song key comes from the chart, the import or -key)`)
+	return &c
+}
+
+// runBatch converts every selected file under root into outDir, mirroring the directory
+// structure, prints a summary and returns the exit code (1 if any file failed).
+// SIGINT stops the run: files that have not started are reported as failed.
+// With a state file, files unchanged since their last successful conversion are skipped.
+func runBatch(c convert.Converter, root, outDir, ext string, opts batch.Options, cfg config.Pipeline, stateFile string) int {
+	if outDir == "" {
+		fmt.Fprintln(os.Stderr, "error: -o <directory> is required when processing a directory")
+		return 2
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	results, err := convertDir(ctx, c, root, outDir, ext, opts, cfg, stateFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	if batch.WriteSummary(os.Stderr, results) > 0 {
+		return 1
+	}
+	return 0
+}
+
+// convertDir runs the batch pipeline over a directory: it converts the selected files under root
+// into outDir on the configured worker pool, skipping unchanged files when a state file is given.
+// Source files that would share an output path fail the run before anything is converted.
+func convertDir(ctx context.Context, c convert.Converter, root, outDir, ext string, opts batch.Options, cfg config.Pipeline, stateFile string) ([]batch.Result, error) {
+	opts.OutDir = outDir
+	files, err := batch.Files(root, opts)
+	if err != nil {
+		return nil, err
+	}
+	if err := batch.CheckOutputs(outDir, ext, files); err != nil {
+		return nil, err
+	}
+	// Every conversion flag is a Converter field, so its JSON identifies the options
+	settings, err := json.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+	runOpts := batch.RunOptions{Pool: pool.Options{Workers: cfg.Workers}, OptionsHash: state.Hash(settings)}
+	if cfg.RateLimit > 0 {
+		runOpts.Pool.Limiter = rate.NewLimiter(rate.Limit(cfg.RateLimit), cfg.RateBurst)
+	}
+	if stateFile != "" {
+		st, err := state.Open(stateFile)
+		if err != nil {
+			return nil, err
+		}
+		defer st.Close()
+		runOpts.State = st
+	}
+	return batch.Run(ctx, root, outDir, ext, files, runOpts, c.Convert), nil
+}
+
+// splitList splits a comma-separated flag value, dropping empty items.
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
 }
@@ -0,0 +1,264 @@
+package main
+
+import (
+	"chordparser/config"
+	"chordparser/internal/audit"
+	"chordparser/internal/backup"
+	"chordparser/internal/fetcher"
+	"chordparser/internal/parser"
+	"chordparser/internal/pcosync"
+	"chordparser/internal/state"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"slices"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// syncFlags holds the flags shared by the commands that write to Planning Center.
+type syncFlags struct {
+	lang      string
+	abbrev    bool
+	backupDir string
+	stateFile string
+	dryRun    bool
+	auditLog  string
+	operator  string
+}
+
+// addSyncFlags registers the sync flags on fs.
+func addSyncFlags(fs *flag.FlagSet) *syncFlags {
+	var f syncFlags
+	fs.StringVar(&f.lang, "lang", "", `chart language: "auto" to detect it per chart, or a code such as "nl" (default all languages)`)
+	fs.BoolVar(&f.abbrev, "abbrev", false, `also accept abbreviated headers such as "V1", "PC" or "C:"; a bare note letter ("C", "B", "E", "C2") is a header only when all other headers are abbreviations`)
+	fs.StringVar(&f.stateFile, "state", "", "state file so that arrangements unchanged since their last sync are skipped")
+	f.addWriteFlags(fs)
+	return &f
+}
+
+// addWriteFlags registers the flags about writing to PCO, for commands that have their own
+// -lang, -abbrev and -state flags.
+func (f *syncFlags) addWriteFlags(fs *flag.FlagSet) {
+	fs.StringVar(&f.backupDir, "backup", envOr("CHORDPARSER_BACKUP_DIR", "backups"), "backup archive of every arrangement before it is written (default CHORDPARSER_BACKUP_DIR or backups)")
+	fs.BoolVar(&f.dryRun, "dry-run", false, "report what would be cleaned without writing anything")
+	fs.StringVar(&f.auditLog, "audit", envOr("CHORDPARSER_AUDIT_LOG", "audit.jsonl"), "audit log recording every write (default CHORDPARSER_AUDIT_LOG or audit.jsonl)")
+	fs.StringVar(&f.operator, "operator", envOr("USER", "unknown"), "operator recorded in the audit log (default $USER)")
+}
+
+// options builds the sync options: the PCO client, the backup archive, the audit log and the
+// state store. done releases them.
+func (f *syncFlags) options() (opts pcosync.Options, done func(), err error) {
+	if f.lang != "" && f.lang != "auto" && !slices.Contains(parser.Languages(), f.lang) {
+		return opts, nil, fmt.Errorf("unsupported language %q (supported: %v)", f.lang, parser.Languages())
+	}
+	pipeline, err := config.LoadPipeline()
+	if err != nil {
+		return opts, nil, err
+	}
+	client, err := pcoClient(pipeline)
+	if err != nil {
+		return opts, nil, err
+	}
+	opts = pcosync.Options{Client: client, Workers: pipeline.Workers, Language: f.lang, Abbreviations: f.abbrev, DryRun: f.dryRun}
+	var closers []func() error
+	done = func() {
+		for _, c := range closers {
+			c()
+		}
+	}
+	if !f.dryRun {
+		if opts.Archive, err = backup.Open(f.backupDir); err != nil {
+			return opts, nil, err
+		}
+		if opts.Audit, err = audit.Open(f.auditLog, f.operator); err != nil {
+			return opts, nil, err
+		}
+		closers = append(closers, opts.Audit.Close)
+	}
+	if f.stateFile != "" {
+		st, err := state.Open(f.stateFile)
+		if err != nil {
+			done()
+			return opts, nil, err
+		}
+		opts.State = st
+		closers = append(closers, st.Close)
+	}
+	return opts, done, nil
+}
+
+// pcoClient returns a Planning Center client from the configuration, paced by the pipeline's
+// rate limit.
+func pcoClient(pipeline config.Pipeline) (*fetcher.Client, error) {
+	pco, err := config.LoadPlanningCenter()
+	if err != nil {
+		return nil, err
+	}
+	if !pco.Configured() {
+		return nil, errors.New("CHORDPARSER_PCO_APP_ID and CHORDPARSER_PCO_SECRET are required to sync with Planning Center")
+	}
+	opts := fetcher.Options{BaseURL: pco.BaseURL, AppID: pco.AppID, Secret: pco.Secret}
+	if pipeline.RateLimit > 0 {
+		opts.Limiter = rate.NewLimiter(rate.Limit(pipeline.RateLimit), pipeline.RateBurst)
+	}
+	return fetcher.New(opts), nil
+}
+
+// runSync implements the "sync" command: it cleans the chord charts of the whole library, a
+// song or one arrangement and writes the changed ones back, backing each up first. It returns
+// the exit code (1 if any arrangement failed).
+func runSync(args []string) int {
+	fs := flag.NewFlagSet("sync", flag.ContinueOnError)
+	f := addSyncFlags(fs)
+	song := fs.String("song", "", "only sync the arrangements of this PCO song ID")
+	arrangement := fs.String("arrangement", "", "only sync this PCO arrangement ID (requires -song)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() > 0 || (*arrangement != "" && *song == "") {
+		fmt.Fprintln(os.Stderr, "usage: cli sync [flags] [-song ID [-arrangement ID]]")
+		return 2
+	}
+	opts, closeOpts, err := f.options()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 2
+	}
+	defer closeOpts()
+
+	// SIGINT lets started arrangements finish; the others are reported as failed
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	opts.RunID = backup.NewRunID(time.Now())
+	var results []pcosync.Result
+	if *song != "" {
+		results, err = pcosync.Song(ctx, opts, *song, *arrangement)
+	} else {
+		results, err = pcosync.Library(ctx, opts)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	failed := pcosync.WriteSummary(os.Stderr, results)
+	if !opts.DryRun {
+		fmt.Fprintf(os.Stderr, "backup run %s (undo with: cli rollback -run %s)\n", opts.RunID, opts.RunID)
+	}
+	if failed > 0 {
+		return 1
+	}
+	return 0
+}
+
+// runRollback implements the "rollback" command: it restores arrangements from the backup
+// archive to their state before a sync changed them. It returns the exit code.
+func runRollback(args []string) int {
+	fs := flag.NewFlagSet("rollback", flag.ContinueOnError)
+	backupDir := fs.String("backup", envOr("CHORDPARSER_BACKUP_DIR", "backups"), "backup archive (default CHORDPARSER_BACKUP_DIR or backups)")
+	arrangement := fs.String("arrangement", "", "only restore this PCO arrangement ID")
+	run := fs.String("run", "", `only restore the changes of this run, e.g. "20260318T101500Z-3f9a1c"`)
+	since := fs.String("since", "", "only restore changes made at or after this date (YYYY-MM-DD) or time (RFC 3339)")
+	auditLog := fs.String("audit", envOr("CHORDPARSER_AUDIT_LOG", "audit.jsonl"), "audit log recording every write (default CHORDPARSER_AUDIT_LOG or audit.jsonl)")
+	operator := fs.String("operator", envOr("USER", "unknown"), "operator recorded in the audit log (default $USER)")
+	release := fs.String("release", "", "release this rolled-back arrangement ID, so syncs clean it again")
+	listHolds := fs.Bool("holds", false, "list the rolled-back arrangements that syncs leave alone")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *release != "" || *listHolds {
+		if fs.NArg() > 0 || *arrangement != "" || *run != "" || *since != "" {
+			fmt.Fprintln(os.Stderr, "usage: cli rollback [-backup dir] -holds | -release ID")
+			return 2
+		}
+		return runHolds(*backupDir, *release)
+	}
+	if fs.NArg() > 0 || (*arrangement == "" && *run == "" && *since == "") {
+		fmt.Fprintln(os.Stderr, "usage: cli rollback [-backup dir] [-arrangement ID] [-run ID] [-since date]  (at least one of -arrangement, -run and -since)")
+		return 2
+	}
+	sel := backup.Selection{ArrangementID: *arrangement, RunID: *run}
+	var err error
+	if sel.Since, err = parseAuditTime(*since, false); err != nil {
+		fmt.Fprintf(os.Stderr, "error: -since: %v\n", err)
+		return 2
+	}
+	pipeline, err := config.LoadPipeline()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 2
+	}
+	client, err := pcoClient(pipeline)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 2
+	}
+	opts := pcosync.Options{Client: client, RunID: backup.NewRunID(time.Now())}
+	if opts.Archive, err = backup.Open(*backupDir); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	if opts.Audit, err = audit.Open(*auditLog, *operator); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	defer opts.Audit.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	// The restores are backed up as a run of their own, so the rollback can be undone too
+	results, err := pcosync.Rollback(ctx, opts, sel)
+	failed := 0
+	for _, r := range results {
+		name := fmt.Sprintf("song %s arrangement %s (run %s)", r.Snapshot.SongID, r.Snapshot.ArrangementID, r.Snapshot.RunID)
+		switch {
+		case r.Err != nil:
+			failed++
+			fmt.Fprintf(os.Stderr, "FAIL %s: %v\n", name, r.Err)
+		case r.Skipped:
+			fmt.Fprintf(os.Stderr, "skip %s (the change was never applied)\n", name)
+		default:
+			fmt.Fprintf(os.Stderr, "ok   %s restored and held (release with: cli rollback -release %s)\n", name, r.Snapshot.ArrangementID)
+		}
+	}
+	fmt.Fprintf(os.Stderr, "%d arrangements, %d failed; rollback run %s\n", len(results), failed, opts.RunID)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	if failed > 0 {
+		return 1
+	}
+	return 0
+}
+
+// runHolds lists the held arrangements of the archive in dir, or releases one if release is
+// set. It returns the exit code.
+func runHolds(dir, release string) int {
+	archive, err := backup.Open(dir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	if release != "" {
+		if err := archive.Release(release); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			return 1
+		}
+		fmt.Fprintf(os.Stderr, "arrangement %s released; the next sync cleans it again\n", release)
+		return 0
+	}
+	holds, err := archive.Holds()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	for _, h := range holds {
+		fmt.Printf("song %s arrangement %s: held since %s (rolled back run %s)\n", h.SongID, h.ArrangementID, h.HeldAt.Format(time.RFC3339), h.RunID)
+	}
+	return 0
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"chordparser/config"
+	"chordparser/internal/webhook"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+)
+
+// runWebhook implements the "webhook" command: it sends a signed PCO webhook delivery for a
+// song or arrangement event, as Planning Center would, to test the server's receiver locally.
+// It returns the exit code.
+func runWebhook(args []string) int {
+	pco, err := config.LoadPlanningCenter()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 2
+	}
+	secret := ""
+	if len(pco.WebhookSecrets) > 0 {
+		secret = pco.WebhookSecrets[0]
+	}
+	fs := flag.NewFlagSet("webhook", flag.ContinueOnError)
+	url := fs.String("url", "http://localhost:8080/webhooks/pco", "receiver URL")
+	fs.StringVar(&secret, "secret", secret, "authenticity secret to sign with (default the first of CHORDPARSER_PCO_WEBHOOK_SECRETS)")
+	event := fs.String("event", "arrangement.updated", `event name, e.g. "song.updated" or "arrangement.created"`)
+	var t webhook.Target
+	fs.StringVar(&t.SongID, "song", "", "song ID (required)")
+	fs.StringVar(&t.ArrangementID, "arrangement", "", "arrangement ID (required for arrangement events)")
+	printOnly := fs.Bool("print", false, "print the signature header and body instead of sending them")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 0 || secret == "" {
+		fmt.Fprintln(os.Stderr, "usage: cli webhook -secret <secret> -song <id> [-arrangement <id>] [-event arrangement.updated] [-url ...] [-print]")
+		return 2
+	}
+	body, err := webhook.Delivery(*event, t)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 2
+	}
+	if *printOnly {
+		fmt.Printf("%s: %s\n\n%s\n", webhook.SignatureHeader, webhook.Sign(secret, body), body)
+		return 0
+	}
+
+	req, err := webhook.NewRequest(*url, secret, body)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 2
+	}
+	resp, err := (&http.Client{Timeout: 30 * time.Second}).Do(req)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	defer resp.Body.Close()
+	reply, _ := io.ReadAll(resp.Body)
+	fmt.Printf("%s\n%s", resp.Status, reply)
+	if resp.StatusCode >= 300 {
+		return 1
+	}
+	return 0
+}
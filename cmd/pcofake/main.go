@@ -0,0 +1,79 @@
+package main
+
+import (
+	"chordparser/internal/pcofake"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+)
+
+func main() {
+	addr := flag.String("addr", "127.0.0.1:8081", "listen address")
+	fixtures := flag.String("fixtures", "", "directory of song fixture files (*.json, one song each; default the built-in sample songs)")
+	perPage := flag.Int("per-page", pcofake.DefaultPerPage, "default page size of list endpoints")
+	rateLimit := flag.Int("rate-limit", pcofake.DefaultRateLimit, "requests allowed per -rate-period (negative for unlimited)")
+	ratePeriod := flag.Duration("rate-period", pcofake.DefaultRatePeriod, "rate limit period")
+	webhooks := flag.String("webhooks", "", "comma-separated URLs that receive signed webhooks when an arrangement changes, e.g. http://localhost:8080/webhooks/pco")
+	secret := flag.String("webhook-secret", os.Getenv("CHORDPARSER_PCO_WEBHOOK_SECRETS"), "secret that signs the webhooks (default the first of CHORDPARSER_PCO_WEBHOOK_SECRETS)")
+	user := flag.String("user", "", "require this basic auth user (the PCO application ID)")
+	password := flag.String("password", "", "require this basic auth password (the PCO secret)")
+	flag.Parse()
+
+	opts := pcofake.Options{
+		PerPage:       *perPage,
+		RateLimit:     *rateLimit,
+		RatePeriod:    *ratePeriod,
+		WebhookSecret: strings.TrimSpace(strings.Split(*secret, ",")[0]),
+		Username:      *user,
+		Password:      *password,
+		Log:           log.Default(),
+	}
+	if *fixtures != "" {
+		songs, err := pcofake.LoadFixtures(os.DirFS(*fixtures))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(2)
+		}
+		opts.Songs = songs
+	}
+	for _, url := range strings.Split(*webhooks, ",") {
+		if url = strings.TrimSpace(url); url != "" {
+			opts.Webhooks = append(opts.Webhooks, url)
+		}
+	}
+	if len(opts.Webhooks) > 0 && opts.WebhookSecret == "" {
+		fmt.Fprintln(os.Stderr, "error: -webhooks needs -webhook-secret")
+		os.Exit(2)
+	}
+
+	fake := pcofake.New(opts)
+	srv := &http.Server{Addr: *addr, Handler: fake, ReadHeaderTimeout: 10 * time.Second}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		<-ctx.Done()
+		shutdown, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := srv.Shutdown(shutdown); err != nil {
+			log.Printf("shutdown: %v", err)
+		}
+		fake.Close()
+	}()
+
+	log.Printf("Fake Planning Center API listening on http://%s/services/v2/songs", *addr)
+	if err := srv.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		log.Printf("error: %v", err)
+		os.Exit(1)
+	}
+	<-done
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"chordparser/config"
+	"chordparser/internal/api"
+	"chordparser/internal/audit"
+	"chordparser/internal/backup"
+	"chordparser/internal/fetcher"
+	"chordparser/internal/pcosync"
+	"chordparser/internal/webhook"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+func main() {
+	cfg, err := config.LoadServer()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(2)
+	}
+	flag.StringVar(&cfg.Addr, "addr", cfg.Addr, "listen address (default CHORDPARSER_ADDR or :8080)")
+	flag.Int64Var(&cfg.MaxBody, "max-body", cfg.MaxBody, "request size limit in bytes (default CHORDPARSER_MAX_BODY or 1 MiB)")
+	flag.Parse()
+	pco, err := config.LoadPlanningCenter()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(2)
+	}
+
+	// SIGINT or SIGTERM stop accepting requests and let running ones finish
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	mux := http.NewServeMux()
+	mux.Handle("/", api.New(api.Options{MaxBody: cfg.MaxBody}))
+	synced := make(chan struct{})
+	switch {
+	case len(pco.WebhookSecrets) == 0:
+		close(synced)
+	case !pco.Configured():
+		// Accepting webhooks that are never synced would tell PCO they were handled
+		log.Printf("PCO webhooks disabled: CHORDPARSER_PCO_APP_ID and CHORDPARSER_PCO_SECRET are required to sync")
+		close(synced)
+	default:
+		opts, closeOpts, err := syncOptions(pco)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(2)
+		}
+		defer closeOpts()
+		q := webhook.NewQueue(pco.WebhookDelay)
+		mux.Handle("/webhooks/pco", webhook.NewHandler(q, webhook.Options{Secrets: pco.WebhookSecrets, MaxBody: cfg.MaxBody}))
+		go func() {
+			defer close(synced)
+			q.Run(ctx, 1, func(ctx context.Context, t webhook.Target) error {
+				return syncTarget(ctx, opts, t)
+			}, func(t webhook.Target, err error) {
+				log.Printf("webhook: %s: %v", t, err)
+			})
+		}()
+		log.Printf("Receiving PCO webhooks on /webhooks/pco")
+	}
+
+	srv := &http.Server{
+		Addr:              cfg.Addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+		ReadTimeout:       30 * time.Second,
+		WriteTimeout:      30 * time.Second,
+		IdleTimeout:       2 * time.Minute,
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		<-ctx.Done()
+		shutdown, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := srv.Shutdown(shutdown); err != nil {
+			log.Printf("shutdown: %v", err)
+		}
+	}()
+
+	log.Printf("Chord Parser API listening on %s", cfg.Addr)
+	if err := srv.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		log.Printf("error: %v", err)
+		os.Exit(1)
+	}
+	<-done
+	<-synced
+}
+
+// syncOptions builds the options of the webhook syncs: the PCO client, paced by
+// CHORDPARSER_RATE_LIMIT, the backup archive in CHORDPARSER_BACKUP_DIR and the audit log in
+// CHORDPARSER_AUDIT_LOG. done closes the audit log.
+func syncOptions(pco config.PlanningCenter) (opts pcosync.Options, done func(), err error) {
+	pipeline, err := config.LoadPipeline()
+	if err != nil {
+		return opts, nil, err
+	}
+	copts := fetcher.Options{BaseURL: pco.BaseURL, AppID: pco.AppID, Secret: pco.Secret}
+	if pipeline.RateLimit > 0 {
+		copts.Limiter = rate.NewLimiter(rate.Limit(pipeline.RateLimit), pipeline.RateBurst)
+	}
+	opts = pcosync.Options{Client: fetcher.New(copts), Workers: pipeline.Workers}
+	if opts.Archive, err = backup.Open(envOr("CHORDPARSER_BACKUP_DIR", "backups")); err != nil {
+		return opts, nil, err
+	}
+	if opts.Audit, err = audit.Open(envOr("CHORDPARSER_AUDIT_LOG", "audit.jsonl"), "webhook"); err != nil {
+		return opts, nil, err
+	}
+	return opts, func() { opts.Audit.Close() }, nil
+}
+
+// syncTarget is the webhook queue's sync step: it cleans the changed song's arrangements (or the
+// changed arrangement) and writes them back, backed up and audited (see internal/pcosync).
+func syncTarget(ctx context.Context, opts pcosync.Options, t webhook.Target) error {
+	results, err := pcosync.Song(ctx, opts, t.SongID, t.ArrangementID)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, r := range results {
+		switch {
+		case r.Err != nil:
+			errs = append(errs, fmt.Errorf("arrangement %s: %w", r.ArrangementID, r.Err))
+		case r.Written:
+			log.Printf("webhook: song %s arrangement %s: %d lines cleaned and written (%s)", r.SongID, r.ArrangementID, r.Lines, strings.Join(r.Rules, ", "))
+		case r.Held:
+			log.Printf("webhook: song %s arrangement %s: held after a rollback, not synced", r.SongID, r.ArrangementID)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// envOr returns the environment variable, or def if it is not set.
+func envOr(name, def string) string {
+	if v := os.Getenv(name); v != "" {
+		return v
+	}
+	return def
+}
@@ -0,0 +1,43 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// Daemon holds configuration values for the scheduled daemon mode (cli daemon).
+// Values are pulled from environment variables:
+//
+//	CHORDPARSER_SCHEDULE      cron expression for runs (default "@hourly")
+//	CHORDPARSER_LOCK_FILE     lock file that prevents overlapping runs (default "chordparser.lock")
+//	CHORDPARSER_HISTORY_FILE  file keeping the last run summaries (default "runs.json")
+//	CHORDPARSER_HISTORY_KEEP  number of run summaries to keep (default 20)
+type Daemon struct {
+	Schedule    string
+	LockFile    string
+	HistoryFile string
+	HistoryKeep int
+}
+
+// LoadDaemon reads the daemon configuration from the environment.
+func LoadDaemon() (Daemon, error) {
+	d := Daemon{Schedule: "@hourly", LockFile: "chordparser.lock", HistoryFile: "runs.json", HistoryKeep: 20}
+	if v := os.Getenv("CHORDPARSER_SCHEDULE"); v != "" {
+		d.Schedule = v
+	}
+	if v := os.Getenv("CHORDPARSER_LOCK_FILE"); v != "" {
+		d.LockFile = v
+	}
+	if v := os.Getenv("CHORDPARSER_HISTORY_FILE"); v != "" {
+		d.HistoryFile = v
+	}
+	if v := os.Getenv("CHORDPARSER_HISTORY_KEEP"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return d, fmt.Errorf("config: CHORDPARSER_HISTORY_KEEP must be a positive integer, got %q", v)
+		}
+		d.HistoryKeep = n
+	}
+	return d, nil
+}
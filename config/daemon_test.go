@@ -0,0 +1,24 @@
+package config
+
+import "testing"
+
+func TestLoadDaemon(t *testing.T) {
+	for _, k := range []string{"CHORDPARSER_SCHEDULE", "CHORDPARSER_LOCK_FILE", "CHORDPARSER_HISTORY_FILE", "CHORDPARSER_HISTORY_KEEP"} {
+		t.Setenv(k, "")
+	}
+	d, err := LoadDaemon()
+	if err != nil || d != (Daemon{Schedule: "@hourly", LockFile: "chordparser.lock", HistoryFile: "runs.json", HistoryKeep: 20}) {
+		t.Fatalf("defaults: %#v, %v", d, err)
+	}
+
+	t.Setenv("CHORDPARSER_SCHEDULE", "*/15 * * * *")
+	t.Setenv("CHORDPARSER_HISTORY_KEEP", "5")
+	if d, err = LoadDaemon(); err != nil || d.Schedule != "*/15 * * * *" || d.HistoryKeep != 5 {
+		t.Fatalf("from env: %#v, %v", d, err)
+	}
+
+	t.Setenv("CHORDPARSER_HISTORY_KEEP", "none")
+	if _, err := LoadDaemon(); err == nil {
+		t.Fatalf("expected an error for an invalid history size")
+	}
+}
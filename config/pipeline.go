@@ -0,0 +1,48 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"strconv"
+)
+
+// Pipeline holds configuration values for processing runs (batch conversions and library syncs).
+// Values are pulled from environment variables:
+//
+//	CHORDPARSER_WORKERS     number of concurrent workers (default: number of CPUs)
+//	CHORDPARSER_RATE_LIMIT  maximum operations per second shared by all workers: file conversions,
+//	                        or Planning Center API requests in syncs (default 0: unlimited)
+//	CHORDPARSER_RATE_BURST  operations allowed at once before the rate limit applies (default 1)
+type Pipeline struct {
+	Workers   int
+	RateLimit float64
+	RateBurst int
+}
+
+// LoadPipeline reads the pipeline configuration from the environment.
+func LoadPipeline() (Pipeline, error) {
+	p := Pipeline{Workers: runtime.NumCPU(), RateBurst: 1}
+	if v := os.Getenv("CHORDPARSER_WORKERS"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return p, fmt.Errorf("config: CHORDPARSER_WORKERS must be a positive integer, got %q", v)
+		}
+		p.Workers = n
+	}
+	if v := os.Getenv("CHORDPARSER_RATE_LIMIT"); v != "" {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil || f < 0 {
+			return p, fmt.Errorf("config: CHORDPARSER_RATE_LIMIT must be a non-negative number, got %q", v)
+		}
+		p.RateLimit = f
+	}
+	if v := os.Getenv("CHORDPARSER_RATE_BURST"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return p, fmt.Errorf("config: CHORDPARSER_RATE_BURST must be a positive integer, got %q", v)
+		}
+		p.RateBurst = n
+	}
+	return p, nil
+}
@@ -0,0 +1,39 @@
+package config
+
+import (
+	"runtime"
+	"testing"
+)
+
+func TestLoadPipeline_Defaults(t *testing.T) {
+	t.Setenv("CHORDPARSER_WORKERS", "")
+	t.Setenv("CHORDPARSER_RATE_LIMIT", "")
+	t.Setenv("CHORDPARSER_RATE_BURST", "")
+	p, err := LoadPipeline()
+	if err != nil {
+		t.Fatalf("LoadPipeline: %v", err)
+	}
+	if p.Workers != runtime.NumCPU() || p.RateLimit != 0 || p.RateBurst != 1 {
+		t.Fatalf("unexpected defaults: %#v", p)
+	}
+}
+
+func TestLoadPipeline_FromEnv(t *testing.T) {
+	t.Setenv("CHORDPARSER_WORKERS", "4")
+	t.Setenv("CHORDPARSER_RATE_LIMIT", "5")
+	t.Setenv("CHORDPARSER_RATE_BURST", "10")
+	p, err := LoadPipeline()
+	if err != nil {
+		t.Fatalf("LoadPipeline: %v", err)
+	}
+	if p != (Pipeline{Workers: 4, RateLimit: 5, RateBurst: 10}) {
+		t.Fatalf("unexpected config: %#v", p)
+	}
+}
+
+func TestLoadPipeline_Invalid(t *testing.T) {
+	t.Setenv("CHORDPARSER_WORKERS", "0")
+	if _, err := LoadPipeline(); err == nil {
+		t.Fatalf("expected an error for zero workers")
+	}
+}
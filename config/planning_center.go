@@ -1,4 +1,62 @@
 package config
 
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
 // PlanningCenter holds configuration values for the Planning Center API.
-// Values are pulled from environment variables.
+// Values are pulled from environment variables:
+//
+//	CHORDPARSER_PCO_APP_ID           application ID of the personal access token
+//	CHORDPARSER_PCO_SECRET           secret of the personal access token
+//	CHORDPARSER_PCO_BASE_URL         API root (default https://api.planningcenteronline.com)
+//	CHORDPARSER_PCO_WEBHOOK_SECRETS  comma-separated authenticity secrets of the webhook
+//	                                 subscriptions (default none: webhooks are disabled)
+//	CHORDPARSER_PCO_WEBHOOK_DELAY    quiet period before a changed song is synced (default 10s)
+type PlanningCenter struct {
+	AppID, Secret  string
+	BaseURL        string
+	WebhookSecrets []string
+	WebhookDelay   time.Duration
+}
+
+// Configured reports whether API credentials are set, which syncing requires.
+func (p PlanningCenter) Configured() bool {
+	return p.AppID != "" && p.Secret != ""
+}
+
+// LoadPlanningCenter reads the Planning Center configuration from the environment.
+func LoadPlanningCenter() (PlanningCenter, error) {
+	p := PlanningCenter{
+		AppID:        os.Getenv("CHORDPARSER_PCO_APP_ID"),
+		Secret:       os.Getenv("CHORDPARSER_PCO_SECRET"),
+		BaseURL:      "https://api.planningcenteronline.com",
+		WebhookDelay: 10 * time.Second,
+	}
+	if (p.AppID == "") != (p.Secret == "") {
+		return p, errors.New("config: CHORDPARSER_PCO_APP_ID and CHORDPARSER_PCO_SECRET must be set together")
+	}
+	if v := os.Getenv("CHORDPARSER_PCO_BASE_URL"); v != "" {
+		if !strings.HasPrefix(v, "http://") && !strings.HasPrefix(v, "https://") {
+			return p, fmt.Errorf("config: CHORDPARSER_PCO_BASE_URL must be an http(s) URL, got %q", v)
+		}
+		p.BaseURL = v
+	}
+	for _, s := range strings.Split(os.Getenv("CHORDPARSER_PCO_WEBHOOK_SECRETS"), ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			p.WebhookSecrets = append(p.WebhookSecrets, s)
+		}
+	}
+	if v := os.Getenv("CHORDPARSER_PCO_WEBHOOK_DELAY"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 0 {
+			return p, fmt.Errorf("config: CHORDPARSER_PCO_WEBHOOK_DELAY must be a duration such as 10s, got %q", v)
+		}
+		p.WebhookDelay = d
+	}
+	return p, nil
+}
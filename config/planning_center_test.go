@@ -0,0 +1,46 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestLoadPlanningCenter(t *testing.T) {
+	for _, name := range []string{"APP_ID", "SECRET", "BASE_URL", "WEBHOOK_SECRETS", "WEBHOOK_DELAY"} {
+		t.Setenv("CHORDPARSER_PCO_"+name, "")
+	}
+	p, err := LoadPlanningCenter()
+	if err != nil || !reflect.DeepEqual(p, PlanningCenter{BaseURL: "https://api.planningcenteronline.com", WebhookDelay: 10 * time.Second}) || p.Configured() {
+		t.Fatalf("defaults: %#v, %v", p, err)
+	}
+
+	t.Setenv("CHORDPARSER_PCO_APP_ID", "app")
+	t.Setenv("CHORDPARSER_PCO_SECRET", "secret")
+	t.Setenv("CHORDPARSER_PCO_BASE_URL", "http://127.0.0.1:8081")
+	t.Setenv("CHORDPARSER_PCO_WEBHOOK_SECRETS", "songs, arrangements,")
+	t.Setenv("CHORDPARSER_PCO_WEBHOOK_DELAY", "2m")
+	want := PlanningCenter{
+		AppID: "app", Secret: "secret", BaseURL: "http://127.0.0.1:8081",
+		WebhookSecrets: []string{"songs", "arrangements"}, WebhookDelay: 2 * time.Minute,
+	}
+	if p, err = LoadPlanningCenter(); err != nil || !reflect.DeepEqual(p, want) || !p.Configured() {
+		t.Fatalf("from env: %#v, %v", p, err)
+	}
+
+	t.Setenv("CHORDPARSER_PCO_BASE_URL", "api.example.com")
+	if _, err := LoadPlanningCenter(); err == nil {
+		t.Fatalf("expected an error for a base URL without a scheme")
+	}
+	t.Setenv("CHORDPARSER_PCO_BASE_URL", "")
+	t.Setenv("CHORDPARSER_PCO_SECRET", "")
+	if _, err := LoadPlanningCenter(); err == nil {
+		t.Fatalf("expected an error for an application ID without a secret")
+	}
+	t.Setenv("CHORDPARSER_PCO_APP_ID", "")
+
+	t.Setenv("CHORDPARSER_PCO_WEBHOOK_DELAY", "10")
+	if _, err := LoadPlanningCenter(); err == nil {
+		t.Fatalf("expected an error for a delay without a unit")
+	}
+}
@@ -0,0 +1,33 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// Server holds configuration values for the HTTP service (cmd/server).
+// Values are pulled from environment variables:
+//
+//	CHORDPARSER_ADDR      listen address (default ":8080")
+//	CHORDPARSER_MAX_BODY  request size limit in bytes (default 1048576)
+type Server struct {
+	Addr    string
+	MaxBody int64
+}
+
+// LoadServer reads the server configuration from the environment.
+func LoadServer() (Server, error) {
+	s := Server{Addr: ":8080", MaxBody: 1 << 20}
+	if v := os.Getenv("CHORDPARSER_ADDR"); v != "" {
+		s.Addr = v
+	}
+	if v := os.Getenv("CHORDPARSER_MAX_BODY"); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || n < 1 {
+			return s, fmt.Errorf("config: CHORDPARSER_MAX_BODY must be a positive integer, got %q", v)
+		}
+		s.MaxBody = n
+	}
+	return s, nil
+}
@@ -0,0 +1,23 @@
+package config
+
+import "testing"
+
+func TestLoadServer(t *testing.T) {
+	t.Setenv("CHORDPARSER_ADDR", "")
+	t.Setenv("CHORDPARSER_MAX_BODY", "")
+	s, err := LoadServer()
+	if err != nil || s != (Server{Addr: ":8080", MaxBody: 1 << 20}) {
+		t.Fatalf("defaults: %#v, %v", s, err)
+	}
+
+	t.Setenv("CHORDPARSER_ADDR", "127.0.0.1:9000")
+	t.Setenv("CHORDPARSER_MAX_BODY", "4096")
+	if s, err = LoadServer(); err != nil || s != (Server{Addr: "127.0.0.1:9000", MaxBody: 4096}) {
+		t.Fatalf("from env: %#v, %v", s, err)
+	}
+
+	t.Setenv("CHORDPARSER_MAX_BODY", "-1")
+	if _, err := LoadServer(); err == nil {
+		t.Fatalf("expected an error for a negative body limit")
+	}
+}
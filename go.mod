@@ -1,3 +1,11 @@
 module chordparser
 
 go 1.25.0
+
+require (
+	go.etcd.io/bbolt v1.4.3
+	golang.org/x/text v0.28.0
+	golang.org/x/time v0.12.0
+)
+
+require golang.org/x/sys v0.29.0 // indirect
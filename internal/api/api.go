@@ -0,0 +1,286 @@
+package api
+
+import (
+	"bytes"
+	"chordparser/internal/convert"
+	"chordparser/internal/parser"
+	"chordparser/internal/processor"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"slices"
+)
+
+// DefaultMaxBody is the default request size limit in bytes.
+const DefaultMaxBody = 1 << 20
+
+// Content types of the export formats.
+var contentTypes = map[string]string{
+	"json":         "application/json",
+	"chordpro":     "text/plain; charset=utf-8",
+	"openlyrics":   "application/xml; charset=utf-8",
+	"propresenter": "text/plain; charset=utf-8",
+	"html":         "text/html; charset=utf-8",
+}
+
+//go:embed openapi.yaml
+var openAPI []byte
+
+// Options configures the API handler.
+type Options struct {
+	// MaxBody is the request size limit in bytes; zero means DefaultMaxBody.
+	MaxBody int64
+}
+
+// Request is the JSON body of every endpoint. Only Chart is required.
+type Request struct {
+	// Chart is the chart text or the imported file (OnSong, OpenSong or OpenLyrics).
+	Chart string `json:"chart"`
+	// Name is the file name, which helps to detect the input format.
+	Name string `json:"name,omitempty"`
+	// From is the input format: "auto" (default), "chordpro", "onsong", "opensong" or "openlyrics".
+	From string `json:"from,omitempty"`
+	// Lang is the chart language: "auto" to detect, a code such as "nl", or empty for all languages.
+	Lang   string `json:"lang,omitempty"`
+	Abbrev bool   `json:"abbrev,omitempty"`
+	// Title, Author, Key and CCLI override the song metadata.
+	Title  string `json:"title,omitempty"`
+	Author string `json:"author,omitempty"`
+	Key    string `json:"key,omitempty"`
+	CCLI   string `json:"ccli,omitempty"`
+	// To is the target key of /transpose.
+	To string `json:"to,omitempty"`
+	// Columns and SlideLines configure the HTML and ProPresenter exports.
+	Columns    bool `json:"columns,omitempty"`
+	SlideLines int  `json:"slideLines,omitempty"`
+}
+
+// Response is the JSON body of /parse, /clean and /transpose. Chart is the rewritten chart
+// text of /clean and /transpose, where only the lines that changed differ from the input;
+// it is omitted for imported formats.
+type Response struct {
+	Language string      `json:"language,omitempty"`
+	Song     parser.Song `json:"song"`
+	Chart    *string     `json:"chart,omitempty"`
+	Changed  int         `json:"changed,omitempty"`
+}
+
+// Error is the JSON body of every error response.
+type Error struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// requestError is an error with its HTTP status and error code.
+type requestError struct {
+	status int
+	code   string
+	err    error
+}
+
+func (e *requestError) Error() string { return e.err.Error() }
+
+func fail(status int, code string, format string, args ...any) error {
+	return &requestError{status: status, code: code, err: fmt.Errorf(format, args...)}
+}
+
+// New returns the API handler:
+//
+//	POST /parse             parsed song with source positions
+//	POST /clean             cleaned song and minimal-diff cleaned chart
+//	POST /transpose         transposed song and chart ("to" is the target key)
+//	POST /export/{format}   cleaned song as json, chordpro, openlyrics, propresenter or html
+//	GET  /openapi.yaml      OpenAPI description
+func New(opts Options) http.Handler {
+	if opts.MaxBody <= 0 {
+		opts.MaxBody = DefaultMaxBody
+	}
+	h := handler{maxBody: opts.MaxBody}
+	mux := http.NewServeMux()
+	mux.Handle("POST /parse", h.json(parse))
+	mux.Handle("POST /clean", h.json(clean))
+	mux.Handle("POST /transpose", h.json(transpose))
+	mux.HandleFunc("POST /export/{format}", h.export)
+	mux.HandleFunc("GET /openapi.yaml", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/yaml")
+		_, _ = w.Write(openAPI)
+	})
+	for _, path := range []string{"/parse", "/clean", "/transpose", "/export/{format}", "/openapi.yaml"} {
+		mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+			writeError(w, fail(http.StatusMethodNotAllowed, "method_not_allowed", "method %s is not allowed on %s", r.Method, r.URL.Path))
+		})
+	}
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, fail(http.StatusNotFound, "not_found", "no endpoint %s", r.URL.Path))
+	})
+	return mux
+}
+
+type handler struct {
+	maxBody int64
+}
+
+// json adapts an endpoint that answers with a Response.
+func (h handler) json(fn func(Request) (Response, error)) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req, err := h.decode(w, r)
+		if err == nil {
+			var resp Response
+			if resp, err = fn(req); err == nil {
+				writeJSON(w, http.StatusOK, resp)
+				return
+			}
+		}
+		writeError(w, err)
+	})
+}
+
+func parse(req Request) (Response, error) {
+	song, language, err := read(converter(req), req)
+	return Response{Language: language, Song: song}, err
+}
+
+func clean(req Request) (Response, error) {
+	c := converter(req)
+	song, language, err := read(c, req)
+	if err != nil {
+		return Response{}, err
+	}
+	popts := processor.Options{Language: language}
+	song.Sections = processor.CleanSections(song.Sections, popts)
+	resp := Response{Language: language, Song: song}
+	if tree, _, ok := c.Chart(req.Name, []byte(req.Chart)); ok {
+		resp.Changed = processor.CleanTree(tree, popts).Lines
+		resp.Chart = ptr(tree.String())
+	}
+	return resp, nil
+}
+
+func transpose(req Request) (Response, error) {
+	if req.To == "" {
+		return Response{}, fail(http.StatusBadRequest, "missing_key", `"to" (the target key) is required`)
+	}
+	c := converter(req)
+	song, language, err := read(c, req)
+	if err != nil {
+		return Response{}, err
+	}
+	from := song.Key
+	if err := convert.TransposeSong(&song, req.To); err != nil {
+		return Response{}, fail(http.StatusUnprocessableEntity, "invalid_key", "cannot transpose: %v", err)
+	}
+	resp := Response{Language: language, Song: song}
+	if tree, _, ok := c.Chart(req.Name, []byte(req.Chart)); ok {
+		if resp.Changed, err = convert.TransposeTree(tree, from, req.To); err != nil {
+			return Response{}, fail(http.StatusUnprocessableEntity, "invalid_key", "cannot transpose: %v", err)
+		}
+		resp.Chart = ptr(tree.String())
+	}
+	return resp, nil
+}
+
+func (h handler) export(w http.ResponseWriter, r *http.Request) {
+	format := r.PathValue("format")
+	contentType, ok := contentTypes[format]
+	if !ok {
+		writeError(w, fail(http.StatusNotFound, "unsupported_format", "unsupported export format %q (supported: %v)", format, formats()))
+		return
+	}
+	req, err := h.decode(w, r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	c := converter(req)
+	c.Format = format
+	song, language, err := read(c, req)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	var buf bytes.Buffer
+	if err := c.Write(&buf, song, language); err != nil {
+		writeError(w, &requestError{status: http.StatusInternalServerError, code: "export_failed", err: err})
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	_, _ = w.Write(buf.Bytes())
+}
+
+// decode reads the JSON request body within the size limit.
+func (h handler) decode(w http.ResponseWriter, r *http.Request) (Request, error) {
+	var req Request
+	if mt, _, err := mime.ParseMediaType(r.Header.Get("Content-Type")); err != nil || mt != "application/json" {
+		return req, fail(http.StatusUnsupportedMediaType, "unsupported_media_type", "the request body must be application/json")
+	}
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, h.maxBody))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return req, fail(http.StatusRequestEntityTooLarge, "too_large", "the request body exceeds %d bytes", h.maxBody)
+		}
+		return req, fail(http.StatusBadRequest, "invalid_json", "invalid request body: %v", err)
+	}
+	if req.Chart == "" {
+		return req, fail(http.StatusBadRequest, "missing_chart", `"chart" is required`)
+	}
+	if req.Lang != "" && req.Lang != "auto" && !slices.Contains(parser.Languages(), req.Lang) {
+		return req, fail(http.StatusBadRequest, "unsupported_language", "unsupported language %q (supported: %v)", req.Lang, parser.Languages())
+	}
+	return req, nil
+}
+
+// converter configures the CLI pipeline for a request.
+func converter(req Request) convert.Converter {
+	c := convert.Converter{
+		Lang: req.Lang, From: req.From, Abbrev: req.Abbrev, Format: "json",
+		Columns: req.Columns, SlideLines: req.SlideLines,
+		Title: req.Title, Author: req.Author, Key: req.Key, CCLI: req.CCLI,
+	}
+	if c.From == "" {
+		c.From = "auto"
+	}
+	return c
+}
+
+// read runs the pipeline's reading step, reporting import failures as client errors.
+func read(c convert.Converter, req Request) (parser.Song, string, error) {
+	song, language, err := c.Read(req.Name, []byte(req.Chart))
+	if err != nil {
+		return song, "", &requestError{status: http.StatusUnprocessableEntity, code: "import_failed", err: err}
+	}
+	return song, language, nil
+}
+
+// formats lists the export formats.
+func formats() []string {
+	out := make([]string, 0, len(contentTypes))
+	for f := range contentTypes {
+		out = append(out, f)
+	}
+	slices.Sort(out)
+	return out
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// writeError answers with a structured error; errors without a status are internal.
+func writeError(w http.ResponseWriter, err error) {
+	var re *requestError
+	if !errors.As(err, &re) {
+		re = &requestError{status: http.StatusInternalServerError, code: "internal", err: err}
+	}
+	writeJSON(w, re.status, struct {
+		Error Error `json:"error"`
+	}{Error{Code: re.code, Message: re.Error()}})
+}
+
+func ptr[T any](v T) *T { return &v }
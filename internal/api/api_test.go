@@ -0,0 +1,143 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"chordparser/internal/parser"
+)
+
+func post(t *testing.T, h http.Handler, path, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func decodeResponse(t *testing.T, rec *httptest.ResponseRecorder) Response {
+	t.Helper()
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+	var resp Response
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	return resp
+}
+
+func TestAPI_Parse(t *testing.T) {
+	t.Parallel()
+	h := New(Options{})
+	resp := decodeResponse(t, post(t, h, "/parse", `{"chart": "{title: Song}\nVerse 1\n[G]La (x2)\n"}`))
+	if resp.Song.Title != "Song" || len(resp.Song.Sections) != 1 {
+		t.Fatalf("song = %#v", resp.Song)
+	}
+	s := resp.Song.Sections[0]
+	if s.Header != "VERSE 1" || s.Content[0] != "[G]La (x2)" || s.Lines[0].Line != 3 || resp.Chart != nil {
+		t.Fatalf("parse response = %#v", resp)
+	}
+}
+
+func TestAPI_CleanRewritesOnlyTouchedLines(t *testing.T) {
+	t.Parallel()
+	h := New(Options{})
+	chart := "[Verse 1]  \r\nAmazing  grace\r\nHow sweet (x2)\r\n"
+	body, _ := json.Marshal(Request{Chart: chart})
+	resp := decodeResponse(t, post(t, h, "/clean", string(body)))
+	if resp.Chart == nil || *resp.Chart != "[Verse 1]  \r\nAmazing  grace\r\nHow sweet\r\n" || resp.Changed != 1 {
+		t.Fatalf("clean response = %#v", resp)
+	}
+	if got := resp.Song.Sections[0].Content; len(got) != 2 || got[1] != "How sweet" {
+		t.Fatalf("cleaned content = %q", got)
+	}
+}
+
+func TestAPI_Transpose(t *testing.T) {
+	t.Parallel()
+	h := New(Options{})
+	resp := decodeResponse(t, post(t, h, "/transpose", `{"chart": "{key: G}\nVerse\n[G]Amazing [D/F#]grace\n", "to": "A"}`))
+	if resp.Chart == nil || *resp.Chart != "{key: A}\nVerse\n[A]Amazing [E/G#]grace\n" || resp.Song.Key != "A" {
+		t.Fatalf("transpose response = %#v", resp)
+	}
+
+	rec := post(t, h, "/transpose", `{"chart": "Verse\n[G]La\n", "to": "A"}`)
+	if rec.Code != http.StatusUnprocessableEntity || !strings.Contains(rec.Body.String(), `"invalid_key"`) {
+		t.Fatalf("transpose without a key: %d %s", rec.Code, rec.Body)
+	}
+}
+
+func TestAPI_Export(t *testing.T) {
+	t.Parallel()
+	h := New(Options{})
+	rec := post(t, h, "/export/chordpro", `{"chart": "Verse\nLa x2\n", "title": "Song"}`)
+	if rec.Code != http.StatusOK || !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/plain") {
+		t.Fatalf("export: %d %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	if want := "{title: Song}\n"; !strings.HasPrefix(rec.Body.String(), want) || strings.Contains(rec.Body.String(), "x2") {
+		t.Fatalf("export body:\n%s", rec.Body)
+	}
+
+	rec = post(t, h, "/export/json", `{"chart": "Verse\nLa x2 (To Chorus)\n"}`)
+	var sections []parser.Section
+	if err := json.Unmarshal(rec.Body.Bytes(), &sections); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("json export: %d %v\n%s", rec.Code, err, rec.Body)
+	}
+	if len(sections) != 1 || !reflect.DeepEqual(sections[0].Content, []string{"La"}) {
+		t.Fatalf("json export is not cleaned: %#v", sections)
+	}
+}
+
+func TestAPI_Errors(t *testing.T) {
+	t.Parallel()
+	h := New(Options{MaxBody: 64})
+	cases := []struct {
+		name, method, path, contentType, body string
+		status                                int
+		code                                  string
+	}{
+		{"too large", "POST", "/parse", "application/json", `{"chart": "` + strings.Repeat("la ", 50) + `"}`, 413, "too_large"},
+		{"not json", "POST", "/parse", "text/plain", "Verse", 415, "unsupported_media_type"},
+		{"invalid json", "POST", "/clean", "application/json", `{"chart": 1}`, 400, "invalid_json"},
+		{"unknown field", "POST", "/clean", "application/json", `{"chart": "a", "x": 1}`, 400, "invalid_json"},
+		{"no chart", "POST", "/parse", "application/json", `{}`, 400, "missing_chart"},
+		{"language", "POST", "/parse", "application/json", `{"chart": "a", "lang": "xx"}`, 400, "unsupported_language"},
+		{"import", "POST", "/parse", "application/json", `{"chart": "<x", "from": "openlyrics"}`, 422, "import_failed"},
+		{"format", "POST", "/export/pdf", "application/json", `{"chart": "a"}`, 404, "unsupported_format"},
+		{"method", "GET", "/parse", "", "", 405, "method_not_allowed"},
+		{"path", "POST", "/nothing", "application/json", `{"chart": "a"}`, 404, "not_found"},
+	}
+	for _, c := range cases {
+		req := httptest.NewRequest(c.method, c.path, strings.NewReader(c.body))
+		req.Header.Set("Content-Type", c.contentType)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		var body struct{ Error Error }
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatalf("%s: decode %q: %v", c.name, rec.Body, err)
+		}
+		if rec.Code != c.status || body.Error.Code != c.code || body.Error.Message == "" {
+			t.Fatalf("%s: got %d %+v, want %d %s", c.name, rec.Code, body.Error, c.status, c.code)
+		}
+	}
+}
+
+func TestAPI_OpenAPI(t *testing.T) {
+	t.Parallel()
+	rec := httptest.NewRecorder()
+	New(Options{}).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/openapi.yaml", nil))
+	if rec.Code != http.StatusOK || !strings.HasPrefix(rec.Body.String(), "openapi: 3") {
+		t.Fatalf("openapi: %d", rec.Code)
+	}
+	for _, path := range []string{"/parse:", "/clean:", "/transpose:", "/export/{format}:"} {
+		if !strings.Contains(rec.Body.String(), path) {
+			t.Fatalf("openapi.yaml does not describe %s", path)
+		}
+	}
+}
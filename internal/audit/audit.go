@@ -0,0 +1,156 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// Outcomes of a sync action.
+const (
+	Succeeded = "succeeded"
+	Failed    = "failed"
+	Skipped   = "skipped"
+	Conflict  = "conflict"
+)
+
+// Entry is one sync action in the audit log.
+type Entry struct {
+	Time          time.Time `json:"time"`
+	Operator      string    `json:"operator"`
+	Action        string    `json:"action"` // e.g. "sync", "patch", "rollback"
+	SongID        string    `json:"song_id"`
+	ArrangementID string    `json:"arrangement_id"`
+	// Rules lists the cleaning rules that changed the chart.
+	Rules      []string `json:"rules,omitempty"`
+	BeforeHash string   `json:"before_hash,omitempty"`
+	AfterHash  string   `json:"after_hash,omitempty"`
+	HTTPStatus int      `json:"http_status,omitempty"`
+	Outcome    string   `json:"outcome"`
+	Error      string   `json:"error,omitempty"`
+}
+
+// Log is an append-only JSON Lines audit log. It is safe for concurrent use.
+type Log struct {
+	mu       sync.Mutex
+	f        *os.File
+	operator string
+	now      func() time.Time
+}
+
+// Open opens the audit log at path for appending, creating it if needed.
+// Entries without an operator are recorded with the given operator.
+func Open(path, operator string) (*Log, error) {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("audit: %w", err)
+	}
+	return &Log{f: f, operator: operator, now: time.Now}, nil
+}
+
+// Record appends an entry as one line and syncs it to disk. A zero Time is set to now.
+func (l *Log) Record(e Entry) error {
+	if e.Time.IsZero() {
+		e.Time = l.now()
+	}
+	e.Time = e.Time.UTC()
+	if e.Operator == "" {
+		e.Operator = l.operator
+	}
+	line, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("audit: %w", err)
+	}
+	line = append(line, '\n')
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	// One write per entry, so concurrent writers (even other processes, thanks to O_APPEND)
+	// never interleave lines
+	if _, err := l.f.Write(line); err != nil {
+		return fmt.Errorf("audit: %w", err)
+	}
+	if err := l.f.Sync(); err != nil {
+		return fmt.Errorf("audit: %w", err)
+	}
+	return nil
+}
+
+// Close closes the log file.
+func (l *Log) Close() error {
+	return l.f.Close()
+}
+
+// Filter selects entries in Query. Empty fields match everything.
+type Filter struct {
+	SongID  string
+	Outcome string
+	// From and To bound the entry time (From inclusive, To exclusive).
+	From time.Time
+	To   time.Time
+}
+
+// Match reports whether an entry passes the filter.
+func (f Filter) Match(e Entry) bool {
+	switch {
+	case f.SongID != "" && e.SongID != f.SongID:
+		return false
+	case f.Outcome != "" && e.Outcome != f.Outcome:
+		return false
+	case !f.From.IsZero() && e.Time.Before(f.From):
+		return false
+	case !f.To.IsZero() && !e.Time.Before(f.To):
+		return false
+	}
+	return true
+}
+
+// Query reads a JSON Lines audit log and returns the entries matching f, in log order.
+// A truncated last line (e.g., after a crash while writing) is ignored.
+func Query(r io.Reader, f Filter) ([]Entry, error) {
+	var entries []Entry
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	lineNo := 0
+	var pending error
+	for sc.Scan() {
+		lineNo++
+		if pending != nil {
+			// A bad line in the middle of the log is corruption, not a torn write
+			return nil, pending
+		}
+		if len(sc.Bytes()) == 0 {
+			continue
+		}
+		var e Entry
+		if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+			pending = fmt.Errorf("audit: line %d: %w", lineNo, err)
+			continue
+		}
+		if f.Match(e) {
+			entries = append(entries, e)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("audit: %w", err)
+	}
+	return entries, nil
+}
+
+// QueryFile is like Query for the log file at path. A missing log has no entries.
+func QueryFile(path string, f Filter) ([]Entry, error) {
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("audit: %w", err)
+	}
+	defer file.Close()
+	return Query(file, f)
+}
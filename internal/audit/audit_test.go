@@ -0,0 +1,120 @@
+package audit
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLog_RecordAndQuery(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	l, err := Open(path, "jan")
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	day := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	entries := []Entry{
+		{Time: day, Action: "patch", SongID: "1", ArrangementID: "10", Rules: []string{"repeat-markers"}, BeforeHash: "a", AfterHash: "b", HTTPStatus: 200, Outcome: Succeeded},
+		{Time: day.Add(24 * time.Hour), Action: "patch", SongID: "2", ArrangementID: "20", HTTPStatus: 409, Outcome: Conflict, Error: "modified"},
+		{Time: day.Add(48 * time.Hour), Operator: "piet", Action: "rollback", SongID: "1", ArrangementID: "10", HTTPStatus: 200, Outcome: Succeeded},
+	}
+	for _, e := range entries {
+		if err := l.Record(e); err != nil {
+			t.Fatalf("Record: %v", err)
+		}
+	}
+	if err := l.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	all, err := QueryFile(path, Filter{})
+	if err != nil || len(all) != 3 {
+		t.Fatalf("QueryFile = %d entries, %v", len(all), err)
+	}
+	if all[0].Operator != "jan" || all[2].Operator != "piet" || all[0].Rules[0] != "repeat-markers" {
+		t.Fatalf("unexpected entries: %#v", all)
+	}
+
+	cases := []struct {
+		name   string
+		filter Filter
+		want   int
+	}{
+		{"song", Filter{SongID: "1"}, 2},
+		{"outcome", Filter{Outcome: Conflict}, 1},
+		{"date range", Filter{From: day.Add(time.Hour), To: day.Add(48 * time.Hour)}, 1},
+		{"combined", Filter{SongID: "1", From: day.Add(time.Hour)}, 1},
+	}
+	for _, c := range cases {
+		got, err := QueryFile(path, c.filter)
+		if err != nil || len(got) != c.want {
+			t.Fatalf("%s: got %d entries, %v; want %d", c.name, len(got), err, c.want)
+		}
+	}
+}
+
+func TestLog_AppendOnlyAcrossOpens(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	for i := range 2 {
+		l, err := Open(path, "op")
+		if err != nil {
+			t.Fatalf("Open: %v", err)
+		}
+		_ = l.Record(Entry{SongID: fmt.Sprint(i), Outcome: Succeeded})
+		l.Close()
+	}
+	data, _ := os.ReadFile(path)
+	if n := strings.Count(string(data), "\n"); n != 2 {
+		t.Fatalf("expected 2 lines, got %d:\n%s", n, data)
+	}
+}
+
+func TestLog_ConcurrentRecords(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	l, err := Open(path, "op")
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	var wg sync.WaitGroup
+	for i := range 50 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = l.Record(Entry{SongID: fmt.Sprint(i), Outcome: Succeeded})
+		}()
+	}
+	wg.Wait()
+	l.Close()
+	got, err := QueryFile(path, Filter{})
+	if err != nil || len(got) != 50 {
+		t.Fatalf("QueryFile = %d entries, %v", len(got), err)
+	}
+}
+
+func TestQuery_TruncatedLastLine(t *testing.T) {
+	t.Parallel()
+	log := `{"song_id":"1","outcome":"succeeded"}` + "\n" + `{"song_id":"2","outc`
+	got, err := Query(strings.NewReader(log), Filter{})
+	if err != nil || len(got) != 1 {
+		t.Fatalf("Query = %#v, %v", got, err)
+	}
+	log = `{"song_id":"1"` + "\n" + `{"song_id":"2"}` + "\n"
+	if _, err := Query(strings.NewReader(log), Filter{}); err == nil {
+		t.Fatalf("expected an error for a corrupt line in the middle")
+	}
+}
+
+func TestQueryFile_Missing(t *testing.T) {
+	t.Parallel()
+	got, err := QueryFile(filepath.Join(t.TempDir(), "none.jsonl"), Filter{})
+	if err != nil || got != nil {
+		t.Fatalf("QueryFile = %#v, %v", got, err)
+	}
+}
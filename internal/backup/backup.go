@@ -0,0 +1,185 @@
+package backup
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// runIDLayout formats the start of run IDs as sortable UTC timestamps, e.g. "20260318T101500Z".
+const runIDLayout = "20060102T150405Z"
+
+// Snapshot is the original state of an arrangement, taken before the sync modified it.
+type Snapshot struct {
+	RunID         string   `json:"run_id"`
+	SongID        string   `json:"song_id"`
+	ArrangementID string   `json:"arrangement_id"`
+	ChordChart    string   `json:"chord_chart"`
+	Sequence      []string `json:"sequence"`
+	Lyrics        string   `json:"lyrics"`
+	// UpdatedAt is the arrangement's updated_at before the change.
+	UpdatedAt time.Time `json:"updated_at"`
+	// AppliedAt is the arrangement's updated_at after the change was written
+	// (zero if the change was never applied).
+	AppliedAt time.Time `json:"applied_at,omitzero"`
+	// TakenAt is when the snapshot was stored.
+	TakenAt time.Time `json:"taken_at"`
+}
+
+// Archive is a local backup archive: one directory per run holding one JSON file per arrangement.
+type Archive struct {
+	dir string
+	now func() time.Time
+	mu  sync.Mutex // guards the holds file
+}
+
+// Open opens (or creates) the archive in dir.
+func Open(dir string) (*Archive, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("backup: %w", err)
+	}
+	return &Archive{dir: dir, now: time.Now}, nil
+}
+
+// NewRunID returns the ID of a run started at t: its time to the second and a random suffix,
+// e.g. "20260318T101500Z-3f9a1c", so that runs started in the same second get their own.
+func NewRunID(t time.Time) string {
+	var suffix [3]byte
+	rand.Read(suffix[:])
+	return t.UTC().Format(runIDLayout) + "-" + hex.EncodeToString(suffix[:])
+}
+
+// Save stores a snapshot under its run, before the arrangement is modified.
+// TakenAt is set to the current time. A run holds one snapshot per arrangement: if it
+// already has one, Save leaves it alone and returns an error matching fs.ErrExist.
+func (a *Archive) Save(s Snapshot) error {
+	if s.RunID == "" || s.ArrangementID == "" {
+		return errors.New("backup: snapshot needs a run and arrangement ID")
+	}
+	s.TakenAt = a.now().UTC()
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("backup: %w", err)
+	}
+	p := a.path(s.RunID, s.ArrangementID)
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return fmt.Errorf("backup: %w", err)
+	}
+	tmp := p + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("backup: %w", err)
+	}
+	defer os.Remove(tmp)
+	// Linking, unlike renaming, fails if the snapshot exists
+	if err := os.Link(tmp, p); err != nil {
+		if errors.Is(err, fs.ErrExist) {
+			return fmt.Errorf("backup: run %s already has a snapshot of arrangement %s: %w", s.RunID, s.ArrangementID, fs.ErrExist)
+		}
+		return fmt.Errorf("backup: %w", err)
+	}
+	return nil
+}
+
+// Applied records the arrangement's updated_at after the change was written, so a rollback
+// can tell whether someone else modified the arrangement since.
+func (a *Archive) Applied(runID, arrangementID string, updatedAt time.Time) error {
+	s, err := a.read(a.path(runID, arrangementID))
+	if err != nil {
+		return err
+	}
+	s.AppliedAt = updatedAt
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("backup: %w", err)
+	}
+	return writeFile(a.path(runID, arrangementID), data)
+}
+
+// Selection picks the snapshots to roll back. Empty fields match everything.
+type Selection struct {
+	ArrangementID string
+	RunID         string
+	// Since selects snapshots taken at or after this time.
+	Since time.Time
+}
+
+// Snapshots returns the snapshots matching sel, ordered by the time they were taken.
+func (a *Archive) Snapshots(sel Selection) ([]Snapshot, error) {
+	pattern := filepath.Join(a.dir, "*", "*.json")
+	if sel.RunID != "" {
+		pattern = filepath.Join(a.dir, sel.RunID, "*.json")
+	}
+	paths, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("backup: %w", err)
+	}
+	var snaps []Snapshot
+	for _, p := range paths {
+		s, err := a.read(p)
+		if err != nil {
+			return nil, err
+		}
+		if sel.ArrangementID != "" && s.ArrangementID != sel.ArrangementID {
+			continue
+		}
+		if !sel.Since.IsZero() && s.TakenAt.Before(sel.Since) {
+			continue
+		}
+		snaps = append(snaps, s)
+	}
+	sort.SliceStable(snaps, func(i, j int) bool {
+		if !snaps[i].TakenAt.Equal(snaps[j].TakenAt) {
+			return snaps[i].TakenAt.Before(snaps[j].TakenAt)
+		}
+		return snaps[i].ArrangementID < snaps[j].ArrangementID
+	})
+	return snaps, nil
+}
+
+func (a *Archive) path(runID, arrangementID string) string {
+	return filepath.Join(a.dir, runID, fileName(arrangementID)+".json")
+}
+
+// writeFile replaces a file atomically (temporary file and rename), so a crash never leaves
+// half a backup.
+func writeFile(p string, data []byte) error {
+	tmp := p + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("backup: %w", err)
+	}
+	if err := os.Rename(tmp, p); err != nil {
+		return fmt.Errorf("backup: %w", err)
+	}
+	return nil
+}
+
+func (a *Archive) read(p string) (Snapshot, error) {
+	var s Snapshot
+	data, err := os.ReadFile(p)
+	if err != nil {
+		return s, fmt.Errorf("backup: %w", err)
+	}
+	if err := json.Unmarshal(data, &s); err != nil {
+		return s, fmt.Errorf("backup: %s: %w", p, err)
+	}
+	return s, nil
+}
+
+// fileName makes an ID safe to use as a file name.
+func fileName(id string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '/' || r == '\\' || r == ':' || r < ' ' {
+			return '_'
+		}
+		return r
+	}, id)
+}
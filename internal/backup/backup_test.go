@@ -0,0 +1,102 @@
+package backup
+
+import (
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestArchive returns an archive whose clock advances one minute per snapshot from start.
+func newTestArchive(t *testing.T, start time.Time) *Archive {
+	t.Helper()
+	a, err := Open(t.TempDir())
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	clock := start
+	a.now = func() time.Time {
+		clock = clock.Add(time.Minute)
+		return clock
+	}
+	return a
+}
+
+func TestArchive_SaveAndSelect(t *testing.T) {
+	t.Parallel()
+	start := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	a := newTestArchive(t, start)
+	run1, run2 := NewRunID(start), NewRunID(start.Add(24*time.Hour))
+	if !strings.HasPrefix(run1, "20260301T100000Z-") || NewRunID(start) == run1 {
+		t.Fatalf("NewRunID = %q, want a unique ID starting with the time", run1)
+	}
+
+	for _, s := range []Snapshot{
+		{RunID: run1, SongID: "1", ArrangementID: "10", ChordChart: "orig 10"},
+		{RunID: run1, SongID: "2", ArrangementID: "20", ChordChart: "orig 20"},
+		{RunID: run2, SongID: "1", ArrangementID: "10", ChordChart: "second 10"},
+	} {
+		if err := a.Save(s); err != nil {
+			t.Fatalf("Save: %v", err)
+		}
+	}
+	if err := a.Applied(run1, "10", start.Add(time.Hour)); err != nil {
+		t.Fatalf("Applied: %v", err)
+	}
+
+	all, err := a.Snapshots(Selection{})
+	if err != nil || len(all) != 3 || all[0].ChordChart != "orig 10" || all[0].AppliedAt.IsZero() {
+		t.Fatalf("Snapshots(all) = %#v, %v", all, err)
+	}
+	if got, _ := a.Snapshots(Selection{RunID: run1}); len(got) != 2 {
+		t.Fatalf("run selection = %#v", got)
+	}
+	if got, _ := a.Snapshots(Selection{ArrangementID: "10"}); len(got) != 2 || got[1].ChordChart != "second 10" {
+		t.Fatalf("arrangement selection = %#v", got)
+	}
+	if got, _ := a.Snapshots(Selection{Since: start.Add(3 * time.Minute)}); len(got) != 1 || got[0].RunID != run2 {
+		t.Fatalf("since selection = %#v", got)
+	}
+
+	tmp, _ := filepath.Glob(filepath.Join(a.dir, "*", "*.tmp"))
+	if len(tmp) != 0 {
+		t.Fatalf("temporary files left behind: %v", tmp)
+	}
+}
+
+func TestArchive_SaveRequiresIDs(t *testing.T) {
+	t.Parallel()
+	a := newTestArchive(t, time.Now())
+	if err := a.Save(Snapshot{RunID: "r"}); err == nil {
+		t.Fatalf("expected an error without arrangement ID")
+	}
+}
+
+func TestArchive_SaveKeepsTheFirstSnapshot(t *testing.T) {
+	t.Parallel()
+	a := newTestArchive(t, time.Now())
+	if err := a.Save(Snapshot{RunID: "r", ArrangementID: "10", ChordChart: "original"}); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	err := a.Save(Snapshot{RunID: "r", ArrangementID: "10", ChordChart: "cleaned"})
+	if !errors.Is(err, fs.ErrExist) {
+		t.Fatalf("second Save: expected an exists error, got %v", err)
+	}
+	if snaps, _ := a.Snapshots(Selection{RunID: "r"}); len(snaps) != 1 || snaps[0].ChordChart != "original" {
+		t.Fatalf("snapshots = %#v", snaps)
+	}
+	if tmp, _ := filepath.Glob(filepath.Join(a.dir, "*", "*.tmp")); len(tmp) != 0 {
+		t.Fatalf("temporary files left behind: %v", tmp)
+	}
+}
+
+func TestArchive_AppliedUnknownSnapshot(t *testing.T) {
+	t.Parallel()
+	a := newTestArchive(t, time.Now())
+	err := a.Applied("r", "missing", time.Now())
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("expected a not-exist error, got %v", err)
+	}
+}
@@ -0,0 +1,114 @@
+package backup
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// holdsFile lists the held arrangements. It sits next to the run directories, out of reach of
+// the snapshot pattern.
+const holdsFile = "holds.json"
+
+// Hold marks an arrangement that was rolled back: syncs leave it alone until an operator
+// releases it, so the restored chart is not cleaned again.
+type Hold struct {
+	SongID        string `json:"song_id"`
+	ArrangementID string `json:"arrangement_id"`
+	// RunID is the run whose change was rolled back.
+	RunID  string    `json:"run_id"`
+	HeldAt time.Time `json:"held_at"`
+}
+
+// Hold holds an arrangement, replacing an earlier hold. HeldAt is set to the current time.
+func (a *Archive) Hold(h Hold) error {
+	if h.ArrangementID == "" {
+		return errors.New("backup: hold needs an arrangement ID")
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	holds, err := a.readHolds()
+	if err != nil {
+		return err
+	}
+	h.HeldAt = a.now().UTC()
+	holds[h.ArrangementID] = h
+	return a.writeHolds(holds)
+}
+
+// Held reports whether an arrangement is held.
+func (a *Archive) Held(arrangementID string) (bool, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	holds, err := a.readHolds()
+	if err != nil {
+		return false, err
+	}
+	_, ok := holds[arrangementID]
+	return ok, nil
+}
+
+// Holds returns the held arrangements, ordered by the time they were held.
+func (a *Archive) Holds() ([]Hold, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	holds, err := a.readHolds()
+	if err != nil {
+		return nil, err
+	}
+	list := make([]Hold, 0, len(holds))
+	for _, h := range holds {
+		list = append(list, h)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if !list[i].HeldAt.Equal(list[j].HeldAt) {
+			return list[i].HeldAt.Before(list[j].HeldAt)
+		}
+		return list[i].ArrangementID < list[j].ArrangementID
+	})
+	return list, nil
+}
+
+// Release releases a held arrangement, so the next sync cleans it again.
+func (a *Archive) Release(arrangementID string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	holds, err := a.readHolds()
+	if err != nil {
+		return err
+	}
+	if _, ok := holds[arrangementID]; !ok {
+		return fmt.Errorf("backup: arrangement %s is not held", arrangementID)
+	}
+	delete(holds, arrangementID)
+	return a.writeHolds(holds)
+}
+
+func (a *Archive) readHolds() (map[string]Hold, error) {
+	holds := map[string]Hold{}
+	p := filepath.Join(a.dir, holdsFile)
+	data, err := os.ReadFile(p)
+	if errors.Is(err, fs.ErrNotExist) {
+		return holds, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("backup: %w", err)
+	}
+	if err := json.Unmarshal(data, &holds); err != nil {
+		return nil, fmt.Errorf("backup: %s: %w", p, err)
+	}
+	return holds, nil
+}
+
+func (a *Archive) writeHolds(holds map[string]Hold) error {
+	data, err := json.MarshalIndent(holds, "", "  ")
+	if err != nil {
+		return fmt.Errorf("backup: %w", err)
+	}
+	return writeFile(filepath.Join(a.dir, holdsFile), data)
+}
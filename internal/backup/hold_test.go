@@ -0,0 +1,46 @@
+package backup
+
+import (
+	"testing"
+	"time"
+)
+
+func TestArchive_HoldAndRelease(t *testing.T) {
+	t.Parallel()
+	a := newTestArchive(t, time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC))
+	if held, err := a.Held("10"); err != nil || held {
+		t.Fatalf("Held before any hold = %v, %v", held, err)
+	}
+	for _, h := range []Hold{
+		{SongID: "2", ArrangementID: "20", RunID: "r1"},
+		{SongID: "1", ArrangementID: "10", RunID: "r1"},
+	} {
+		if err := a.Hold(h); err != nil {
+			t.Fatalf("Hold: %v", err)
+		}
+	}
+	holds, err := a.Holds()
+	if err != nil || len(holds) != 2 || holds[0].ArrangementID != "20" || holds[1].HeldAt.IsZero() {
+		t.Fatalf("Holds = %#v, %v", holds, err)
+	}
+	// The holds file is not mistaken for a run
+	if snaps, err := a.Snapshots(Selection{}); err != nil || len(snaps) != 0 {
+		t.Fatalf("Snapshots = %#v, %v", snaps, err)
+	}
+
+	if err := a.Release("20"); err != nil {
+		t.Fatalf("Release: %v", err)
+	}
+	if held, _ := a.Held("20"); held {
+		t.Fatalf("arrangement 20 still held after Release")
+	}
+	if held, _ := a.Held("10"); !held {
+		t.Fatalf("arrangement 10 should stay held")
+	}
+	if err := a.Release("20"); err == nil {
+		t.Fatalf("expected an error releasing an arrangement that is not held")
+	}
+	if err := a.Hold(Hold{SongID: "1"}); err == nil {
+		t.Fatalf("expected an error without arrangement ID")
+	}
+}
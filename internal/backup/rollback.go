@@ -0,0 +1,143 @@
+package backup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrConflict is returned when an arrangement was modified by someone else after the change
+// that is being rolled back, or while it is being written.
+var ErrConflict = errors.New("arrangement was modified since the change")
+
+// Arrangement holds the arrangement fields the sync modifies.
+type Arrangement struct {
+	ChordChart string
+	Sequence   []string
+	Lyrics     string
+	UpdatedAt  time.Time
+}
+
+// Writer reads and conditionally writes arrangements (the PCO writer).
+type Writer interface {
+	// Get returns the current state of an arrangement.
+	Get(ctx context.Context, songID, arrangementID string) (Arrangement, error)
+	// Patch writes the chord chart, sequence and lyrics and returns the new updated_at. It
+	// returns ErrConflict instead if the arrangement's updated_at no longer equals ifUpdatedAt;
+	// PCO has no conditional write, so this is a best-effort check before writing.
+	Patch(ctx context.Context, songID, arrangementID string, a Arrangement, ifUpdatedAt time.Time) (time.Time, error)
+}
+
+// Restore is the outcome of rolling back one arrangement.
+type Restore struct {
+	Snapshot Snapshot
+	// Skipped is set when the change was never applied, so there is nothing to roll back.
+	Skipped bool
+	Err     error
+}
+
+// Rollback restores the selected arrangements to their state before the sync changed them.
+// When an arrangement was changed several times within the selection, the oldest snapshot
+// (its original state) is restored. Like the writer, it only writes when the arrangement is
+// unchanged since the sync's last change to it; otherwise the result is ErrConflict. Restored
+// arrangements are held (see Archive.Hold), so that syncs do not clean them again.
+func Rollback(ctx context.Context, a *Archive, sel Selection, w Writer) ([]Restore, error) {
+	snaps, err := a.Snapshots(sel)
+	if err != nil {
+		return nil, err
+	}
+
+	// Oldest snapshot per arrangement, and the updated_at after the latest applied change
+	type target struct {
+		oldest    Snapshot
+		appliedAt time.Time
+	}
+	var order []string
+	targets := map[string]*target{}
+	for _, s := range snaps {
+		t, ok := targets[s.ArrangementID]
+		if !ok {
+			t = &target{oldest: s}
+			targets[s.ArrangementID] = t
+			order = append(order, s.ArrangementID)
+		}
+		if !s.AppliedAt.IsZero() {
+			t.appliedAt = s.AppliedAt
+		}
+	}
+
+	results := make([]Restore, 0, len(order))
+	for _, id := range order {
+		if err := ctx.Err(); err != nil {
+			return results, err
+		}
+		t := targets[id]
+		res := Restore{Snapshot: t.oldest}
+		if t.appliedAt.IsZero() {
+			res.Skipped = true
+		} else {
+			res.Err = restore(ctx, w, t.oldest, t.appliedAt)
+			if res.Err == nil {
+				res.Err = a.Hold(Hold{SongID: t.oldest.SongID, ArrangementID: id, RunID: t.oldest.RunID})
+			}
+		}
+		results = append(results, res)
+	}
+	return results, nil
+}
+
+// restore writes a snapshot back if the arrangement still has the updated_at of the sync's change.
+func restore(ctx context.Context, w Writer, s Snapshot, appliedAt time.Time) error {
+	cur, err := w.Get(ctx, s.SongID, s.ArrangementID)
+	if err != nil {
+		return err
+	}
+	if !cur.UpdatedAt.Equal(appliedAt) {
+		return fmt.Errorf("%w (updated_at %s, expected %s)", ErrConflict, cur.UpdatedAt.Format(time.RFC3339), appliedAt.Format(time.RFC3339))
+	}
+	_, err = w.Patch(ctx, s.SongID, s.ArrangementID, Arrangement{
+		ChordChart: s.ChordChart,
+		Sequence:   s.Sequence,
+		Lyrics:     s.Lyrics,
+	}, appliedAt)
+	return err
+}
+
+// Writer returns a Writer that saves a snapshot of every arrangement under runID before writing
+// it and records the write with Applied, so that rollbacks can be undone like syncs.
+func (a *Archive) Writer(w Writer, runID string) Writer {
+	return &backedUpWriter{w: w, archive: a, runID: runID}
+}
+
+type backedUpWriter struct {
+	w       Writer
+	archive *Archive
+	runID   string
+}
+
+func (b *backedUpWriter) Get(ctx context.Context, songID, arrangementID string) (Arrangement, error) {
+	return b.w.Get(ctx, songID, arrangementID)
+}
+
+func (b *backedUpWriter) Patch(ctx context.Context, songID, arrangementID string, a Arrangement, ifUpdatedAt time.Time) (time.Time, error) {
+	cur, err := b.w.Get(ctx, songID, arrangementID)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if !cur.UpdatedAt.Equal(ifUpdatedAt) {
+		return time.Time{}, fmt.Errorf("%w (updated_at %s, expected %s)", ErrConflict, cur.UpdatedAt.Format(time.RFC3339), ifUpdatedAt.Format(time.RFC3339))
+	}
+	err = b.archive.Save(Snapshot{
+		RunID: b.runID, SongID: songID, ArrangementID: arrangementID,
+		ChordChart: cur.ChordChart, Sequence: cur.Sequence, Lyrics: cur.Lyrics, UpdatedAt: cur.UpdatedAt,
+	})
+	if err != nil {
+		return time.Time{}, err
+	}
+	updated, err := b.w.Patch(ctx, songID, arrangementID, a, ifUpdatedAt)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return updated, b.archive.Applied(b.runID, arrangementID, updated)
+}
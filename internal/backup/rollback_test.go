@@ -0,0 +1,134 @@
+package backup
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// fakeWriter keeps arrangements in memory and bumps updated_at on every patch.
+type fakeWriter struct {
+	arrangements map[string]Arrangement
+	patches      int
+}
+
+func (f *fakeWriter) Get(_ context.Context, _, id string) (Arrangement, error) {
+	a, ok := f.arrangements[id]
+	if !ok {
+		return a, errors.New("not found")
+	}
+	return a, nil
+}
+
+func (f *fakeWriter) Patch(_ context.Context, _, id string, a Arrangement, ifUpdatedAt time.Time) (time.Time, error) {
+	cur := f.arrangements[id]
+	if !cur.UpdatedAt.Equal(ifUpdatedAt) {
+		return time.Time{}, ErrConflict
+	}
+	f.patches++
+	a.UpdatedAt = cur.UpdatedAt.Add(time.Second)
+	f.arrangements[id] = a
+	return a.UpdatedAt, nil
+}
+
+func TestRollback(t *testing.T) {
+	t.Parallel()
+	start := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	a := newTestArchive(t, start)
+	run1, run2 := NewRunID(start), NewRunID(start.Add(time.Hour))
+	t1, t2, t3 := start.Add(time.Hour), start.Add(2*time.Hour), start.Add(3*time.Hour)
+
+	w := &fakeWriter{arrangements: map[string]Arrangement{
+		// Changed twice by the sync; the second change is the current state
+		"10": {ChordChart: "clean twice", UpdatedAt: t2},
+		// Changed by the sync, then edited by someone in PCO
+		"20": {ChordChart: "edited by hand", UpdatedAt: t3},
+	}}
+	_ = a.Save(Snapshot{RunID: run1, SongID: "1", ArrangementID: "10", ChordChart: "original", Sequence: []string{"V1", "C"}})
+	_ = a.Applied(run1, "10", t1)
+	_ = a.Save(Snapshot{RunID: run2, SongID: "1", ArrangementID: "10", ChordChart: "clean once", UpdatedAt: t1})
+	_ = a.Applied(run2, "10", t2)
+	_ = a.Save(Snapshot{RunID: run1, SongID: "2", ArrangementID: "20", ChordChart: "original 20"})
+	_ = a.Applied(run1, "20", t1)
+	// Backed up but the PATCH never happened
+	_ = a.Save(Snapshot{RunID: run2, SongID: "3", ArrangementID: "30", ChordChart: "untouched"})
+
+	results, err := Rollback(context.Background(), a, Selection{}, w)
+	if err != nil {
+		t.Fatalf("Rollback: %v", err)
+	}
+	if len(results) != 3 {
+		t.Fatalf("expected 3 results, got %#v", results)
+	}
+
+	if results[0].Err != nil || results[0].Snapshot.ArrangementID != "10" {
+		t.Fatalf("arrangement 10: %#v", results[0])
+	}
+	got := w.arrangements["10"]
+	if got.ChordChart != "original" || !reflect.DeepEqual(got.Sequence, []string{"V1", "C"}) {
+		t.Fatalf("arrangement 10 not restored to its original state: %#v", got)
+	}
+
+	if !errors.Is(results[1].Err, ErrConflict) || w.arrangements["20"].ChordChart != "edited by hand" {
+		t.Fatalf("arrangement 20 should conflict and stay untouched: %#v", results[1])
+	}
+	if !results[2].Skipped {
+		t.Fatalf("arrangement 30 was never changed and should be skipped: %#v", results[2])
+	}
+	if w.patches != 1 {
+		t.Fatalf("patches = %d, want 1", w.patches)
+	}
+	// Only the restored arrangement is held
+	if holds, err := a.Holds(); err != nil || len(holds) != 1 || holds[0].ArrangementID != "10" || holds[0].RunID != run1 {
+		t.Fatalf("Holds = %#v, %v", holds, err)
+	}
+}
+
+func TestRollback_SingleRun(t *testing.T) {
+	t.Parallel()
+	start := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	a := newTestArchive(t, start)
+	run1, run2 := NewRunID(start), NewRunID(start.Add(time.Hour))
+	t1, t2 := start.Add(time.Hour), start.Add(2*time.Hour)
+
+	w := &fakeWriter{arrangements: map[string]Arrangement{"10": {ChordChart: "clean twice", UpdatedAt: t2}}}
+	_ = a.Save(Snapshot{RunID: run1, ArrangementID: "10", ChordChart: "original"})
+	_ = a.Applied(run1, "10", t1)
+	_ = a.Save(Snapshot{RunID: run2, ArrangementID: "10", ChordChart: "clean once"})
+	_ = a.Applied(run2, "10", t2)
+
+	results, err := Rollback(context.Background(), a, Selection{RunID: run2}, w)
+	if err != nil || len(results) != 1 || results[0].Err != nil {
+		t.Fatalf("Rollback = %#v, %v", results, err)
+	}
+	if got := w.arrangements["10"].ChordChart; got != "clean once" {
+		t.Fatalf("rolling back run 2 should restore the state before run 2, got %q", got)
+	}
+}
+
+func TestRollback_BackedUpWriterCanBeUndone(t *testing.T) {
+	t.Parallel()
+	start := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	a := newTestArchive(t, start)
+	sync, undo := NewRunID(start), NewRunID(start.Add(time.Hour))
+	t1 := start.Add(time.Hour)
+
+	w := &fakeWriter{arrangements: map[string]Arrangement{"10": {ChordChart: "cleaned", UpdatedAt: t1}}}
+	_ = a.Save(Snapshot{RunID: sync, ArrangementID: "10", ChordChart: "original"})
+	_ = a.Applied(sync, "10", t1)
+
+	results, err := Rollback(context.Background(), a, Selection{RunID: sync}, a.Writer(w, undo))
+	if err != nil || len(results) != 1 || results[0].Err != nil || w.arrangements["10"].ChordChart != "original" {
+		t.Fatalf("Rollback = %#v, %v", results, err)
+	}
+	// The rollback itself was backed up, so rolling it back restores the cleaned chart
+	snaps, err := a.Snapshots(Selection{RunID: undo})
+	if err != nil || len(snaps) != 1 || snaps[0].ChordChart != "cleaned" || !snaps[0].AppliedAt.Equal(w.arrangements["10"].UpdatedAt) {
+		t.Fatalf("rollback snapshots = %#v, %v", snaps, err)
+	}
+	if _, err := Rollback(context.Background(), a, Selection{RunID: undo}, w); err != nil || w.arrangements["10"].ChordChart != "cleaned" {
+		t.Fatalf("undoing the rollback: %v, %#v", err, w.arrangements["10"])
+	}
+}
@@ -0,0 +1,256 @@
+package batch
+
+import (
+	"bytes"
+	"chordparser/internal/pool"
+	"chordparser/internal/state"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Options selects the files of a batch run.
+type Options struct {
+	// Include lists glob patterns (e.g., "*.cho") of files to process; empty means all files.
+	// Patterns without a slash match the file name, others the slash-separated path relative to the root.
+	Include []string
+	// Exclude lists glob patterns of files to skip, matched like Include.
+	Exclude []string
+	// OutDir is the output directory of the run. When it lies inside the root it is not walked,
+	// so a rerun never takes the outputs of the previous run as inputs.
+	OutDir string
+}
+
+// RunOptions configures how Run processes the files.
+type RunOptions struct {
+	// Pool sets the number of workers and the shared rate limiter.
+	Pool pool.Options
+	// State, if set, makes the run incremental: files whose modification time, content,
+	// conversion options and output path are unchanged since their last successful conversion
+	// (and whose output still exists) are skipped, and files interrupted by a crash are converted
+	// again. Records are keyed on the absolute root and the relative path, so one state file can
+	// serve several directories.
+	State *state.Store
+	// OptionsHash identifies the conversion options (e.g., a hash of the output format and flags);
+	// files converted with other options are converted again.
+	OptionsHash string
+}
+
+// ConvertFunc converts the chart read from the source file at path name and writes the result to w.
+type ConvertFunc func(name string, data []byte, w io.Writer) error
+
+// Result is the outcome of one file in a batch run.
+type Result struct {
+	// Path is the source path relative to the root (slash-separated).
+	Path string
+	// Output is the path of the written file (empty if it failed).
+	Output string
+	// Skipped is set when the file was unchanged since the last run (see RunOptions.State).
+	Skipped bool
+	Err     error
+}
+
+// Files walks root recursively and returns the paths (relative, slash-separated, sorted)
+// of the files selected by opts. Hidden files and directories such as ".git" and the output
+// directory (Options.OutDir) are skipped.
+func Files(root string, opts Options) ([]string, error) {
+	for _, p := range append(append([]string{}, opts.Include...), opts.Exclude...) {
+		if _, err := path.Match(p, ""); err != nil {
+			return nil, fmt.Errorf("batch: invalid pattern %q: %w", p, err)
+		}
+	}
+	outDir := ""
+	if opts.OutDir != "" {
+		var err error
+		if outDir, err = filepath.Abs(opts.OutDir); err != nil {
+			return nil, err
+		}
+	}
+
+	var files []string
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if p != root && strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() && outDir != "" {
+			if abs, err := filepath.Abs(p); err == nil && abs == outDir && p != root {
+				return filepath.SkipDir
+			}
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if (len(opts.Include) == 0 || Match(opts.Include, rel)) && !Match(opts.Exclude, rel) {
+			files = append(files, rel)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// Match reports whether the relative path matches any of the glob patterns.
+func Match(patterns []string, rel string) bool {
+	for _, p := range patterns {
+		target := rel
+		if !strings.Contains(p, "/") {
+			target = path.Base(rel)
+		}
+		if ok, _ := path.Match(p, target); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// OutputPath mirrors a relative source path into outDir with the given extension,
+// e.g. "hymns/grace.cho" with ".json" gives "<outDir>/hymns/grace.json".
+func OutputPath(outDir, rel, ext string) string {
+	rel = strings.TrimSuffix(rel, path.Ext(rel)) + ext
+	return filepath.Join(outDir, filepath.FromSlash(rel))
+}
+
+// CheckOutputs reports source files that would be written to the same output path, such as
+// "song.cho" and "song.txt" both giving "song.json". Run them separately or rename a file.
+func CheckOutputs(outDir, ext string, files []string) error {
+	var errs []error
+	seen := make(map[string]string, len(files))
+	for _, rel := range files {
+		dst := OutputPath(outDir, rel, ext)
+		if first, ok := seen[dst]; ok {
+			errs = append(errs, fmt.Errorf("batch: %s and %s would both be written to %s", first, rel, dst))
+			continue
+		}
+		seen[dst] = rel
+	}
+	return errors.Join(errs...)
+}
+
+// Run converts each file (relative to root) and writes the result to its mirrored path
+// in outDir, on the worker pool configured by opts.Pool. Results are in the order of files.
+// A failing file does not stop the run; its error is reported in its Result.
+// Output files are only written when the conversion succeeds; callers should reject colliding
+// output paths with CheckOutputs first. After ctx is cancelled,
+// files that have not started yet fail with the context error.
+func Run(ctx context.Context, root, outDir, ext string, files []string, opts RunOptions, convert ConvertFunc) []Result {
+	// The absolute root keeps records of different directories with the same layout apart
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		absRoot = root
+	}
+	return pool.Map(ctx, files, opts.Pool, func(_ context.Context, rel string) Result {
+		return runOne(root, outDir, ext, rel, StateKey(absRoot, rel), opts, convert)
+	}, func(rel string, err error) Result {
+		return Result{Path: rel, Err: err}
+	})
+}
+
+// StateKey returns the state record ID of a file: the slash-separated absolute root joined with
+// the relative path, e.g. "/srv/charts/hymns/grace.cho".
+func StateKey(absRoot, rel string) string {
+	return path.Join(filepath.ToSlash(absRoot), rel)
+}
+
+// runOne converts a single file, consulting and updating the state store if there is one.
+func runOne(root, outDir, ext, rel, key string, opts RunOptions, convert ConvertFunc) Result {
+	st := opts.State
+	res := Result{Path: rel}
+	src := filepath.Join(root, filepath.FromSlash(rel))
+	dst := OutputPath(outDir, rel, ext)
+	fi, err := os.Stat(src)
+	if err != nil {
+		res.Err = err
+		return res
+	}
+	data, err := os.ReadFile(src)
+	if err != nil {
+		res.Err = err
+		return res
+	}
+
+	if st != nil {
+		src := state.Source{UpdatedAt: fi.ModTime().UTC(), OriginalHash: state.Hash(data), OptionsHash: opts.OptionsHash, Output: dst}
+		changed, err := st.Changed(key, src)
+		if err != nil {
+			res.Err = err
+			return res
+		}
+		if _, statErr := os.Stat(dst); !changed && statErr == nil {
+			res.Output, res.Skipped = dst, true
+			return res
+		}
+		if err := st.Begin(key, src); err != nil {
+			res.Err = err
+			return res
+		}
+	}
+
+	out, err := convertTo(src, dst, data, convert)
+	if st != nil {
+		if finishErr := st.Finish(key, state.Hash(out), err); err == nil {
+			err = finishErr
+		}
+	}
+	if err != nil {
+		res.Err = err
+		return res
+	}
+	res.Output = dst
+	return res
+}
+
+// convertTo converts data and writes the result to dst, creating directories as needed.
+// It returns the converted output.
+func convertTo(src, dst string, data []byte, convert ConvertFunc) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := convert(src, data, &buf); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(dst, buf.Bytes(), 0o644); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// WriteSummary writes one line per file and a total, and returns the number of failed files.
+func WriteSummary(w io.Writer, results []Result) int {
+	failed, skipped := 0, 0
+	for _, r := range results {
+		switch {
+		case r.Err != nil:
+			failed++
+			fmt.Fprintf(w, "FAIL %s: %v\n", r.Path, r.Err)
+		case r.Skipped:
+			skipped++
+			fmt.Fprintf(w, "skip %s (unchanged)\n", r.Path)
+		default:
+			fmt.Fprintf(w, "ok   %s -> %s\n", r.Path, r.Output)
+		}
+	}
+	fmt.Fprintf(w, "%d files, %d succeeded, %d skipped, %d failed\n", len(results), len(results)-failed-skipped, skipped, failed)
+	return failed
+}
@@ -0,0 +1,254 @@
+package batch
+
+import (
+	"bytes"
+	"chordparser/internal/pool"
+	"chordparser/internal/state"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// writeTree creates the given files (slash-separated relative paths) under a temporary root.
+func writeTree(t *testing.T, files map[string]string) string {
+	t.Helper()
+	root := t.TempDir()
+	for rel, content := range files {
+		p := filepath.Join(root, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+func TestFiles_GlobFilters(t *testing.T) {
+	t.Parallel()
+	root := writeTree(t, map[string]string{
+		"a.cho":               "",
+		"notes.md":            "",
+		"hymns/b.txt":         "",
+		"hymns/old/c.cho":     "",
+		"drafts/d.cho":        "",
+		".git/config":         "",
+		"hymns/.hidden.cho":   "",
+		"hymns/old/README.md": "",
+	})
+	got, err := Files(root, Options{Include: []string{"*.cho", "*.txt"}, Exclude: []string{"drafts/*"}})
+	if err != nil {
+		t.Fatalf("Files: %v", err)
+	}
+	want := []string{"a.cho", "hymns/b.txt", "hymns/old/c.cho"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Files = %v, want %v", got, want)
+	}
+
+	all, err := Files(root, Options{})
+	if err != nil {
+		t.Fatalf("Files: %v", err)
+	}
+	if len(all) != 6 {
+		t.Fatalf("expected all 6 visible files, got %v", all)
+	}
+}
+
+func TestFiles_SkipsOutputDirectory(t *testing.T) {
+	t.Parallel()
+	root := writeTree(t, map[string]string{
+		"a.cho":           "",
+		"out/a.json":      "",
+		"out/hymns/b.cho": "",
+		"hymns/out/c.cho": "",
+	})
+	got, err := Files(root, Options{OutDir: filepath.Join(root, "out")})
+	if err != nil {
+		t.Fatalf("Files: %v", err)
+	}
+	// Only the output directory itself is skipped, not other directories of the same name
+	if want := []string{"a.cho", "hymns/out/c.cho"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("Files = %v, want %v", got, want)
+	}
+}
+
+func TestFiles_InvalidPattern(t *testing.T) {
+	t.Parallel()
+	if _, err := Files(t.TempDir(), Options{Include: []string{"[a"}}); err == nil {
+		t.Fatalf("expected an error for an invalid pattern")
+	}
+}
+
+func TestRun_MirrorsOutputAndReportsFailures(t *testing.T) {
+	t.Parallel()
+	root := writeTree(t, map[string]string{
+		"a.cho":       "one",
+		"hymns/b.txt": "two",
+		"bad.cho":     "fail",
+	})
+	out := t.TempDir()
+	files, err := Files(root, Options{})
+	if err != nil {
+		t.Fatalf("Files: %v", err)
+	}
+	results := Run(context.Background(), root, out, ".json", files, RunOptions{Pool: pool.Options{Workers: 2}}, func(name string, data []byte, w io.Writer) error {
+		if string(data) == "fail" {
+			return errors.New("boom")
+		}
+		_, err := io.WriteString(w, strings.ToUpper(string(data)))
+		return err
+	})
+
+	if got, err := os.ReadFile(filepath.Join(out, "hymns", "b.json")); err != nil || string(got) != "TWO" {
+		t.Fatalf("mirrored output = %q, %v", got, err)
+	}
+	if _, err := os.Stat(filepath.Join(out, "bad.json")); !os.IsNotExist(err) {
+		t.Fatalf("failed file should not produce output, stat err = %v", err)
+	}
+
+	var buf bytes.Buffer
+	if failed := WriteSummary(&buf, results); failed != 1 {
+		t.Fatalf("failed = %d, want 1", failed)
+	}
+	summary := buf.String()
+	for _, want := range []string{"ok   a.cho -> ", "FAIL bad.cho: boom", "3 files, 2 succeeded, 0 skipped, 1 failed"} {
+		if !strings.Contains(summary, want) {
+			t.Fatalf("summary misses %q:\n%s", want, summary)
+		}
+	}
+}
+
+func TestCheckOutputs_Collisions(t *testing.T) {
+	t.Parallel()
+	out := t.TempDir()
+	if err := CheckOutputs(out, ".json", []string{"a.cho", "hymns/a.cho", "b.json"}); err != nil {
+		t.Fatalf("distinct outputs: %v", err)
+	}
+	err := CheckOutputs(out, ".json", []string{"hymns/song.cho", "hymns/song.txt", "song.cho", "x.cho", "x.onsong", "x.txt"})
+	if err == nil {
+		t.Fatalf("expected an error for colliding outputs")
+	}
+	for _, want := range []string{
+		"hymns/song.cho and hymns/song.txt would both be written to " + filepath.Join(out, "hymns", "song.json"),
+		"x.cho and x.onsong", "x.cho and x.txt",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Fatalf("error misses %q:\n%v", want, err)
+		}
+	}
+	if strings.Count(err.Error(), "\n") != 2 {
+		t.Fatalf("files in different directories do not collide:\n%v", err)
+	}
+}
+
+func TestRun_ConcurrentDeterministicOrder(t *testing.T) {
+	t.Parallel()
+	files := map[string]string{}
+	for i := range 40 {
+		files[fmt.Sprintf("dir%d/song%02d.cho", i%3, i)] = strings.Repeat("x", i)
+	}
+	root := writeTree(t, files)
+	list, err := Files(root, Options{})
+	if err != nil {
+		t.Fatalf("Files: %v", err)
+	}
+	results := Run(context.Background(), root, t.TempDir(), ".txt", list, RunOptions{Pool: pool.Options{Workers: 8}}, func(_ string, data []byte, w io.Writer) error {
+		_, err := fmt.Fprintf(w, "%d", len(data))
+		return err
+	})
+	for i, r := range results {
+		if r.Path != list[i] || r.Err != nil {
+			t.Fatalf("result %d = %+v, want %s without error", i, r, list[i])
+		}
+	}
+}
+
+func TestRun_CancelledContext(t *testing.T) {
+	t.Parallel()
+	root := writeTree(t, map[string]string{"a.cho": "a"})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	results := Run(ctx, root, t.TempDir(), ".txt", []string{"a.cho"}, RunOptions{}, func(string, []byte, io.Writer) error {
+		t.Error("convert should not run after cancellation")
+		return nil
+	})
+	if !errors.Is(results[0].Err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", results[0].Err)
+	}
+}
+
+func TestRun_IncrementalWithState(t *testing.T) {
+	t.Parallel()
+	root := writeTree(t, map[string]string{"a.cho": "one", "b.cho": "two"})
+	out := t.TempDir()
+	st, err := state.Open(filepath.Join(t.TempDir(), "state.db"))
+	if err != nil {
+		t.Fatalf("state.Open: %v", err)
+	}
+	defer st.Close()
+
+	var converted []string
+	convert := func(name string, data []byte, w io.Writer) error {
+		converted = append(converted, filepath.Base(name))
+		_, err := w.Write(data)
+		return err
+	}
+	opts := RunOptions{State: st}
+	files := []string{"a.cho", "b.cho"}
+
+	Run(context.Background(), root, out, ".txt", files, opts, convert)
+	if len(converted) != 2 {
+		t.Fatalf("first run should convert everything, converted %v", converted)
+	}
+
+	// Unchanged files are skipped; an edited file and a file whose output was removed are converted again
+	converted = nil
+	if err := os.WriteFile(filepath.Join(root, "b.cho"), []byte("edited"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	results := Run(context.Background(), root, out, ".txt", files, opts, convert)
+	if !results[0].Skipped || results[1].Skipped || !reflect.DeepEqual(converted, []string{"b.cho"}) {
+		t.Fatalf("second run: results %+v, converted %v", results, converted)
+	}
+	absRoot, _ := filepath.Abs(root)
+	rec, _, _ := st.Get(StateKey(absRoot, "b.cho"))
+	if rec.Outcome != state.Succeeded || rec.CleanedHash != state.Hash([]byte("edited")) {
+		t.Fatalf("unexpected record: %#v", rec)
+	}
+
+	converted = nil
+	if err := os.Remove(filepath.Join(out, "a.txt")); err != nil {
+		t.Fatal(err)
+	}
+	Run(context.Background(), root, out, ".txt", files, opts, convert)
+	if !reflect.DeepEqual(converted, []string{"a.cho"}) {
+		t.Fatalf("third run converted %v, want [a.cho]", converted)
+	}
+
+	// Other conversion options, another output directory or another root with the same layout
+	// convert everything again
+	for name, run := range map[string]func() []Result{
+		"options": func() []Result {
+			return Run(context.Background(), root, out, ".txt", files, RunOptions{State: st, OptionsHash: "html"}, convert)
+		},
+		"output": func() []Result {
+			return Run(context.Background(), root, t.TempDir(), ".txt", files, RunOptions{State: st, OptionsHash: "html"}, convert)
+		},
+		"root": func() []Result {
+			other := writeTree(t, map[string]string{"a.cho": "one", "b.cho": "edited"})
+			return Run(context.Background(), other, out, ".txt", files, RunOptions{State: st, OptionsHash: "html"}, convert)
+		},
+	} {
+		converted = nil
+		if results := run(); results[0].Skipped || results[1].Skipped || len(converted) != 2 {
+			t.Fatalf("%s: results %+v, converted %v", name, results, converted)
+		}
+	}
+}
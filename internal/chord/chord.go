@@ -0,0 +1,105 @@
+package chord
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Triad is the basic quality of a chord, used to match it against a key.
+type Triad int
+
+const (
+	Major Triad = iota
+	Minor
+	Diminished
+	Augmented
+	// Suspended covers sus and power chords, which have no third.
+	Suspended
+)
+
+// Chord is a parsed chord symbol.
+type Chord struct {
+	// Root is the pitch class of the root (0 = C, 1 = C#/Db, ... 11 = B).
+	Root int
+	// Triad is the chord's basic quality.
+	Triad Triad
+	// Bass is the pitch class of a slash bass note, or -1 if there is none.
+	Bass int
+}
+
+// Pitch classes of the natural notes.
+var naturals = map[byte]int{'C': 0, 'D': 2, 'E': 4, 'F': 5, 'G': 7, 'A': 9, 'B': 11}
+
+var (
+	// Chord grammar: root, quality, extension, modifiers and an optional slash bass,
+	// e.g. "C", "F#m7", "Bbmaj7#11", "Am7b5", "Dsus4", "G7(b9)", "C/E", "Cm(maj7)".
+	reChord = regexp.MustCompile(`^([A-G])([#b]?)` +
+		`(maj|Maj|ma|M|Δ|min|mi|m|-|dim|°|o|aug|\+|ø)?` +
+		`(\d{1,2})?` +
+		`((?:sus[24]?|add\d{1,2}|[#b](?:5|9|11|13)|maj\d{0,2}|alt|no3|\((?:[#b]?\d{1,2}|maj\d{0,2}|add\d{1,2})(?:,\s*(?:[#b]?\d{1,2}))*\))*)` +
+		`(?:/([A-G])([#b]?))?$`)
+	// Chord extensions and added tones that may follow the quality
+	validExtensions = map[int]bool{2: true, 4: true, 5: true, 6: true, 7: true, 9: true, 11: true, 13: true, 69: true}
+)
+
+// NoChord reports whether the symbol marks a passage without chords ("N.C.", "NC").
+func NoChord(s string) bool {
+	switch strings.ToUpper(strings.TrimSpace(s)) {
+	case "N.C.", "N.C", "NC", "N/C":
+		return true
+	}
+	return false
+}
+
+// Parse parses a chord symbol (without brackets).
+func Parse(s string) (Chord, error) {
+	m := reChord.FindStringSubmatch(s)
+	if m == nil {
+		return Chord{}, fmt.Errorf("chord: cannot parse %q", s)
+	}
+	c := Chord{Root: pitch(m[1], m[2]), Bass: -1}
+	if m[4] != "" {
+		n, _ := strconv.Atoi(m[4])
+		if !validExtensions[n] {
+			return Chord{}, fmt.Errorf("chord: %q has an unknown extension %d", s, n)
+		}
+	}
+	switch m[3] {
+	case "min", "mi", "m", "-":
+		c.Triad = Minor
+	case "dim", "°", "o", "ø":
+		c.Triad = Diminished
+	case "aug", "+":
+		c.Triad = Augmented
+	}
+	if m[3] == "" && (m[4] == "5" || strings.Contains(m[5], "sus")) {
+		c.Triad = Suspended
+	}
+	if m[6] != "" {
+		c.Bass = pitch(m[6], m[7])
+	}
+	return c, nil
+}
+
+// Valid reports whether s is a chord symbol the grammar accepts (or a no-chord marker).
+func Valid(s string) bool {
+	if NoChord(s) {
+		return true
+	}
+	_, err := Parse(s)
+	return err == nil
+}
+
+// pitch returns the pitch class of a note letter with an optional accidental.
+func pitch(letter, accidental string) int {
+	p := naturals[letter[0]]
+	switch accidental {
+	case "#":
+		p++
+	case "b":
+		p--
+	}
+	return (p + 12) % 12
+}
@@ -0,0 +1,89 @@
+package chord
+
+import "testing"
+
+func TestParse_Valid(t *testing.T) {
+	t.Parallel()
+	cases := map[string]Chord{
+		"C":         {Root: 0, Triad: Major, Bass: -1},
+		"F#m7":      {Root: 6, Triad: Minor, Bass: -1},
+		"Bbmaj7#11": {Root: 10, Triad: Major, Bass: -1},
+		"Am7b5":     {Root: 9, Triad: Minor, Bass: -1},
+		"Bdim7":     {Root: 11, Triad: Diminished, Bass: -1},
+		"Dsus4":     {Root: 2, Triad: Suspended, Bass: -1},
+		"G7sus4":    {Root: 7, Triad: Suspended, Bass: -1},
+		"E5":        {Root: 4, Triad: Suspended, Bass: -1},
+		"Caug":      {Root: 0, Triad: Augmented, Bass: -1},
+		"G7(b9)":    {Root: 7, Triad: Major, Bass: -1},
+		"Cm(maj7)":  {Root: 0, Triad: Minor, Bass: -1},
+		"Cadd9":     {Root: 0, Triad: Major, Bass: -1},
+		"C2":        {Root: 0, Triad: Major, Bass: -1},
+		"D/F#":      {Root: 2, Triad: Major, Bass: 6},
+		"Ebm7/Db":   {Root: 3, Triad: Minor, Bass: 1},
+	}
+	for in, want := range cases {
+		got, err := Parse(in)
+		if err != nil || got != want {
+			t.Fatalf("Parse(%q) = %+v, %v; want %+v", in, got, err, want)
+		}
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	t.Parallel()
+	for _, in := range []string{"Cmj7", "H", "Bb#", "C8", "Chorus", "c", "G/H", ""} {
+		if _, err := Parse(in); err == nil {
+			t.Fatalf("Parse(%q) should fail", in)
+		}
+	}
+	if !Valid("N.C.") || Valid("Cmj7") {
+		t.Fatalf("Valid: no-chord markers are valid, typos are not")
+	}
+}
+
+func TestParseKey(t *testing.T) {
+	t.Parallel()
+	cases := map[string]Key{
+		"G":       {Tonic: 7},
+		"Bb":      {Tonic: 10, Flats: true},
+		"F":       {Tonic: 5, Flats: true},
+		"F#m":     {Tonic: 6, Minor: true},
+		"Dm":      {Tonic: 2, Minor: true, Flats: true},
+		"A minor": {Tonic: 9, Minor: true},
+	}
+	for in, want := range cases {
+		got, err := ParseKey(in)
+		if err != nil || got != want {
+			t.Fatalf("ParseKey(%q) = %+v, %v; want %+v", in, got, err, want)
+		}
+	}
+	if _, err := ParseKey("X"); err == nil {
+		t.Fatalf("expected an error")
+	}
+}
+
+func TestKey_Fits(t *testing.T) {
+	t.Parallel()
+	g, _ := ParseKey("G")
+	em, _ := ParseKey("Em")
+	cases := []struct {
+		key   Key
+		chord string
+		want  bool
+	}{
+		{g, "G", true}, {g, "Am7", true}, {g, "Bm", true}, {g, "C/G", true}, {g, "D7", true}, {g, "F#dim", true},
+		{g, "Cm", true}, {g, "F", true}, {g, "Eb", true}, {g, "A7", true}, {g, "B7", true}, {g, "Dsus4", true},
+		{g, "C#", false}, {g, "Fm", false}, {g, "G#m", false}, {g, "Bbm", false},
+		{em, "Em", true}, {em, "B7", true}, {em, "D", true}, {em, "C", true}, {em, "F#dim", true}, {em, "A", true},
+		{em, "Fm", false},
+	}
+	for _, c := range cases {
+		ch, err := Parse(c.chord)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", c.chord, err)
+		}
+		if got := c.key.Fits(ch); got != c.want {
+			t.Fatalf("key %s: Fits(%q) = %v, want %v", c.key, c.chord, got, c.want)
+		}
+	}
+}
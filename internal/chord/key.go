@@ -0,0 +1,105 @@
+package chord
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Key is a song key.
+type Key struct {
+	// Tonic is the pitch class of the tonic.
+	Tonic int
+	Minor bool
+	// Flats is set for keys written with flats (F, Bb, Dm, ...), used to spell suggestions.
+	Flats bool
+}
+
+// Key names as found in PCO and {key:} directives, e.g. "G", "Bb", "F#m", "Ebmin", "A minor".
+var reKey = regexp.MustCompile(`^([A-G])([#b]?)\s*(m|min|minor|mi|-)?$`)
+
+// Keys written with flats (tonic pitch classes): F, Bb, Eb, Ab, Db, Gb and their relative minors.
+var (
+	flatMajors = map[int]bool{5: true, 10: true, 3: true, 8: true, 1: true, 6: true}
+	flatMinors = map[int]bool{2: true, 7: true, 0: true, 5: true, 10: true, 3: true}
+)
+
+// ParseKey parses a key name.
+func ParseKey(s string) (Key, error) {
+	m := reKey.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil {
+		return Key{}, fmt.Errorf("chord: cannot parse key %q", s)
+	}
+	k := Key{Tonic: pitch(m[1], m[2]), Minor: m[3] != ""}
+	switch {
+	case m[2] == "b":
+		k.Flats = true
+	case m[2] == "#":
+		k.Flats = false
+	case k.Minor:
+		k.Flats = flatMinors[k.Tonic]
+	default:
+		k.Flats = flatMajors[k.Tonic]
+	}
+	return k, nil
+}
+
+// degree is a chord built on a scale step (semitones above the tonic) with its triad.
+type degree struct {
+	step  int
+	triad Triad
+}
+
+// Diatonic chords per mode, and chords commonly borrowed or used as secondary dominants.
+var (
+	majorDiatonic = []degree{{0, Major}, {2, Minor}, {4, Minor}, {5, Major}, {7, Major}, {9, Minor}, {11, Diminished}}
+	// bIII, iv, bVI, bVII (modal mixture) and II, III, VI (V/V, V/vi, V/ii)
+	majorBorrowed = []degree{{3, Major}, {5, Minor}, {8, Major}, {10, Major}, {2, Major}, {4, Major}, {9, Major}}
+	minorDiatonic = []degree{{0, Minor}, {2, Diminished}, {3, Major}, {5, Minor}, {7, Minor}, {8, Major}, {10, Major}}
+	// V and vii° (harmonic minor), IV (dorian), I (picardy third) and bII (neapolitan)
+	minorBorrowed = []degree{{7, Major}, {11, Diminished}, {5, Major}, {0, Major}, {1, Major}}
+)
+
+// degrees returns the accepted chords of the key: diatonic and common borrowed chords.
+func (k Key) degrees() []degree {
+	if k.Minor {
+		return append(append([]degree{}, minorDiatonic...), minorBorrowed...)
+	}
+	return append(append([]degree{}, majorDiatonic...), majorBorrowed...)
+}
+
+// scale returns the pitch classes of the key's (natural) scale.
+func (k Key) scale() map[int]bool {
+	diatonic := majorDiatonic
+	if k.Minor {
+		diatonic = minorDiatonic
+	}
+	s := map[int]bool{}
+	for _, d := range diatonic {
+		s[(k.Tonic+d.step)%12] = true
+	}
+	return s
+}
+
+// Fits reports whether a chord belongs to the key: a diatonic or commonly borrowed chord.
+// Suspended and power chords fit when their root is in the scale; augmented chords when their
+// root is the root of an accepted major chord.
+func (k Key) Fits(c Chord) bool {
+	step := (c.Root - k.Tonic + 12) % 12
+	switch c.Triad {
+	case Suspended:
+		return k.scale()[c.Root]
+	case Augmented:
+		return k.accepts(step, Major)
+	}
+	return k.accepts(step, c.Triad)
+}
+
+func (k Key) accepts(step int, t Triad) bool {
+	for _, d := range k.degrees() {
+		if d.step == step && d.triad == t {
+			return true
+		}
+	}
+	return false
+}
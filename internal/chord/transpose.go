@@ -0,0 +1,101 @@
+package chord
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	// A root or bass note at the start of a chord symbol or after its slash
+	reNote = regexp.MustCompile(`^[A-G][#b]?`)
+	// Bracketed chords in a lyric line, e.g. "[G]Amazing [D/F#]grace"
+	reBracketed = regexp.MustCompile(`\[([^\[\]]+)\]`)
+)
+
+// Interval returns the number of semitones (0 to 11) up from key from to key to.
+func Interval(from, to Key) int {
+	return (to.Tonic - from.Tonic + 12) % 12
+}
+
+// Transpose moves a chord symbol up by the given number of semitones. The quality, extensions
+// and modifiers are kept as written; the root and bass are spelled with the accidentals of the
+// target key. No-chord markers are returned unchanged.
+func Transpose(symbol string, steps int, to Key) (string, error) {
+	if NoChord(symbol) {
+		return symbol, nil
+	}
+	if _, err := Parse(symbol); err != nil {
+		return "", err
+	}
+	move := func(note string) string {
+		return to.spell((pitch(note[:1], note[1:]) + steps%12 + 12) % 12)
+	}
+	root := reNote.FindString(symbol)
+	out := move(root) + symbol[len(root):]
+	if i := strings.LastIndexByte(out, '/'); i >= 0 {
+		bass := reNote.FindString(out[i+1:])
+		if bass == "" {
+			return "", fmt.Errorf("chord: cannot transpose the bass of %q", symbol)
+		}
+		out = out[:i+1] + move(bass)
+	}
+	return out, nil
+}
+
+// TransposeLine transposes the chords of a chart line: every bracketed chord ("[G]Amazing"),
+// or every chord of a line holding only chords ("G  D/F#  Em"), keeping the spacing. Tokens that
+// are not chords are left alone.
+func TransposeLine(line string, steps int, to Key) string {
+	if chordOnly(line) {
+		var b strings.Builder
+		for i := 0; i < len(line); {
+			j := i
+			for j < len(line) && line[j] != ' ' && line[j] != '\t' {
+				j++
+			}
+			if j > i {
+				b.WriteString(transposeToken(line[i:j], steps, to))
+			}
+			for i = j; i < len(line) && (line[i] == ' ' || line[i] == '\t'); i++ {
+				b.WriteByte(line[i])
+			}
+		}
+		return b.String()
+	}
+	return reBracketed.ReplaceAllStringFunc(line, func(m string) string {
+		return transposeToken(m, steps, to)
+	})
+}
+
+// chordOnly reports whether a line holds only chords (bare or bracketed) and bar lines.
+func chordOnly(line string) bool {
+	tokens := strings.Fields(line)
+	for _, t := range tokens {
+		if t != "|" && !Valid(unbracket(t)) {
+			return false
+		}
+	}
+	return len(tokens) > 0
+}
+
+// transposeToken transposes a bare or bracketed chord, or returns the token unchanged.
+func transposeToken(tok string, steps int, to Key) string {
+	inner := unbracket(tok)
+	out, err := Transpose(inner, steps, to)
+	if err != nil {
+		return tok
+	}
+	if inner != tok {
+		return "[" + out + "]"
+	}
+	return out
+}
+
+// unbracket strips the brackets around a chord token such as "[G]".
+func unbracket(tok string) string {
+	if len(tok) >= 2 && tok[0] == '[' && tok[len(tok)-1] == ']' {
+		return tok[1 : len(tok)-1]
+	}
+	return tok
+}
@@ -0,0 +1,52 @@
+package chord
+
+import "testing"
+
+func TestTranspose(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		in    string
+		steps int
+		to    string
+		want  string
+	}{
+		{"G", 2, "A", "A"},
+		{"D/F#", 2, "A", "E/G#"},
+		{"Em7", 3, "Bb", "Gm7"},
+		{"Cmaj7#11", 1, "Db", "Dbmaj7#11"},
+		{"F#m7b5", -2, "D", "Em7b5"},
+		{"Bbsus4/Ab", 1, "B", "Bsus4/A"},
+		{"N.C.", 5, "C", "N.C."},
+	}
+	for _, c := range cases {
+		key, err := ParseKey(c.to)
+		if err != nil {
+			t.Fatalf("ParseKey(%q): %v", c.to, err)
+		}
+		got, err := Transpose(c.in, c.steps, key)
+		if err != nil || got != c.want {
+			t.Fatalf("Transpose(%q, %d, %s) = %q, %v; want %q", c.in, c.steps, c.to, got, err, c.want)
+		}
+	}
+	if _, err := Transpose("Hm", 2, Key{}); err == nil {
+		t.Fatalf("Transpose of an invalid chord should fail")
+	}
+}
+
+func TestTransposeLine(t *testing.T) {
+	t.Parallel()
+	from, _ := ParseKey("G")
+	to, _ := ParseKey("Bb")
+	steps := Interval(from, to)
+	cases := map[string]string{
+		"[G]Amazing [D/F#]grace [Chorus]": "[Bb]Amazing [F/A]grace [Chorus]",
+		"G   D/F# |  Em\t[C]":             "Bb   F/A |  Gm\t[Eb]",
+		"Go tell it on the mountain":      "Go tell it on the mountain",
+		"":                                "",
+	}
+	for in, want := range cases {
+		if got := TransposeLine(in, steps, to); got != want {
+			t.Fatalf("TransposeLine(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package chord
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Kind is the kind of chord problem.
+type Kind string
+
+const (
+	// Invalid chords cannot be parsed by the chord grammar.
+	Invalid Kind = "invalid"
+	// OutOfKey chords are neither diatonic to the key nor a common borrowed chord.
+	OutOfKey Kind = "out-of-key"
+)
+
+// Problem is a chord that failed validation, with a suggested correction if one was found.
+type Problem struct {
+	Chord      string
+	Kind       Kind
+	Message    string
+	Suggestion string
+}
+
+var (
+	sharpNames = []string{"C", "C#", "D", "D#", "E", "F", "F#", "G", "G#", "A", "A#", "B"}
+	flatNames  = []string{"C", "Db", "D", "Eb", "E", "F", "Gb", "G", "Ab", "A", "Bb", "B"}
+)
+
+// Quality typos at the start of the chord suffix and their corrections.
+var qualityFixes = []struct{ from, to string }{
+	{"minor", "m"}, {"mj", "maj"}, {"Mj", "maj"}, {"MAJ", "maj"}, {"mn", "m"}, {"dom", ""},
+	{"Dim", "dim"}, {"DIM", "dim"}, {"Aug", "aug"}, {"AUG", "aug"}, {"Sus", "sus"}, {"SUS", "sus"},
+}
+
+// Check validates a chord symbol. With a key, chords outside the key are reported too;
+// key may be nil to only check the grammar. It reports false when the chord is fine.
+func Check(symbol string, key *Key) (Problem, bool) {
+	if NoChord(symbol) {
+		return Problem{}, false
+	}
+	c, err := Parse(symbol)
+	if err != nil {
+		p := Problem{Chord: symbol, Kind: Invalid, Message: fmt.Sprintf("%s is not a valid chord", symbol)}
+		if s, ok := Suggest(symbol, key); ok {
+			p.Suggestion = s
+			p.Message += fmt.Sprintf("; did you mean %s?", s)
+		}
+		return p, true
+	}
+	if key == nil || key.Fits(c) {
+		return Problem{}, false
+	}
+	p := Problem{Chord: symbol, Kind: OutOfKey, Message: fmt.Sprintf("%s is not in the key of %s", symbol, key)}
+	if s, ok := key.nearest(c); ok {
+		p.Suggestion = s
+		p.Message += fmt.Sprintf("; did you mean %s?", s)
+	}
+	return p, true
+}
+
+// Suggest proposes a correction for a chord symbol the grammar cannot parse: German "H" for B,
+// a lowercase root, conflicting or doubled accidentals ("Bb#" gives "B") and quality typos
+// ("Cmj7" gives "Cmaj7"). It reports false when no valid correction was found.
+func Suggest(symbol string, key *Key) (string, bool) {
+	flats := key != nil && key.Flats
+	main, bass, hasBass := strings.Cut(strings.TrimSpace(symbol), "/")
+	fixed, ok := fixPart(main, flats, true)
+	if !ok {
+		return "", false
+	}
+	if hasBass {
+		b, ok := fixPart(bass, flats, false)
+		if !ok {
+			return "", false
+		}
+		fixed += "/" + b
+	}
+	if fixed == symbol || !Valid(fixed) {
+		return "", false
+	}
+	return fixed, true
+}
+
+// fixPart corrects the root (and, for the main part, the quality) of a chord or bass note.
+func fixPart(s string, flats, main bool) (string, bool) {
+	if s == "" {
+		return "", false
+	}
+	letter := s[0]
+	switch {
+	case letter == 'H' || letter == 'h':
+		letter = 'B'
+	case letter >= 'a' && letter <= 'g':
+		letter -= 'a' - 'A'
+	}
+	natural, ok := naturals[letter]
+	if !ok {
+		return "", false
+	}
+
+	// Accidentals following the letter, e.g. "b#" or "##"
+	i, net := 1, 0
+	for i < len(s) && (s[i] == '#' || s[i] == 'b') {
+		i++
+	}
+	for _, r := range s[1:i] {
+		if r == '#' {
+			net++
+		} else {
+			net--
+		}
+	}
+	root := string(letter)
+	switch {
+	case net == 1 && i == 2:
+		root += "#"
+	case net == -1 && i == 2:
+		root += "b"
+	case net != 0:
+		names := sharpNames
+		if flats || net < 0 {
+			names = flatNames
+		}
+		root = names[(natural+net+12)%12]
+	}
+
+	rest := s[i:]
+	if !main {
+		return root, rest == ""
+	}
+	for _, f := range qualityFixes {
+		if strings.HasPrefix(rest, f.from) {
+			rest = f.to + rest[len(f.from):]
+			break
+		}
+	}
+	return root + rest, true
+}
+
+// String returns the key name, e.g. "G" or "Em".
+func (k Key) String() string {
+	name := k.spell(k.Tonic)
+	if k.Minor {
+		name += "m"
+	}
+	return name
+}
+
+// spell names a pitch class using the key's accidentals.
+func (k Key) spell(pc int) string {
+	if k.Flats {
+		return flatNames[pc]
+	}
+	return sharpNames[pc]
+}
+
+// nearest proposes an accepted chord for an out-of-key chord: the same root with another
+// quality, or otherwise a root one semitone away with the same quality, if there is exactly one.
+func (k Key) nearest(c Chord) (string, bool) {
+	var same, near []string
+	for _, d := range k.degrees() {
+		root := (k.Tonic + d.step) % 12
+		switch {
+		case root == c.Root && d.triad != c.Triad:
+			same = append(same, k.spell(root)+triadSuffix(d.triad))
+		case d.triad == c.Triad && (root == (c.Root+1)%12 || root == (c.Root+11)%12):
+			near = append(near, k.spell(root)+triadSuffix(d.triad))
+		}
+	}
+	if len(same) == 1 {
+		return same[0], true
+	}
+	if len(same) == 0 && len(near) == 1 {
+		return near[0], true
+	}
+	return "", false
+}
+
+// triadSuffix is the chord symbol suffix of a triad quality.
+func triadSuffix(t Triad) string {
+	switch t {
+	case Minor:
+		return "m"
+	case Diminished:
+		return "dim"
+	case Augmented:
+		return "aug"
+	}
+	return ""
+}
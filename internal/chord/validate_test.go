@@ -0,0 +1,69 @@
+package chord
+
+import "testing"
+
+func TestSuggest(t *testing.T) {
+	t.Parallel()
+	f, _ := ParseKey("F")
+	cases := []struct {
+		in   string
+		key  *Key
+		want string
+	}{
+		{"Cmj7", nil, "Cmaj7"},
+		{"H", nil, "B"},
+		{"Hm7", nil, "Bm7"},
+		{"Bb#", nil, "B"},
+		{"C##", nil, "D"},
+		{"Cbb", nil, "Bb"},
+		{"am", nil, "Am"},
+		{"Dminor", nil, "Dm"},
+		{"G/H", nil, "G/B"},
+		{"A#b", &f, "A"},
+		{"Ebb7", &f, "D7"},
+	}
+	for _, c := range cases {
+		got, ok := Suggest(c.in, c.key)
+		if !ok || got != c.want {
+			t.Fatalf("Suggest(%q) = %q, %v; want %q", c.in, got, ok, c.want)
+		}
+	}
+	for _, in := range []string{"Chorus", "X7", "C"} {
+		if got, ok := Suggest(in, nil); ok {
+			t.Fatalf("Suggest(%q) = %q, want no suggestion", in, got)
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	t.Parallel()
+	c, _ := ParseKey("C")
+	cases := []struct {
+		chord      string
+		key        *Key
+		kind       Kind
+		suggestion string
+		message    string
+	}{
+		{"Cmj7", nil, Invalid, "Cmaj7", "Cmj7 is not a valid chord; did you mean Cmaj7?"},
+		{"Xyz", &c, Invalid, "", "Xyz is not a valid chord"},
+		{"C#m", &c, OutOfKey, "Dm", "C#m is not in the key of C; did you mean Dm?"},
+		{"F#", &c, OutOfKey, "", "F# is not in the key of C"},
+		{"Bm", &c, OutOfKey, "Bdim", "Bm is not in the key of C; did you mean Bdim?"},
+		{"Gm", &c, OutOfKey, "G", "Gm is not in the key of C; did you mean G?"},
+	}
+	for _, tc := range cases {
+		p, ok := Check(tc.chord, tc.key)
+		if !ok || p.Kind != tc.kind || p.Suggestion != tc.suggestion || p.Message != tc.message {
+			t.Fatalf("Check(%q) = %+v, %v", tc.chord, p, ok)
+		}
+	}
+	for _, fine := range []string{"Am7", "F", "G7", "Bb", "N.C.", "Fm"} {
+		if p, ok := Check(fine, &c); ok {
+			t.Fatalf("Check(%q) reported %+v", fine, p)
+		}
+	}
+	if _, ok := Check("C#m", nil); ok {
+		t.Fatalf("without a key only the grammar is checked")
+	}
+}
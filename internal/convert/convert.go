@@ -0,0 +1,150 @@
+package convert
+
+import (
+	"chordparser/internal/exporter"
+	"chordparser/internal/importer"
+	"chordparser/internal/langdetect"
+	"chordparser/internal/normalize"
+	"chordparser/internal/parser"
+	"chordparser/internal/processor"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// Extensions maps every output format to the file extension used when writing into a directory.
+var Extensions = map[string]string{
+	"json":         ".json",
+	"chordpro":     ".cho",
+	"openlyrics":   ".xml",
+	"propresenter": ".txt",
+	"html":         ".html",
+}
+
+// Converter turns one chart into the selected output format. The CLI fills it from its flags
+// and the HTTP server from its requests.
+type Converter struct {
+	// Lang is the chart language: "auto" to detect, a code such as "nl", or empty for all languages.
+	Lang string
+	// From is the input format: "auto" to detect, "chordpro", "onsong", "opensong" or "openlyrics".
+	From string
+	// Abbrev also accepts abbreviated headers such as "V1", "C" or "PC".
+	Abbrev bool
+	// Format is the output format, one of the keys of Extensions.
+	Format string
+	// Columns selects the two-column layout for HTML.
+	Columns bool
+	// SlideLines is the maximum number of lyric lines per ProPresenter slide.
+	SlideLines int
+	// Title, Author, Key and CCLI override the song metadata when set.
+	Title, Author, Key, CCLI string
+	// Transpose is the key to transpose the song to, if any (see TransposeSong).
+	Transpose string
+}
+
+// Chart parses a plain chart into a lossless tree and returns the chart language (empty when
+// unknown or not restricted). Imported formats have no tree and give false.
+func (c Converter) Chart(name string, data []byte) (*parser.Tree, string, bool) {
+	if c.imported(name, data) {
+		return nil, "", false
+	}
+	text := string(data)
+	opts := parser.Options{Language: c.Lang, Abbreviations: c.Abbrev}
+	if normalize.LooksLikeHTML(text) {
+		// Rich-text chart (e.g., from a PCO field): convert and keep bold-only lines as header hints
+		text, opts.HeaderHints = normalize.HTMLToText(text)
+	}
+	if opts.Language == "auto" {
+		opts.Language = langdetect.Detect(text)
+	}
+	return parser.ParseTree(text, opts), opts.Language, true
+}
+
+// Read imports or parses a chart. It returns the song and the chart language
+// (empty when unknown or not restricted).
+func (c Converter) Read(name string, data []byte) (parser.Song, string, error) {
+	var song parser.Song
+	tree, language, ok := c.Chart(name, data)
+	if ok {
+		song = tree.Song()
+	} else {
+		format := importer.Format(c.From)
+		if c.From == "auto" {
+			format, _ = importer.DetectFormat(name, data)
+		}
+		opts := parser.Options{Language: c.Lang, Abbreviations: c.Abbrev}
+		if opts.Language == "auto" {
+			opts.Language = langdetect.Detect(string(data))
+		}
+		var err error
+		song, err = importer.ImportWith(format, data, opts)
+		if err != nil {
+			return song, "", fmt.Errorf("import error: %w", err)
+		}
+		language = opts.Language
+	}
+	setIfGiven(&song.Title, c.Title)
+	setIfGiven(&song.Author, c.Author)
+	setIfGiven(&song.Key, c.Key)
+	setIfGiven(&song.CCLI, c.CCLI)
+	if c.Transpose != "" {
+		if err := TransposeSong(&song, c.Transpose); err != nil {
+			return song, "", fmt.Errorf("transpose error: %w", err)
+		}
+	}
+	return song, language, nil
+}
+
+// imported reports whether the chart is in an import format rather than a plain chart.
+func (c Converter) imported(name string, data []byte) bool {
+	if c.From == "auto" {
+		_, imported := importer.DetectFormat(name, data)
+		return imported
+	}
+	return c.From != "chordpro"
+}
+
+// Write writes the song in the output format. Exports always carry the cleaned chart.
+func (c Converter) Write(w io.Writer, song parser.Song, language string) error {
+	song.Sections = processor.CleanSections(song.Sections, processor.Options{Language: language})
+	var err error
+	switch c.Format {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(song.Sections); err != nil {
+			return fmt.Errorf("json error: %w", err)
+		}
+		return nil
+	case "chordpro":
+		err = exporter.WriteChordPro(w, song)
+	case "openlyrics":
+		err = exporter.WriteOpenLyrics(w, song)
+	case "html":
+		err = exporter.WriteHTML(w, song, exporter.HTMLOptions{TwoColumns: c.Columns})
+	case "propresenter":
+		err = exporter.WriteProPresenter(w, song, exporter.ProPresenterOptions{MaxLines: c.SlideLines})
+	default:
+		err = fmt.Errorf("unsupported output format %q", c.Format)
+	}
+	if err != nil {
+		return fmt.Errorf("export error: %w", err)
+	}
+	return nil
+}
+
+// Convert reads a chart and writes it in the output format.
+func (c Converter) Convert(name string, data []byte, w io.Writer) error {
+	song, language, err := c.Read(name, data)
+	if err != nil {
+		return err
+	}
+	return c.Write(w, song, language)
+}
+
+// setIfGiven overrides a song field with a non-empty value.
+func setIfGiven(field *string, value string) {
+	if value != "" {
+		*field = value
+	}
+}
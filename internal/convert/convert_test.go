@@ -0,0 +1,75 @@
+package convert
+
+import (
+	"bytes"
+	"chordparser/internal/parser"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestConverter_ReadAndWrite(t *testing.T) {
+	t.Parallel()
+	c := Converter{From: "auto", Format: "chordpro", Title: "Song", Key: "G", Transpose: "A"}
+	var buf bytes.Buffer
+	if err := c.Convert("song.cho", []byte("Verse\n[G]La x2\nG  D\n"), &buf); err != nil {
+		t.Fatalf("Convert: %v", err)
+	}
+	want := "{title: Song}\n{key: A}\n\n{start_of_verse: Verse}\n[A]La\n[A] [E]\n{end_of_verse}\n"
+	if buf.String() != want {
+		t.Fatalf("unexpected output:\n%s\nwant:\n%s", buf.String(), want)
+	}
+
+	c = Converter{From: "auto", Transpose: "A"}
+	if _, _, err := c.Read("song.cho", []byte("Verse\n[G]La\n")); !errors.Is(err, ErrNoKey) {
+		t.Fatalf("Read without a key: %v, want ErrNoKey", err)
+	}
+	if _, _, ok := c.Chart("song.onsong", []byte("Title\n\nVerse 1:\nLa\n")); ok {
+		t.Fatalf("an OnSong chart should not give a tree")
+	}
+}
+
+func TestConverter_PlainChartWithBoldHeaders(t *testing.T) {
+	t.Parallel()
+	// Bold headers are plain-chart decorations, not rich text: the line structure must stay
+	chart := "<b>Verse 1</b>\n[G]Amazing grace how sweet\n<b>Chorus</b>\nline c"
+	song, _, err := Converter{From: "auto"}.Read("song.txt", []byte(chart))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []parser.Section{
+		{Header: "VERSE 1", Content: []string{"[G]Amazing grace how sweet"}},
+		{Header: "CHORUS", Content: []string{"line c"}},
+	}
+	if len(song.Sections) != 2 {
+		t.Fatalf("sections = %#v", song.Sections)
+	}
+	for i, s := range song.Sections {
+		if s.Header != want[i].Header || strings.Join(s.Content, "|") != strings.Join(want[i].Content, "|") {
+			t.Fatalf("section %d = %q %q, want %q %q", i, s.Header, s.Content, want[i].Header, want[i].Content)
+		}
+	}
+
+	// Rich text still goes through the HTML converter
+	song, _, err = Converter{From: "auto"}.Read("song.txt", []byte("<p><b>Chorus</b></p><p>Hallelujah&nbsp;amen</p>"))
+	if err != nil || len(song.Sections) != 1 || song.Sections[0].Header != "CHORUS" || song.Sections[0].Content[0] != "Hallelujah amen" {
+		t.Fatalf("rich text: %#v, %v", song.Sections, err)
+	}
+}
+
+func TestTransposeTree(t *testing.T) {
+	t.Parallel()
+	chart := "{key: G}\r\n<b>Chorus:</b> [G]Glory\r\n  G    C/G   \r\nSing [Em]loud (x2)\r\n"
+	tree := parser.ParseTree(chart, parser.Options{})
+	n, err := TransposeTree(tree, "G", "F")
+	if err != nil {
+		t.Fatalf("TransposeTree: %v", err)
+	}
+	want := "{key: F}\r\n<b>Chorus:</b> [F]Glory\r\n  F    Bb/F   \r\nSing [Dm]loud (x2)\r\n"
+	if got := tree.String(); got != want || n != 4 {
+		t.Fatalf("TransposeTree changed %d lines:\n%q\nwant\n%q", n, got, want)
+	}
+	if _, err := TransposeTree(tree, "G", "Q"); err == nil || !strings.Contains(err.Error(), "Q") {
+		t.Fatalf("expected an error for an invalid key, got %v", err)
+	}
+}
@@ -0,0 +1,84 @@
+package convert
+
+import (
+	"chordparser/internal/chord"
+	"chordparser/internal/parser"
+	"errors"
+	"regexp"
+)
+
+// A {key: ...} directive, with the key value as the second group
+var reKeyDirective = regexp.MustCompile(`^(\s*\{\s*key\s*:\s*)([^}]*?)(\s*\}\s*)$`)
+
+// ErrNoKey is returned when a song cannot be transposed because its key is unknown.
+var ErrNoKey = errors.New("the song key is unknown")
+
+// TransposeSong transposes every chord of the song from its key to the given key and sets
+// the new key.
+func TransposeSong(song *parser.Song, to string) error {
+	steps, target, err := interval(song.Key, to)
+	if err != nil {
+		return err
+	}
+	for i := range song.Sections {
+		for j, line := range song.Sections[i].Content {
+			song.Sections[i].Content[j] = chord.TransposeLine(line, steps, target)
+		}
+	}
+	song.Key = to
+	return nil
+}
+
+// TransposeTree transposes a chart tree in place, rewriting only the lines that hold chords
+// and any {key} directive. It returns the number of lines changed.
+func TransposeTree(t *parser.Tree, from, to string) (int, error) {
+	steps, target, err := interval(from, to)
+	if err != nil {
+		return 0, err
+	}
+	changed := 0
+	rewrite := func(l *parser.TreeLine) {
+		line := t.Fold(l.Text)
+		out := line
+		switch {
+		case l.Kind == parser.LineMeta:
+			if m := reKeyDirective.FindStringSubmatch(line); m != nil && m[2] != to {
+				out = m[1] + to + m[3]
+			}
+		case l.Kind == parser.LineContent || l.Rest != "":
+			out = chord.TransposeLine(line, steps, target)
+		}
+		if out != line {
+			l.Text = out
+			if l.Rest != "" {
+				l.Rest = chord.TransposeLine(l.Rest, steps, target)
+			}
+			changed++
+		}
+	}
+	for _, s := range t.Sections {
+		if s.Header != nil {
+			rewrite(s.Header)
+		}
+		for _, l := range s.Lines {
+			rewrite(l)
+		}
+	}
+	return changed, nil
+}
+
+// interval parses both keys and returns the semitones between them and the target key.
+func interval(from, to string) (int, chord.Key, error) {
+	if from == "" {
+		return 0, chord.Key{}, ErrNoKey
+	}
+	f, err := chord.ParseKey(from)
+	if err != nil {
+		return 0, chord.Key{}, err
+	}
+	k, err := chord.ParseKey(to)
+	if err != nil {
+		return 0, chord.Key{}, err
+	}
+	return chord.Interval(f, k), k, nil
+}
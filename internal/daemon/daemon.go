@@ -0,0 +1,118 @@
+package daemon
+
+import (
+	"chordparser/internal/schedule"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"time"
+)
+
+// Job is one run of the pipeline. It should stop starting new work when ctx is canceled and
+// report what it processed.
+type Job func(ctx context.Context) (Counts, error)
+
+// Options configures the daemon.
+type Options struct {
+	// Schedule says when runs start. Ticks missed while a run is in progress are skipped.
+	Schedule *schedule.Schedule
+	// LockFile prevents overlapping runs, also with other processes using the same file.
+	LockFile string
+	// History records a summary of every run; nil records nothing.
+	History *History
+	// Log receives progress messages; nil discards them.
+	Log *log.Logger
+
+	// now and after are replaced in tests.
+	now   func() time.Time
+	after func(time.Duration) <-chan time.Time
+}
+
+// Run runs the job on the schedule until ctx is canceled. A run in progress when ctx is
+// canceled is told to stop (through its context) and its summary is recorded before Run
+// returns nil. Failing runs do not stop the daemon.
+func Run(ctx context.Context, opts Options, job Job) error {
+	opts = opts.withDefaults()
+	for {
+		next := opts.Schedule.Next(opts.now())
+		if next.IsZero() {
+			return fmt.Errorf("daemon: schedule %q never runs", opts.Schedule)
+		}
+		opts.Log.Printf("next run at %s", next.Format(time.RFC3339))
+		select {
+		case <-ctx.Done():
+			opts.Log.Printf("shutting down")
+			return nil
+		case <-opts.after(next.Sub(opts.now())):
+		}
+		if _, err := RunOnce(ctx, opts, job); err != nil && !errors.Is(err, ErrLocked) {
+			opts.Log.Printf("run failed: %v", err)
+		}
+		if ctx.Err() != nil {
+			opts.Log.Printf("shutting down")
+			return nil
+		}
+	}
+}
+
+// RunOnce takes the lock, runs the job and records its summary. If another run holds the lock,
+// it records an overlapped run and returns ErrLocked.
+func RunOnce(ctx context.Context, opts Options, job Job) (Summary, error) {
+	opts = opts.withDefaults()
+	s := Summary{Start: opts.now()}
+	lock, err := Acquire(opts.LockFile)
+	if err != nil {
+		s.End = s.Start
+		s.Outcome, s.Error = Overlapped, err.Error()
+		if !errors.Is(err, ErrLocked) {
+			s.Outcome = Failed
+		}
+		opts.record(s)
+		return s, err
+	}
+	defer func() {
+		if err := lock.Release(); err != nil {
+			opts.Log.Printf("%v", err)
+		}
+	}()
+
+	opts.Log.Printf("run started")
+	s.Counts, err = job(ctx)
+	s.End = opts.now()
+	s.Outcome = Succeeded
+	if err != nil {
+		s.Error = err.Error()
+	}
+	if err != nil || s.Failed > 0 {
+		s.Outcome = Failed
+	}
+	opts.Log.Printf("run %s in %s: %d processed, %d succeeded, %d skipped, %d failed",
+		s.Outcome, s.End.Sub(s.Start).Round(time.Millisecond), s.Processed, s.Succeeded, s.Skipped, s.Failed)
+	opts.record(s)
+	return s, err
+}
+
+// record adds a summary to the history, logging failures.
+func (o Options) record(s Summary) {
+	if o.History == nil {
+		return
+	}
+	if err := o.History.Add(s); err != nil {
+		o.Log.Printf("history: %v", err)
+	}
+}
+
+func (o Options) withDefaults() Options {
+	if o.Log == nil {
+		o.Log = log.New(io.Discard, "", 0)
+	}
+	if o.now == nil {
+		o.now = time.Now
+	}
+	if o.after == nil {
+		o.after = time.After
+	}
+	return o
+}
@@ -0,0 +1,99 @@
+package daemon
+
+import (
+	"chordparser/internal/schedule"
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRunOnce_RecordsSummary(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	opts := Options{LockFile: filepath.Join(dir, "lock"), History: NewHistory(filepath.Join(dir, "runs.json"), 5)}
+
+	s, err := RunOnce(context.Background(), opts, func(context.Context) (Counts, error) {
+		return Counts{Processed: 3, Succeeded: 2, Failed: 1}, nil
+	})
+	if err != nil || s.Outcome != Failed || s.Processed != 3 {
+		t.Fatalf("RunOnce = %+v, %v", s, err)
+	}
+	got, err := opts.History.Summaries()
+	if err != nil || len(got) != 1 || got[0].Counts != s.Counts || got[0].Outcome != Failed {
+		t.Fatalf("history = %+v, %v", got, err)
+	}
+}
+
+func TestRunOnce_DoesNotOverlap(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	opts := Options{LockFile: filepath.Join(dir, "lock"), History: NewHistory(filepath.Join(dir, "runs.json"), 5)}
+
+	held, err := Acquire(opts.LockFile)
+	if err != nil {
+		t.Fatalf("Acquire: %v", err)
+	}
+	s, err := RunOnce(context.Background(), opts, func(context.Context) (Counts, error) {
+		t.Fatalf("the job ran while the lock was held")
+		return Counts{}, nil
+	})
+	if !errors.Is(err, ErrLocked) || s.Outcome != Overlapped {
+		t.Fatalf("RunOnce = %+v, %v; want an overlapped run", s, err)
+	}
+	if err := held.Release(); err != nil {
+		t.Fatalf("Release: %v", err)
+	}
+	if s, err := RunOnce(context.Background(), opts, func(context.Context) (Counts, error) { return Counts{}, nil }); err != nil || s.Outcome != Succeeded {
+		t.Fatalf("RunOnce after release = %+v, %v", s, err)
+	}
+}
+
+func TestRun_RunsOnScheduleUntilCanceled(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	sched, err := schedule.Parse("*/5 * * * *")
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	clock := time.Date(2026, 3, 13, 10, 7, 0, 0, time.UTC)
+	var waits []time.Duration
+	opts := Options{
+		Schedule: sched,
+		LockFile: filepath.Join(dir, "lock"),
+		History:  NewHistory(filepath.Join(dir, "runs.json"), 2),
+		now:      func() time.Time { return clock },
+		after: func(d time.Duration) <-chan time.Time {
+			waits = append(waits, d)
+			clock = clock.Add(d)
+			c := make(chan time.Time, 1)
+			c <- clock
+			return c
+		},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	runs := 0
+	err = Run(ctx, opts, func(ctx context.Context) (Counts, error) {
+		runs++
+		if runs == 3 {
+			// SIGTERM during a run: the run finishes and is recorded, then Run returns
+			cancel()
+			if ctx.Err() == nil {
+				t.Fatalf("the job context should be canceled")
+			}
+		}
+		return Counts{Processed: runs}, nil
+	})
+	if err != nil || runs != 3 {
+		t.Fatalf("Run = %v after %d runs", err, runs)
+	}
+	if want := []time.Duration{3 * time.Minute, 5 * time.Minute, 5 * time.Minute}; len(waits) != 3 || waits[0] != want[0] || waits[2] != want[2] {
+		t.Fatalf("waits = %v, want %v", waits, want)
+	}
+	got, err := opts.History.Summaries()
+	if err != nil || len(got) != 2 || got[0].Processed != 2 || got[1].Processed != 3 {
+		t.Fatalf("history should keep the last 2 runs: %+v, %v", got, err)
+	}
+}
@@ -0,0 +1,98 @@
+package daemon
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sync"
+	"time"
+)
+
+// Run outcomes.
+const (
+	Succeeded = "succeeded"
+	Failed    = "failed"
+	// Overlapped marks a run that did not start because another run held the lock.
+	Overlapped = "overlapped"
+)
+
+// Counts are the item counts a job reports.
+type Counts struct {
+	Processed int `json:"processed"`
+	Succeeded int `json:"succeeded"`
+	Skipped   int `json:"skipped"`
+	Failed    int `json:"failed"`
+}
+
+// Summary describes one scheduled run.
+type Summary struct {
+	Start   time.Time `json:"start"`
+	End     time.Time `json:"end"`
+	Outcome string    `json:"outcome"`
+	Counts
+	Error string `json:"error,omitempty"`
+}
+
+// History keeps the summaries of the last runs in a JSON file.
+type History struct {
+	path string
+	keep int
+	mu   sync.Mutex
+}
+
+// NewHistory returns the history stored at path, keeping the last keep summaries (at least one).
+// The file is created by the first Add.
+func NewHistory(path string, keep int) *History {
+	return &History{path: path, keep: max(keep, 1)}
+}
+
+// Add records a summary, dropping the oldest beyond the limit. The file is replaced atomically,
+// so a crash never leaves half a history.
+func (h *History) Add(s Summary) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	summaries, err := h.read()
+	if err != nil {
+		return err
+	}
+	summaries = append(summaries, s)
+	if n := len(summaries) - h.keep; n > 0 {
+		summaries = summaries[n:]
+	}
+	data, err := json.MarshalIndent(summaries, "", "  ")
+	if err != nil {
+		return fmt.Errorf("daemon: %w", err)
+	}
+	tmp := h.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("daemon: %w", err)
+	}
+	if err := os.Rename(tmp, h.path); err != nil {
+		return fmt.Errorf("daemon: %w", err)
+	}
+	return nil
+}
+
+// Summaries returns the recorded summaries, oldest first.
+func (h *History) Summaries() ([]Summary, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.read()
+}
+
+func (h *History) read() ([]Summary, error) {
+	data, err := os.ReadFile(h.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("daemon: %w", err)
+	}
+	var summaries []Summary
+	if err := json.Unmarshal(data, &summaries); err != nil {
+		return nil, fmt.Errorf("daemon: %s: %w", h.path, err)
+	}
+	return summaries, nil
+}
@@ -0,0 +1,39 @@
+package daemon
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// ErrLocked is returned when another process holds the lock.
+var ErrLocked = errors.New("daemon: another run holds the lock")
+
+// Lock is an exclusive lock on a lock file, held while a run is in progress so that runs of
+// the daemon and of manual invocations never overlap.
+type Lock struct {
+	f    *os.File
+	path string
+}
+
+// Acquire takes the lock file at path without waiting. It returns ErrLocked if another process
+// holds it. The lock file holds the PID of its holder.
+func Acquire(path string) (*Lock, error) {
+	f, err := lockFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := f.Truncate(0); err == nil {
+		_, _ = f.WriteAt([]byte(strconv.Itoa(os.Getpid())+"\n"), 0)
+	}
+	return &Lock{f: f, path: path}, nil
+}
+
+// Release releases the lock.
+func (l *Lock) Release() error {
+	if err := unlockFile(l.f, l.path); err != nil {
+		return fmt.Errorf("daemon: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,29 @@
+//go:build !unix
+
+package daemon
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+)
+
+// lockFile creates the lock file exclusively. Without advisory locks, a crashed run leaves the
+// file behind; remove it by hand after checking that no run is in progress.
+func lockFile(path string) (*os.File, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0o644)
+	if errors.Is(err, fs.ErrExist) {
+		return nil, ErrLocked
+	}
+	if err != nil {
+		return nil, fmt.Errorf("daemon: %w", err)
+	}
+	return f, nil
+}
+
+// unlockFile releases the lock by removing the lock file.
+func unlockFile(f *os.File, path string) error {
+	f.Close()
+	return os.Remove(path)
+}
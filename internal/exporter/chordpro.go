@@ -0,0 +1,62 @@
+package exporter
+
+import (
+	"chordparser/internal/parser"
+	"io"
+	"strings"
+)
+
+// Canonical header bases with their own ChordPro environment. Other sections use a labelled verse.
+var chordProEnvironments = map[string]string{
+	"CHORUS":  "chorus",
+	"REFRAIN": "chorus",
+	"BRIDGE":  "bridge",
+}
+
+// WriteChordPro writes a song as a ChordPro 6 file: {title}, {artist}, {key} and
+// {meta: ccli ...} metadata followed by one labelled environment per section, e.g.
+// "{start_of_verse: Verse 1}" ... "{end_of_verse}", with the content lines (inline chords,
+// comments) unchanged. Sections without content lines are skipped, as the parser drops empty
+// environments. parser.Parse reads the file back into the same sections.
+func WriteChordPro(w io.Writer, song parser.Song) error {
+	var b strings.Builder
+	meta := false
+	writeMeta := func(name, value string) {
+		if value = strings.TrimSpace(value); value != "" {
+			b.WriteString("{" + name + ": " + value + "}\n")
+			meta = true
+		}
+	}
+	writeMeta("title", song.Title)
+	for _, a := range splitAuthors(song.Author) {
+		writeMeta("artist", a)
+	}
+	writeMeta("key", song.Key)
+	if ccli := strings.TrimSpace(song.CCLI); ccli != "" {
+		writeMeta("meta", "ccli "+ccli)
+	}
+
+	written := 0
+	for _, sec := range song.Sections {
+		if len(sec.Content) == 0 {
+			continue
+		}
+		if meta || written > 0 {
+			b.WriteString("\n")
+		}
+		written++
+		base, _ := splitHeader(sec.Header)
+		env, ok := chordProEnvironments[base]
+		if !ok {
+			env = "verse"
+		}
+		b.WriteString("{start_of_" + env + ": " + headerLabel(sec.Header) + "}\n")
+		for _, line := range sec.Content {
+			b.WriteString(line + "\n")
+		}
+		b.WriteString("{end_of_" + env + "}\n")
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,107 @@
+package exporter
+
+import (
+	"bytes"
+	"chordparser/internal/parser"
+	"reflect"
+	"testing"
+)
+
+func TestWriteChordPro_Output(t *testing.T) {
+	t.Parallel()
+	song := parser.Song{
+		Title:  "Amazing Grace",
+		Author: "John Newton",
+		Key:    "G",
+		CCLI:   "22025",
+		Sections: []parser.Section{
+			{Header: "VERSE 1", Content: []string{"[G]Amazing grace how [C]sweet the [G]sound"}},
+			{Header: "CHORUS", Content: []string{"{comment: softly}", "My chains are gone"}},
+			{Header: "PRE-CHORUS 2", Content: []string{"Oh"}},
+		},
+	}
+	var buf bytes.Buffer
+	if err := WriteChordPro(&buf, song); err != nil {
+		t.Fatalf("WriteChordPro: %v", err)
+	}
+	want := `{title: Amazing Grace}
+{artist: John Newton}
+{key: G}
+{meta: ccli 22025}
+
+{start_of_verse: Verse 1}
+[G]Amazing grace how [C]sweet the [G]sound
+{end_of_verse}
+
+{start_of_chorus: Chorus}
+{comment: softly}
+My chains are gone
+{end_of_chorus}
+
+{start_of_verse: Pre-Chorus 2}
+Oh
+{end_of_verse}
+`
+	if got := buf.String(); got != want {
+		t.Fatalf("unexpected output:\n--- got ---\n%s\n--- want ---\n%s", got, want)
+	}
+}
+
+func TestWriteChordPro_RoundTrip(t *testing.T) {
+	t.Parallel()
+	charts := map[string]string{
+		"headers": "Intro\n[G] [C]\n\nVerse 1\n[G]Amazing grace\nHow sweet\n\nChorus:\nMy chains\n\n" +
+			"Verse: I once was lost\n\nChorus\nMy chains\n\n[Pre-Chorus]\nOh\n\nTag\n[G]Amen\n",
+		"general":   "Just some lyrics\n\n[G]with chords\n",
+		"languages": "Refrein\nla la\n2e couplet\nli li\nSchluss\nEnde\n",
+		"leading":   "Some intro words\n\nBridge\nOh\n",
+	}
+	for name, chart := range charts {
+		want := parser.ParseSong(chart)
+		want.Title, want.Key = "Song", "D"
+		want.Sections = withoutPositions(want.Sections)
+
+		var buf bytes.Buffer
+		if err := WriteChordPro(&buf, want); err != nil {
+			t.Fatalf("%s: WriteChordPro: %v", name, err)
+		}
+		got := parser.ParseSong(buf.String())
+		got.Sections = withoutPositions(got.Sections)
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("%s: round trip mismatch:\nwant %#v\ngot  %#v\nfile:\n%s", name, want, got, buf.String())
+		}
+		if sections := withoutPositions(parser.Parse(buf.String())); !reflect.DeepEqual(sections, want.Sections) {
+			t.Fatalf("%s: parser.Parse mismatch:\nwant %#v\ngot  %#v", name, want.Sections, sections)
+		}
+	}
+}
+
+func TestWriteChordPro_RoundTripEmptySection(t *testing.T) {
+	t.Parallel()
+	song := parser.Song{Sections: []parser.Section{
+		{Header: "VERSE 1"},
+		{Header: "CHORUS", Content: []string{"My chains are gone", ""}},
+		{Header: "BRIDGE", Content: []string{}},
+		{Header: "TAG", Content: []string{"Amen"}},
+	}}
+	var buf bytes.Buffer
+	if err := WriteChordPro(&buf, song); err != nil {
+		t.Fatalf("WriteChordPro: %v", err)
+	}
+	want := "{start_of_chorus: Chorus}\nMy chains are gone\n\n{end_of_chorus}\n\n{start_of_verse: Tag}\nAmen\n{end_of_verse}\n"
+	if buf.String() != want {
+		t.Fatalf("unexpected output:\n--- got ---\n%s\n--- want ---\n%s", buf.String(), want)
+	}
+	got := withoutPositions(parser.ParseSong(buf.String()).Sections)
+	if !reflect.DeepEqual(got, []parser.Section{song.Sections[1], song.Sections[3]}) {
+		t.Fatalf("round trip: %#v", got)
+	}
+}
+
+// withoutPositions clears section positions, which differ between a chart and its export.
+func withoutPositions(sections []parser.Section) []parser.Section {
+	for i := range sections {
+		sections[i].Pos, sections[i].Lines = parser.Span{}, nil
+	}
+	return sections
+}
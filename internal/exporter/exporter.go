@@ -0,0 +1,126 @@
+package exporter
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	// Inline chord in a content line, e.g. "[G]" or "[F#m7/C#]"
+	reInlineChord = regexp.MustCompile(`\[([^\[\]]+)\]`)
+	// ChordPro directive filling a whole line, e.g. "{comment: softly}"
+	reDirectiveLine = regexp.MustCompile(`^\s*\{[^}]*\}\s*$`)
+	// Characters that are not allowed or awkward in file names on common systems
+	reUnsafeFileChars = regexp.MustCompile(`[<>:"/\\|?*\x00-\x1f]+`)
+)
+
+// segment is a piece of a content line: a chord (possibly empty) followed by the lyric text it sits on.
+type segment struct {
+	Chord string
+	Text  string
+}
+
+// splitChords splits a content line with inline chords into segments,
+// e.g. "[G]Amazing [C]grace" gives {G, "Amazing "}, {C, "grace"}.
+// Text before the first chord is returned as a segment without a chord.
+func splitChords(line string) []segment {
+	var segs []segment
+	locs := reInlineChord.FindAllStringSubmatchIndex(line, -1)
+	if len(locs) == 0 || locs[0][0] > 0 {
+		end := len(line)
+		if len(locs) > 0 {
+			end = locs[0][0]
+		}
+		segs = append(segs, segment{Text: line[:end]})
+	}
+	for i, loc := range locs {
+		end := len(line)
+		if i+1 < len(locs) {
+			end = locs[i+1][0]
+		}
+		segs = append(segs, segment{Chord: line[loc[2]:loc[3]], Text: line[loc[1]:end]})
+	}
+	return segs
+}
+
+// stripChords removes inline chords and bar lines from a content line, tidying spaces.
+func stripChords(line string) string {
+	s := reInlineChord.ReplaceAllString(line, "")
+	fields := strings.Fields(s)
+	out := fields[:0]
+	for _, f := range fields {
+		if f != "|" {
+			out = append(out, f)
+		}
+	}
+	return strings.Join(out, " ")
+}
+
+// isChordLine reports whether a content line holds only chords and bar lines.
+func isChordLine(line string) bool {
+	return strings.TrimSpace(line) != "" && stripChords(line) == ""
+}
+
+// isDirective reports whether a content line is a ChordPro directive such as "{comment: ...}".
+func isDirective(line string) bool {
+	return reDirectiveLine.MatchString(line)
+}
+
+// headerLabel turns a canonical header into a display label, e.g. "PRE-CHORUS 2" gives "Pre-Chorus 2".
+func headerLabel(header string) string {
+	b := []byte(strings.ToLower(header))
+	upper := true
+	for i, c := range b {
+		if upper && c >= 'a' && c <= 'z' {
+			b[i] = c - 'a' + 'A'
+		}
+		upper = c == ' ' || c == '-'
+	}
+	return string(b)
+}
+
+// lineGroups splits section content into groups of lines separated by blank lines,
+// dropping ChordPro directives.
+func lineGroups(content []string) [][]string {
+	var groups [][]string
+	var cur []string
+	for _, line := range content {
+		if isDirective(line) {
+			continue
+		}
+		if strings.TrimSpace(line) == "" {
+			if len(cur) > 0 {
+				groups = append(groups, cur)
+				cur = nil
+			}
+			continue
+		}
+		cur = append(cur, strings.TrimSpace(line))
+	}
+	if len(cur) > 0 {
+		groups = append(groups, cur)
+	}
+	return groups
+}
+
+// trimBlankLines removes leading and trailing blank lines.
+func trimBlankLines(lines []string) []string {
+	for len(lines) > 0 && strings.TrimSpace(lines[0]) == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// FileName returns a file name for a song export from its title and the format's extension
+// (e.g., ".txt"), replacing characters that are not allowed in file names.
+func FileName(title, ext string) string {
+	name := strings.Join(strings.Fields(reUnsafeFileChars.ReplaceAllString(title, " ")), " ")
+	name = strings.Trim(name, ". ")
+	if name == "" {
+		name = "Untitled"
+	}
+	return name + ext
+}
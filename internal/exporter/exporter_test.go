@@ -0,0 +1,58 @@
+package exporter
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitChords(t *testing.T) {
+	t.Parallel()
+	got := splitChords("Oh [G]Amazing [C]grace")
+	want := []segment{{Text: "Oh "}, {Chord: "G", Text: "Amazing "}, {Chord: "C", Text: "grace"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("splitChords = %#v, want %#v", got, want)
+	}
+}
+
+func TestStripChords(t *testing.T) {
+	t.Parallel()
+	cases := map[string]string{
+		"[G]Amazing  grace how [C]sweet": "Amazing grace how sweet",
+		"[C] [G] | [D]":                  "",
+		"No chords here":                 "No chords here",
+	}
+	for in, want := range cases {
+		if got := stripChords(in); got != want {
+			t.Fatalf("stripChords(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestHeaderLabel(t *testing.T) {
+	t.Parallel()
+	cases := map[string]string{
+		"PRE-CHORUS 2": "Pre-Chorus 2",
+		"VERSE":        "Verse",
+		"GENERAL":      "General",
+	}
+	for in, want := range cases {
+		if got := headerLabel(in); got != want {
+			t.Fatalf("headerLabel(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestFileName(t *testing.T) {
+	t.Parallel()
+	cases := map[string]string{
+		"Amazing Grace":          "Amazing Grace.txt",
+		"10,000 Reasons (Bless)": "10,000 Reasons (Bless).txt",
+		"What/If: A? <Song>":     "What If A Song.txt",
+		"  ..  ":                 "Untitled.txt",
+	}
+	for in, want := range cases {
+		if got := FileName(in, ".txt"); got != want {
+			t.Fatalf("FileName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package exporter
+
+import (
+	"chordparser/internal/parser"
+	"html"
+	"io"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// ChordPro comment directives rendered as notes, e.g. "{comment: softly}" or "{c: softly}"
+var reCommentDirective = regexp.MustCompile(`(?i)^\s*\{\s*(?:comment|c|comment_italic|ci|comment_box|cb|highlight)\s*:\s*(.*?)\s*\}\s*$`)
+
+// HTMLOptions configures the HTML chord sheet.
+type HTMLOptions struct {
+	// TwoColumns lays the sections out in two columns.
+	TwoColumns bool
+}
+
+// The stylesheet embedded in every chord sheet, so the file is self-contained.
+// Chords sit above their syllable using ruby annotations; a section is never split across
+// pages (or columns) when printed.
+const chordSheetCSS = `body { font-family: Helvetica, Arial, sans-serif; font-size: 12pt; line-height: 1.2; margin: 2em; color: #000; }
+h1 { font-size: 1.6em; margin: 0 0 .2em; }
+.meta { color: #444; margin: 0 0 1.5em; }
+.meta span + span::before { content: " · "; }
+.columns main { column-count: 2; column-gap: 2.5em; }
+section { break-inside: avoid; page-break-inside: avoid; margin: 0 0 1.2em; }
+h2 { font-size: 1em; text-transform: uppercase; margin: 0 0 .4em; }
+.line { white-space: pre-wrap; min-height: 1.2em; }
+ruby { ruby-position: over; }
+rt, .chords { font-size: .85em; font-weight: bold; color: #b00; }
+rt { padding-right: .4em; text-align: start; }
+.comment { font-style: italic; color: #555; }
+@media print {
+  body { margin: 0; font-size: 11pt; }
+  rt, .chords { color: #000; }
+  h1, h2 { break-after: avoid; page-break-after: avoid; }
+}
+@page { margin: 1.5cm; }
+`
+
+// WriteHTML writes a song as a self-contained, printable HTML chord sheet: a title with the
+// author, key and CCLI number, then each section under its header with chords positioned
+// above the lyrics. Comment directives become notes, other directives are dropped.
+func WriteHTML(w io.Writer, song parser.Song, opts HTMLOptions) error {
+	title := strings.TrimSpace(song.Title)
+	if title == "" {
+		title = "Untitled"
+	}
+
+	var b strings.Builder
+	b.WriteString("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n")
+	b.WriteString("<title>" + html.EscapeString(title) + "</title>\n")
+	b.WriteString("<style>\n" + chordSheetCSS + "</style>\n</head>\n")
+	if opts.TwoColumns {
+		b.WriteString("<body class=\"columns\">\n")
+	} else {
+		b.WriteString("<body>\n")
+	}
+	b.WriteString("<h1>" + html.EscapeString(title) + "</h1>\n")
+
+	var meta []string
+	if a := strings.TrimSpace(song.Author); a != "" {
+		meta = append(meta, html.EscapeString(a))
+	}
+	if k := strings.TrimSpace(song.Key); k != "" {
+		meta = append(meta, "Key: "+html.EscapeString(k))
+	}
+	if c := strings.TrimSpace(song.CCLI); c != "" {
+		meta = append(meta, "CCLI: "+html.EscapeString(c))
+	}
+	if len(meta) > 0 {
+		b.WriteString("<p class=\"meta\"><span>" + strings.Join(meta, "</span><span>") + "</span></p>\n")
+	}
+
+	b.WriteString("<main>\n")
+	for _, sec := range song.Sections {
+		b.WriteString("<section>\n<h2>" + html.EscapeString(headerLabel(sec.Header)) + "</h2>\n")
+		for _, line := range trimBlankLines(sec.Content) {
+			if line := htmlLine(line); line != "" {
+				b.WriteString(line + "\n")
+			}
+		}
+		b.WriteString("</section>\n")
+	}
+	b.WriteString("</main>\n</body>\n</html>\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// htmlLine renders one content line. It returns "" for lines that are not shown.
+func htmlLine(line string) string {
+	if m := reCommentDirective.FindStringSubmatch(line); m != nil {
+		return `<div class="line comment">` + html.EscapeString(m[1]) + `</div>`
+	}
+	if isDirective(line) {
+		return ""
+	}
+	if strings.TrimSpace(line) == "" {
+		return `<div class="line"></div>`
+	}
+	if isChordLine(line) {
+		// Chords without lyrics (e.g., an intro) form a row of their own, keeping their spacing
+		return `<div class="line chords">` + html.EscapeString(strings.TrimSpace(reInlineChord.ReplaceAllString(line, "$1"))) + `</div>`
+	}
+
+	var b strings.Builder
+	b.WriteString(`<div class="line">`)
+	for _, seg := range splitChords(line) {
+		if seg.Chord == "" {
+			b.WriteString(html.EscapeString(seg.Text))
+			continue
+		}
+		// Only the first word carries the chord, so the chord stays over the syllable it is sung on
+		// instead of being centred over the rest of the segment
+		word, rest := seg.Text, ""
+		if i := strings.IndexFunc(word, unicode.IsSpace); i >= 0 {
+			word, rest = word[:i], word[i:]
+		}
+		if word == "" {
+			// Keep room under a chord that has no syllable of its own
+			word = "\u00a0"
+		}
+		b.WriteString("<ruby>" + html.EscapeString(word) + "<rt>" + html.EscapeString(seg.Chord) + "</rt></ruby>" + html.EscapeString(rest))
+	}
+	b.WriteString(`</div>`)
+	return b.String()
+}
@@ -0,0 +1,58 @@
+package exporter
+
+import (
+	"bytes"
+	"chordparser/internal/parser"
+	"strings"
+	"testing"
+)
+
+func TestWriteHTML(t *testing.T) {
+	t.Parallel()
+	song := parser.Song{
+		Title:  "Amazing <Grace>",
+		Author: "John Newton",
+		Key:    "G",
+		Sections: []parser.Section{
+			{Header: "INTRO", Content: []string{"[G] [C] | [D]"}},
+			{Header: "VERSE 1", Content: []string{"{comment: softly}", "[G]Amazing grace how [C]sweet", "[Em] la gr[C]ace", "", "{key: A}", "That saved[D]", ""}},
+		},
+	}
+	var buf bytes.Buffer
+	if err := WriteHTML(&buf, song, HTMLOptions{}); err != nil {
+		t.Fatalf("WriteHTML: %v", err)
+	}
+	got := buf.String()
+	for _, want := range []string{
+		"<title>Amazing &lt;Grace&gt;</title>",
+		`<p class="meta"><span>John Newton</span><span>Key: G</span></p>`,
+		"<section>\n<h2>Intro</h2>\n<div class=\"line chords\">G C | D</div>\n</section>",
+		`<div class="line comment">softly</div>`,
+		// Only the word under the chord is annotated
+		`<div class="line"><ruby>Amazing<rt>G</rt></ruby> grace how <ruby>sweet<rt>C</rt></ruby></div>`,
+		"<div class=\"line\"><ruby>\u00a0<rt>Em</rt></ruby> la gr<ruby>ace<rt>C</rt></ruby></div>",
+		"<div class=\"line\"></div>\n<div class=\"line\">That saved<ruby>\u00a0<rt>D</rt></ruby></div>\n</section>",
+		"break-inside: avoid",
+		"@media print",
+		"<body>\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("output misses %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "{key") || strings.Contains(got, "<link") || strings.Contains(got, "<script") {
+		t.Fatalf("output should be self-contained without directives:\n%s", got)
+	}
+}
+
+func TestWriteHTML_TwoColumns(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	song := parser.Song{Sections: []parser.Section{{Header: "GENERAL", Content: []string{"La"}}}}
+	if err := WriteHTML(&buf, song, HTMLOptions{TwoColumns: true}); err != nil {
+		t.Fatalf("WriteHTML: %v", err)
+	}
+	if !strings.Contains(buf.String(), `<body class="columns">`) || !strings.Contains(buf.String(), "<h1>Untitled</h1>") {
+		t.Fatalf("unexpected output:\n%s", buf.String())
+	}
+}
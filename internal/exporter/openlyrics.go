@@ -0,0 +1,210 @@
+package exporter
+
+import (
+	"bytes"
+	"chordparser/internal/normalize"
+	"chordparser/internal/parser"
+	"encoding/xml"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// OpenLyricsNamespace is the XML namespace of OpenLyrics songs.
+const OpenLyricsNamespace = "http://openlyrics.info/namespace/2009/song"
+
+// ErrNoLyrics is returned by WriteOpenLyrics for a song without any lyric lines: the schema
+// requires at least one verse.
+var ErrNoLyrics = errors.New("song has no lyrics")
+
+// Canonical header bases mapped to OpenLyrics verse name letters. Anything else is "o" (other).
+var verseLetters = map[string]string{
+	"VERSE":      "v",
+	"CHORUS":     "c",
+	"REFRAIN":    "c",
+	"PRE-CHORUS": "p",
+	"BRIDGE":     "b",
+	"INTRO":      "i",
+	"ENDING":     "e",
+	"OUTRO":      "e",
+}
+
+// WriteOpenLyrics writes a song as OpenLyrics 0.9 XML, as imported by OpenLP.
+// Sections become verses named v1, c1, b1, ... (numbers follow the headers where possible),
+// the verse order follows the song sequence (or the section order if there is none),
+// blank lines split a verse into <lines> groups and inline chords become <chord name=""/>.
+// Sections are expected to be cleaned already (see processor.CleanSections).
+// A song whose sections are all empty gives ErrNoLyrics.
+func WriteOpenLyrics(w io.Writer, song parser.Song) error {
+	if !hasLyrics(song.Sections) {
+		return ErrNoLyrics
+	}
+	names := verseNames(song.Sections)
+
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	b.WriteString(`<song xmlns="` + OpenLyricsNamespace + `" version="0.9" createdIn="chordparser" modifiedIn="chordparser">` + "\n")
+	b.WriteString("  <properties>\n")
+	title := strings.TrimSpace(song.Title)
+	if title == "" {
+		title = "Untitled"
+	}
+	b.WriteString("    <titles>\n      <title>" + escapeXML(title) + "</title>\n    </titles>\n")
+	if authors := splitAuthors(song.Author); len(authors) > 0 {
+		b.WriteString("    <authors>\n")
+		for _, a := range authors {
+			b.WriteString("      <author>" + escapeXML(a) + "</author>\n")
+		}
+		b.WriteString("    </authors>\n")
+	}
+	// The schema only allows a positive integer here
+	if ccli := strings.TrimSpace(song.CCLI); normalize.IsDigits(ccli) {
+		b.WriteString("    <ccliNo>" + escapeXML(ccli) + "</ccliNo>\n")
+	}
+	if key := strings.TrimSpace(song.Key); key != "" {
+		b.WriteString("    <key>" + escapeXML(key) + "</key>\n")
+	}
+	if order := verseOrder(song, names); len(order) > 0 {
+		b.WriteString("    <verseOrder>" + strings.Join(order, " ") + "</verseOrder>\n")
+	}
+	b.WriteString("  </properties>\n")
+
+	b.WriteString("  <lyrics>\n")
+	for i, s := range song.Sections {
+		groups := lineGroups(s.Content)
+		if len(groups) == 0 {
+			continue
+		}
+		b.WriteString(`    <verse name="` + names[i] + `">` + "\n")
+		for _, g := range groups {
+			rendered := make([]string, len(g))
+			for j, line := range g {
+				rendered[j] = openLyricsLine(line)
+			}
+			b.WriteString("      <lines>" + strings.Join(rendered, "<br/>") + "</lines>\n")
+		}
+		b.WriteString("    </verse>\n")
+	}
+	b.WriteString("  </lyrics>\n")
+	b.WriteString("</song>\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// hasLyrics reports whether any section has a lyric line to write.
+func hasLyrics(sections []parser.Section) bool {
+	for _, s := range sections {
+		if len(lineGroups(s.Content)) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// verseNames assigns a unique OpenLyrics verse name to every section.
+// Explicit header numbers are kept when free; other sections get the next free number.
+func verseNames(sections []parser.Section) []string {
+	names := make([]string, len(sections))
+	used := map[string]bool{}
+	letters := make([]string, len(sections))
+	for i, s := range sections {
+		base, n := splitHeader(s.Header)
+		letter, ok := verseLetters[base]
+		if !ok {
+			letter = "o"
+		}
+		letters[i] = letter
+		if name := letter + strconv.Itoa(n); n > 0 && !used[name] {
+			names[i] = name
+			used[name] = true
+		}
+	}
+	for i := range sections {
+		if names[i] != "" {
+			continue
+		}
+		for n := 1; ; n++ {
+			if name := letters[i] + strconv.Itoa(n); !used[name] {
+				names[i] = name
+				used[name] = true
+				break
+			}
+		}
+	}
+	return names
+}
+
+// verseOrder maps the song sequence to verse names, or lists all verses with content
+// in section order when the song has no sequence. Unknown headers are skipped.
+func verseOrder(song parser.Song, names []string) []string {
+	byHeader := map[string]string{}
+	var all []string
+	for i, s := range song.Sections {
+		if len(lineGroups(s.Content)) == 0 {
+			continue
+		}
+		byHeader[s.Header] = names[i]
+		all = append(all, names[i])
+	}
+	if len(song.Sequence) == 0 {
+		return all
+	}
+	var order []string
+	for _, h := range song.Sequence {
+		if name, ok := byHeader[h]; ok {
+			order = append(order, name)
+		}
+	}
+	return order
+}
+
+// openLyricsLine renders a content line with inline chords as OpenLyrics mixed content.
+func openLyricsLine(line string) string {
+	if isChordLine(line) {
+		var chords []string
+		for _, seg := range splitChords(line) {
+			if seg.Chord != "" {
+				chords = append(chords, `<chord name="`+escapeXML(seg.Chord)+`"/>`)
+			}
+		}
+		return strings.Join(chords, " ")
+	}
+	var b strings.Builder
+	for _, seg := range splitChords(line) {
+		if seg.Chord != "" {
+			b.WriteString(`<chord name="` + escapeXML(seg.Chord) + `"/>`)
+		}
+		b.WriteString(escapeXML(seg.Text))
+	}
+	return b.String()
+}
+
+// splitHeader splits "VERSE 2" into ("VERSE", 2); headers without a number give 0.
+func splitHeader(header string) (string, int) {
+	fields := strings.Fields(header)
+	if len(fields) > 1 && normalize.IsDigits(fields[len(fields)-1]) {
+		n, _ := strconv.Atoi(fields[len(fields)-1])
+		return strings.Join(fields[:len(fields)-1], " "), n
+	}
+	return strings.TrimSpace(header), 0
+}
+
+// splitAuthors splits a comma-separated author list.
+func splitAuthors(s string) []string {
+	var out []string
+	for _, a := range strings.Split(s, ",") {
+		if a = strings.TrimSpace(a); a != "" {
+			out = append(out, a)
+		}
+	}
+	return out
+}
+
+// escapeXML escapes text for use in XML content and attribute values.
+func escapeXML(s string) string {
+	var buf bytes.Buffer
+	_ = xml.EscapeText(&buf, []byte(s))
+	return buf.String()
+}
@@ -0,0 +1,282 @@
+package exporter
+
+import (
+	"bytes"
+	"chordparser/internal/importer"
+	"chordparser/internal/parser"
+	"encoding/xml"
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+var testSong = parser.Song{
+	Title:    "Amazing Grace",
+	Author:   "John Newton, Traditional",
+	Key:      "G",
+	CCLI:     "22025",
+	Sequence: []string{"VERSE 1", "CHORUS", "VERSE 2", "CHORUS"},
+	Sections: []parser.Section{
+		{Header: "VERSE 1", Content: []string{"[G]Amazing grace how [C]sweet the [G]sound", "That saved a wretch like [D]me"}},
+		{Header: "CHORUS", Content: []string{"[C] [G] | [D]", "My chains are [C]gone & I'm <free>", "", "{comment: softly}", "I've been set [G]free"}},
+		{Header: "VERSE 2", Content: []string{"'Twas grace that taught"}},
+		{Header: "TAG", Content: []string{""}},
+	},
+}
+
+// Verse names as the OpenLyrics schema describes them: a type letter, optional number and
+// optional part letter
+var reVerseName = regexp.MustCompile(`^[a-z]+[0-9]*[a-z]?$`)
+
+// validateOpenLyrics validates the document with xmllint against the OpenLyrics 0.9 schema in
+// testdata/openlyrics.rng. It skips the test when xmllint is not installed.
+func validateOpenLyrics(t *testing.T, data []byte) {
+	t.Helper()
+	xmllint, err := exec.LookPath("xmllint")
+	if err != nil {
+		t.Skip("xmllint not installed")
+	}
+	file := filepath.Join(t.TempDir(), "song.xml")
+	if err := os.WriteFile(file, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if out, err := exec.Command(xmllint, "--noout", "--relaxng", filepath.Join("testdata", "openlyrics.rng"), file).CombinedOutput(); err != nil {
+		t.Fatalf("xmllint: %v\n%s\n%s", err, out, data)
+	}
+}
+
+// checkOpenLyricsStructure checks what the schema leaves to the exporter, on top of
+// validateOpenLyrics and without xmllint: the namespaced <song version="0.9"> root with <properties> (required <titles>, optional
+// <authors>, numeric <ccliNo>, <key>, <verseOrder>) followed by <lyrics> with named verses whose
+// <lines> only hold text, <chord name="">, <br/>, <comment> and <tag>. The verse order must only
+// refer to existing verses.
+func checkOpenLyricsStructure(t *testing.T, data []byte) {
+	t.Helper()
+
+	var doc struct {
+		XMLName    xml.Name
+		Version    string `xml:"version,attr"`
+		Properties struct {
+			Titles     []string `xml:"titles>title"`
+			CCLI       string   `xml:"ccliNo"`
+			VerseOrder string   `xml:"verseOrder"`
+		} `xml:"properties"`
+		Verses []struct {
+			Name  string `xml:"name,attr"`
+			Lines []struct {
+				Inner []byte `xml:",innerxml"`
+			} `xml:"lines"`
+		} `xml:"lyrics>verse"`
+	}
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("output is not well-formed XML: %v", err)
+	}
+	if doc.XMLName.Space != OpenLyricsNamespace || doc.XMLName.Local != "song" {
+		t.Fatalf("root element = %v, want {%s}song", doc.XMLName, OpenLyricsNamespace)
+	}
+	if doc.Version != "0.9" {
+		t.Fatalf("version = %q, want 0.9", doc.Version)
+	}
+	if len(doc.Properties.Titles) == 0 || strings.TrimSpace(doc.Properties.Titles[0]) == "" {
+		t.Fatalf("properties/titles/title is required")
+	}
+	if c := doc.Properties.CCLI; c != "" && !regexp.MustCompile(`^[1-9][0-9]*$`).MatchString(c) {
+		t.Fatalf("ccliNo %q is not a positive integer", c)
+	}
+	if len(doc.Verses) == 0 {
+		t.Fatalf("lyrics needs at least one verse")
+	}
+	names := map[string]bool{}
+	for _, v := range doc.Verses {
+		if !reVerseName.MatchString(v.Name) {
+			t.Fatalf("invalid verse name %q", v.Name)
+		}
+		if names[v.Name] {
+			t.Fatalf("duplicate verse name %q", v.Name)
+		}
+		names[v.Name] = true
+		if len(v.Lines) == 0 {
+			t.Fatalf("verse %q has no lines", v.Name)
+		}
+		for _, l := range v.Lines {
+			dec := xml.NewDecoder(io.MultiReader(strings.NewReader("<lines>"), bytes.NewReader(l.Inner), strings.NewReader("</lines>")))
+			for {
+				tok, err := dec.Token()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					t.Fatalf("verse %q: %v", v.Name, err)
+				}
+				if se, ok := tok.(xml.StartElement); ok {
+					switch se.Name.Local {
+					case "lines", "br", "comment", "tag":
+					case "chord":
+						if len(se.Attr) != 1 || se.Attr[0].Name.Local != "name" || se.Attr[0].Value == "" {
+							t.Fatalf("verse %q: chord needs exactly a name attribute, got %v", v.Name, se.Attr)
+						}
+					default:
+						t.Fatalf("verse %q: element <%s> not allowed in lines", v.Name, se.Name.Local)
+					}
+				}
+			}
+		}
+	}
+	for _, name := range strings.Fields(doc.Properties.VerseOrder) {
+		if !names[name] {
+			t.Fatalf("verseOrder refers to unknown verse %q", name)
+		}
+	}
+}
+
+func TestWriteOpenLyrics_Structure(t *testing.T) {
+	t.Parallel()
+	for _, song := range []parser.Song{testSong, {CCLI: "n/a", Sections: []parser.Section{{Header: "GENERAL", Content: []string{"La"}}}}} {
+		var buf bytes.Buffer
+		if err := WriteOpenLyrics(&buf, song); err != nil {
+			t.Fatalf("WriteOpenLyrics: %v", err)
+		}
+		checkOpenLyricsStructure(t, buf.Bytes())
+	}
+}
+
+func TestWriteOpenLyrics_Schema(t *testing.T) {
+	t.Parallel()
+	songs := []parser.Song{
+		testSong,
+		{CCLI: "n/a", Sections: []parser.Section{{Header: "GENERAL", Content: []string{"La"}}}},
+		{Title: "Intro", Sections: []parser.Section{{Header: "INTRO", Content: []string{"[G] [C] | [D]"}}, {Header: "OUTRO 2", Content: []string{"La [Am7/G]la"}}}},
+	}
+	for _, song := range songs {
+		var buf bytes.Buffer
+		if err := WriteOpenLyrics(&buf, song); err != nil {
+			t.Fatalf("WriteOpenLyrics: %v", err)
+		}
+		validateOpenLyrics(t, buf.Bytes())
+	}
+}
+
+func TestOpenLyricsSchema_RejectsInvalid(t *testing.T) {
+	t.Parallel()
+	xmllint, err := exec.LookPath("xmllint")
+	if err != nil {
+		t.Skip("xmllint not installed")
+	}
+	// A non-numeric CCLI number and a song without verses must fail, so the schema check
+	// above is known to bite
+	for _, doc := range []string{
+		`<song xmlns="` + OpenLyricsNamespace + `" version="0.9"><properties><titles><title>T</title></titles><ccliNo>n/a</ccliNo></properties><lyrics><verse name="v1"><lines>La</lines></verse></lyrics></song>`,
+		`<song xmlns="` + OpenLyricsNamespace + `" version="0.9"><properties><titles><title>T</title></titles></properties><lyrics/></song>`,
+	} {
+		file := filepath.Join(t.TempDir(), "song.xml")
+		if err := os.WriteFile(file, []byte(doc), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := exec.Command(xmllint, "--noout", "--relaxng", filepath.Join("testdata", "openlyrics.rng"), file).Run(); err == nil {
+			t.Fatalf("xmllint accepted an invalid song:\n%s", doc)
+		}
+	}
+}
+
+func TestWriteOpenLyrics_Output(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	if err := WriteOpenLyrics(&buf, testSong); err != nil {
+		t.Fatalf("WriteOpenLyrics: %v", err)
+	}
+	want := `<?xml version="1.0" encoding="UTF-8"?>
+<song xmlns="http://openlyrics.info/namespace/2009/song" version="0.9" createdIn="chordparser" modifiedIn="chordparser">
+  <properties>
+    <titles>
+      <title>Amazing Grace</title>
+    </titles>
+    <authors>
+      <author>John Newton</author>
+      <author>Traditional</author>
+    </authors>
+    <ccliNo>22025</ccliNo>
+    <key>G</key>
+    <verseOrder>v1 c1 v2 c1</verseOrder>
+  </properties>
+  <lyrics>
+    <verse name="v1">
+      <lines><chord name="G"/>Amazing grace how <chord name="C"/>sweet the <chord name="G"/>sound<br/>That saved a wretch like <chord name="D"/>me</lines>
+    </verse>
+    <verse name="c1">
+      <lines><chord name="C"/> <chord name="G"/> <chord name="D"/><br/>My chains are <chord name="C"/>gone &amp; I&#39;m &lt;free&gt;</lines>
+      <lines>I&#39;ve been set <chord name="G"/>free</lines>
+    </verse>
+    <verse name="v2">
+      <lines>&#39;Twas grace that taught</lines>
+    </verse>
+  </lyrics>
+</song>
+`
+	if got := buf.String(); got != want {
+		t.Fatalf("unexpected output:\n--- got ---\n%s\n--- want ---\n%s", got, want)
+	}
+}
+
+func TestWriteOpenLyrics_RoundTripThroughImporter(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	if err := WriteOpenLyrics(&buf, testSong); err != nil {
+		t.Fatalf("WriteOpenLyrics: %v", err)
+	}
+	got, err := importer.ParseOpenLyrics(&buf)
+	if err != nil {
+		t.Fatalf("ParseOpenLyrics: %v", err)
+	}
+	if got.Title != testSong.Title || got.Key != testSong.Key || got.CCLI != testSong.CCLI || got.Author != testSong.Author {
+		t.Fatalf("metadata mismatch: %#v", got)
+	}
+	if len(got.Sections) != 3 || got.Sections[0].Content[0] != testSong.Sections[0].Content[0] {
+		t.Fatalf("sections mismatch: %#v", got.Sections)
+	}
+}
+
+func TestVerseNames(t *testing.T) {
+	t.Parallel()
+	sections := []parser.Section{
+		{Header: "VERSE 2"}, {Header: "VERSE"}, {Header: "CHORUS"}, {Header: "BRIDGE"},
+		{Header: "TAG"}, {Header: "INSTRUMENTAL"}, {Header: "VERSE 2"}, {Header: "GENERAL"},
+	}
+	want := []string{"v2", "v1", "c1", "b1", "o1", "o2", "v3", "o3"}
+	got := verseNames(sections)
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Fatalf("verseNames = %v, want %v", got, want)
+	}
+}
+
+func TestWriteOpenLyrics_NonNumericCCLIOmitted(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	song := parser.Song{CCLI: "n/a", Sections: []parser.Section{{Header: "GENERAL", Content: []string{"La"}}}}
+	if err := WriteOpenLyrics(&buf, song); err != nil {
+		t.Fatalf("WriteOpenLyrics: %v", err)
+	}
+	checkOpenLyricsStructure(t, buf.Bytes())
+	validateOpenLyrics(t, buf.Bytes())
+	if strings.Contains(buf.String(), "ccliNo") || !strings.Contains(buf.String(), "<title>Untitled</title>") {
+		t.Fatalf("unexpected output:\n%s", buf.String())
+	}
+}
+
+func TestWriteOpenLyrics_NoLyrics(t *testing.T) {
+	t.Parallel()
+	for _, sections := range [][]parser.Section{
+		nil,
+		{{Header: "VERSE 1", Content: []string{""}}, {Header: "CHORUS", Content: []string{"{comment: softly}", ""}}},
+	} {
+		var buf bytes.Buffer
+		err := WriteOpenLyrics(&buf, parser.Song{Title: "Empty", Sections: sections})
+		if !errors.Is(err, ErrNoLyrics) || buf.Len() != 0 {
+			t.Fatalf("WriteOpenLyrics(%v) = %v, wrote %q; want ErrNoLyrics and no output", sections, err, buf.String())
+		}
+	}
+}
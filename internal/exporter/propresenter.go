@@ -0,0 +1,65 @@
+package exporter
+
+import (
+	"chordparser/internal/parser"
+	"io"
+	"strings"
+)
+
+// DefaultMaxSlideLines is the number of lines per slide used when none is configured.
+const DefaultMaxSlideLines = 4
+
+// ProPresenterOptions configures the ProPresenter text export.
+type ProPresenterOptions struct {
+	// MaxLines is the maximum number of lyric lines per slide (DefaultMaxSlideLines if 0 or less).
+	MaxLines int
+}
+
+// WriteProPresenter writes a song as lyric-only text in the labelled-block format accepted by
+// ProPresenter's text importer: each section starts with its label (e.g., "Verse 1") on its own
+// line, followed by its slides separated by blank lines. Chords, chord-only lines and directives
+// are removed. Blank lines in a section always start a new slide, and longer parts are split
+// into slides of at most MaxLines lines. Sections without lyrics are skipped.
+// Sections are expected to be cleaned already (see processor.CleanSections).
+func WriteProPresenter(w io.Writer, song parser.Song, opts ProPresenterOptions) error {
+	maxLines := opts.MaxLines
+	if maxLines <= 0 {
+		maxLines = DefaultMaxSlideLines
+	}
+
+	var blocks []string
+	for _, sec := range song.Sections {
+		slides := lyricSlides(sec.Content, maxLines)
+		if len(slides) == 0 {
+			continue
+		}
+		texts := make([]string, len(slides))
+		for i, s := range slides {
+			texts[i] = strings.Join(s, "\n")
+		}
+		blocks = append(blocks, headerLabel(sec.Header)+"\n"+strings.Join(texts, "\n\n"))
+	}
+
+	_, err := io.WriteString(w, strings.Join(blocks, "\n\n")+"\n")
+	return err
+}
+
+// lyricSlides strips chords from section content and splits it into slides of at most maxLines lines.
+func lyricSlides(content []string, maxLines int) [][]string {
+	var slides [][]string
+	for _, group := range lineGroups(content) {
+		var lyrics []string
+		for _, line := range group {
+			if isChordLine(line) {
+				continue
+			}
+			lyrics = append(lyrics, stripChords(line))
+		}
+		for len(lyrics) > 0 {
+			n := min(len(lyrics), maxLines)
+			slides = append(slides, lyrics[:n])
+			lyrics = lyrics[n:]
+		}
+	}
+	return slides
+}
@@ -0,0 +1,53 @@
+package exporter
+
+import (
+	"bytes"
+	"chordparser/internal/parser"
+	"reflect"
+	"testing"
+)
+
+func TestWriteProPresenter(t *testing.T) {
+	t.Parallel()
+	song := parser.Song{Sections: []parser.Section{
+		{Header: "VERSE 1", Content: []string{
+			"[G]Amazing grace how [C]sweet the [G]sound",
+			"That saved a wretch like [D]me",
+			"I once was lost",
+			"But now am found",
+			"Was blind but now I see",
+		}},
+		{Header: "INSTRUMENTAL", Content: []string{"[C] [G] | [D]"}},
+		{Header: "PRE-CHORUS", Content: []string{"{comment: softly}", "My chains are gone", "", "I've been set free"}},
+	}}
+	var buf bytes.Buffer
+	if err := WriteProPresenter(&buf, song, ProPresenterOptions{}); err != nil {
+		t.Fatalf("WriteProPresenter: %v", err)
+	}
+	want := `Verse 1
+Amazing grace how sweet the sound
+That saved a wretch like me
+I once was lost
+But now am found
+
+Was blind but now I see
+
+Pre-Chorus
+My chains are gone
+
+I've been set free
+`
+	if got := buf.String(); got != want {
+		t.Fatalf("unexpected output:\n--- got ---\n%s\n--- want ---\n%s", got, want)
+	}
+}
+
+func TestLyricSlides_MaxLines(t *testing.T) {
+	t.Parallel()
+	content := []string{"a", "b", "c", "", "[G]d", "e"}
+	got := lyricSlides(content, 2)
+	want := [][]string{{"a", "b"}, {"c"}, {"d", "e"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("lyricSlides = %v, want %v", got, want)
+	}
+}
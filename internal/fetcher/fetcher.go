@@ -1,3 +1,409 @@
 package fetcher
 
-// TODO: Implement the fetcher package
+import (
+	"bytes"
+	"chordparser/internal/backup"
+	"chordparser/internal/pool"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultBaseURL is the root of the Planning Center API.
+const DefaultBaseURL = "https://api.planningcenteronline.com"
+
+// Defaults of the options.
+const (
+	// DefaultPerPage is the page size of list requests (the API maximum).
+	DefaultPerPage = 100
+	// DefaultRetries is how often a request answered with 429 Too Many Requests is retried.
+	DefaultRetries = 3
+)
+
+// Options configures a Client.
+type Options struct {
+	// BaseURL is the API root; empty means DefaultBaseURL (tests use a pcofake server).
+	BaseURL string
+	// AppID and Secret are the personal access token, sent as HTTP basic auth.
+	AppID, Secret string
+	// HTTPClient sends the requests; nil means a client with a 30 second timeout.
+	HTTPClient *http.Client
+	// Limiter, if set, is waited on before every request (retries included), so that all
+	// workers sharing the client together stay within the API rate limit.
+	Limiter pool.Limiter
+	// PerPage is the page size of list requests; zero means DefaultPerPage.
+	PerPage int
+	// Retries is how often a request answered with 429 Too Many Requests is retried after
+	// its Retry-After delay; zero means DefaultRetries and negative means never.
+	Retries int
+}
+
+// Client reads songs and arrangements from the Planning Center Services API and writes
+// arrangements back. It implements backup.Writer and is safe for concurrent use.
+type Client struct {
+	opts Options
+	base string
+	http *http.Client
+}
+
+// New returns a client for the API at opts.BaseURL.
+func New(opts Options) *Client {
+	if opts.PerPage <= 0 {
+		opts.PerPage = DefaultPerPage
+	}
+	if opts.Retries == 0 {
+		opts.Retries = DefaultRetries
+	}
+	c := &Client{opts: opts, base: strings.TrimRight(opts.BaseURL, "/"), http: opts.HTTPClient}
+	if c.base == "" {
+		c.base = DefaultBaseURL
+	}
+	if c.http == nil {
+		c.http = &http.Client{Timeout: 30 * time.Second}
+	}
+	return c
+}
+
+// Song is a PCO song.
+type Song struct {
+	ID         string
+	Title      string
+	Author     string
+	CCLINumber int
+	UpdatedAt  time.Time
+}
+
+// Arrangement is a PCO arrangement of a song.
+type Arrangement struct {
+	SongID        string
+	ID            string
+	Name          string
+	ChordChart    string
+	ChordChartKey string
+	Sequence      []string
+	Lyrics        string
+	UpdatedAt     time.Time
+}
+
+// Fields returns the fields the sync writes (see backup.Writer).
+func (a Arrangement) Fields() backup.Arrangement {
+	return backup.Arrangement{ChordChart: a.ChordChart, Sequence: a.Sequence, Lyrics: a.Lyrics, UpdatedAt: a.UpdatedAt}
+}
+
+// Error is an error response of the API. A 409 Conflict or 412 Precondition Failed
+// matches backup.ErrConflict with errors.Is.
+type Error struct {
+	Method, Path string
+	Status       int
+	// Detail is the detail (or title) of the first JSON:API error, if any.
+	Detail string
+}
+
+func (e *Error) Error() string {
+	msg := fmt.Sprintf("fetcher: %s %s: %d %s", e.Method, e.Path, e.Status, http.StatusText(e.Status))
+	if e.Detail != "" {
+		msg += ": " + e.Detail
+	}
+	return msg
+}
+
+// Unwrap makes conflicts match backup.ErrConflict.
+func (e *Error) Unwrap() error {
+	if e.Status == http.StatusConflict || e.Status == http.StatusPreconditionFailed {
+		return backup.ErrConflict
+	}
+	return nil
+}
+
+// StatusCode returns the HTTP status of an API error, or 0 if err is not one.
+func StatusCode(err error) int {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Status
+	}
+	return 0
+}
+
+// JSON:API documents and resources as the API sends them.
+type (
+	document struct {
+		Data  json.RawMessage `json:"data"`
+		Links struct {
+			Next string `json:"next"`
+		} `json:"links"`
+		Errors []struct {
+			Title  string `json:"title"`
+			Detail string `json:"detail"`
+		} `json:"errors"`
+	}
+	songResource struct {
+		ID         string `json:"id"`
+		Attributes struct {
+			Title      string    `json:"title"`
+			Author     string    `json:"author"`
+			CCLINumber int       `json:"ccli_number"`
+			UpdatedAt  time.Time `json:"updated_at"`
+		} `json:"attributes"`
+	}
+	arrangementResource struct {
+		ID         string `json:"id"`
+		Attributes struct {
+			Name          string    `json:"name"`
+			ChordChart    string    `json:"chord_chart"`
+			ChordChartKey string    `json:"chord_chart_key"`
+			Sequence      []string  `json:"sequence"`
+			Lyrics        string    `json:"lyrics"`
+			UpdatedAt     time.Time `json:"updated_at"`
+		} `json:"attributes"`
+	}
+)
+
+func (r arrangementResource) arrangement(songID string) Arrangement {
+	a := r.Attributes
+	return Arrangement{
+		SongID: songID, ID: r.ID, Name: a.Name, ChordChart: a.ChordChart, ChordChartKey: a.ChordChartKey,
+		Sequence: a.Sequence, Lyrics: a.Lyrics, UpdatedAt: a.UpdatedAt,
+	}
+}
+
+// Songs returns every song of the library, following the pagination.
+func (c *Client) Songs(ctx context.Context) ([]Song, error) {
+	var songs []Song
+	err := c.list(ctx, "/services/v2/songs", func(data json.RawMessage) error {
+		var page []songResource
+		if err := json.Unmarshal(data, &page); err != nil {
+			return err
+		}
+		for _, r := range page {
+			a := r.Attributes
+			songs = append(songs, Song{ID: r.ID, Title: a.Title, Author: a.Author, CCLINumber: a.CCLINumber, UpdatedAt: a.UpdatedAt})
+		}
+		return nil
+	})
+	return songs, err
+}
+
+// Arrangements returns every arrangement of a song.
+func (c *Client) Arrangements(ctx context.Context, songID string) ([]Arrangement, error) {
+	var arrangements []Arrangement
+	err := c.list(ctx, arrangementsPath(songID), func(data json.RawMessage) error {
+		var page []arrangementResource
+		if err := json.Unmarshal(data, &page); err != nil {
+			return err
+		}
+		for _, r := range page {
+			arrangements = append(arrangements, r.arrangement(songID))
+		}
+		return nil
+	})
+	return arrangements, err
+}
+
+// Library returns the arrangements of every song, in song order. The songs' arrangements are
+// listed on a pool of workers (see pool.Map); the client's limiter paces their requests.
+func (c *Client) Library(ctx context.Context, workers int) ([]Arrangement, error) {
+	songs, err := c.Songs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	type result struct {
+		arrangements []Arrangement
+		err          error
+	}
+	results := pool.Map(ctx, songs, pool.Options{Workers: workers}, func(ctx context.Context, s Song) result {
+		a, err := c.Arrangements(ctx, s.ID)
+		return result{a, err}
+	}, func(_ Song, err error) result {
+		return result{err: err}
+	})
+	var all []Arrangement
+	for _, r := range results {
+		if r.err != nil {
+			return nil, r.err
+		}
+		all = append(all, r.arrangements...)
+	}
+	return all, nil
+}
+
+// Arrangement returns one arrangement of a song.
+func (c *Client) Arrangement(ctx context.Context, songID, id string) (Arrangement, error) {
+	var doc document
+	if err := c.do(ctx, http.MethodGet, c.base+arrangementPath(songID, id), nil, &doc); err != nil {
+		return Arrangement{}, err
+	}
+	var r arrangementResource
+	if err := json.Unmarshal(doc.Data, &r); err != nil {
+		return Arrangement{}, fmt.Errorf("fetcher: arrangement %s: %w", id, err)
+	}
+	return r.arrangement(songID), nil
+}
+
+// Get returns the fields of an arrangement that the sync writes (see backup.Writer).
+func (c *Client) Get(ctx context.Context, songID, id string) (backup.Arrangement, error) {
+	a, err := c.Arrangement(ctx, songID, id)
+	if err != nil {
+		return backup.Arrangement{}, err
+	}
+	return a.Fields(), nil
+}
+
+// Patch writes the fields of an arrangement that differ from its current state (usually just
+// the chord chart) and returns the new updated_at (see backup.Writer). PCO has no conditional
+// write, so Patch reads the arrangement first and returns an error matching
+// backup.ErrConflict if its updated_at no longer equals ifUpdatedAt. This pre-check is best
+// effort: a change made between the read and the write is overwritten. If no field differs,
+// nothing is written and the current updated_at is returned.
+func (c *Client) Patch(ctx context.Context, songID, id string, a backup.Arrangement, ifUpdatedAt time.Time) (time.Time, error) {
+	cur, err := c.Arrangement(ctx, songID, id)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if !cur.UpdatedAt.Equal(ifUpdatedAt) {
+		return time.Time{}, fmt.Errorf("fetcher: arrangement %s: %w (updated_at %s, expected %s)", id, backup.ErrConflict,
+			cur.UpdatedAt.Format(time.RFC3339), ifUpdatedAt.Format(time.RFC3339))
+	}
+
+	attributes := map[string]any{}
+	if a.ChordChart != cur.ChordChart {
+		attributes["chord_chart"] = a.ChordChart
+	}
+	if !slices.Equal(a.Sequence, cur.Sequence) {
+		sequence := a.Sequence
+		if sequence == nil {
+			sequence = []string{}
+		}
+		attributes["sequence"] = sequence
+	}
+	if a.Lyrics != cur.Lyrics {
+		attributes["lyrics"] = a.Lyrics
+	}
+	if len(attributes) == 0 {
+		return cur.UpdatedAt, nil
+	}
+	body, err := json.Marshal(map[string]any{"data": map[string]any{"type": "Arrangement", "id": id, "attributes": attributes}})
+	if err != nil {
+		return time.Time{}, fmt.Errorf("fetcher: %w", err)
+	}
+	var doc document
+	if err := c.do(ctx, http.MethodPatch, c.base+arrangementPath(songID, id), body, &doc); err != nil {
+		return time.Time{}, err
+	}
+	var r arrangementResource
+	if err := json.Unmarshal(doc.Data, &r); err != nil {
+		return time.Time{}, fmt.Errorf("fetcher: arrangement %s: %w", id, err)
+	}
+	return r.Attributes.UpdatedAt, nil
+}
+
+// list requests every page of a list endpoint and passes each page's data to fn.
+func (c *Client) list(ctx context.Context, path string, fn func(json.RawMessage) error) error {
+	next := c.base + path + "?per_page=" + strconv.Itoa(c.opts.PerPage)
+	for next != "" {
+		var doc document
+		if err := c.do(ctx, http.MethodGet, next, nil, &doc); err != nil {
+			return err
+		}
+		if err := fn(doc.Data); err != nil {
+			return fmt.Errorf("fetcher: %s: %w", path, err)
+		}
+		next = doc.Links.Next
+	}
+	return nil
+}
+
+// do sends a request, retrying after 429 Too Many Requests, and decodes the JSON:API response
+// into doc.
+func (c *Client) do(ctx context.Context, method, rawURL string, body []byte, doc *document) error {
+	path := rawURL
+	if u, err := url.Parse(rawURL); err == nil {
+		path = u.Path
+	}
+	for attempt := 0; ; attempt++ {
+		if c.opts.Limiter != nil {
+			if err := c.opts.Limiter.Wait(ctx); err != nil {
+				return err
+			}
+		}
+		req, err := http.NewRequestWithContext(ctx, method, rawURL, bytes.NewReader(body))
+		if err != nil {
+			return fmt.Errorf("fetcher: %w", err)
+		}
+		req.Header.Set("Accept", "application/json")
+		if body != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		if c.opts.AppID != "" || c.opts.Secret != "" {
+			req.SetBasicAuth(c.opts.AppID, c.opts.Secret)
+		}
+
+		resp, err := c.http.Do(req)
+		if err != nil {
+			return fmt.Errorf("fetcher: %s %s: %w", method, path, err)
+		}
+		data, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return fmt.Errorf("fetcher: %s %s: %w", method, path, err)
+		}
+
+		if resp.StatusCode == http.StatusTooManyRequests && attempt < c.opts.Retries {
+			if err := sleep(ctx, retryAfter(resp.Header.Get("Retry-After"))); err != nil {
+				return err
+			}
+			continue
+		}
+		*doc = document{}
+		jsonErr := json.Unmarshal(data, doc)
+		if resp.StatusCode >= 300 {
+			e := &Error{Method: method, Path: path, Status: resp.StatusCode}
+			if len(doc.Errors) > 0 {
+				e.Detail = doc.Errors[0].Detail
+				if e.Detail == "" {
+					e.Detail = doc.Errors[0].Title
+				}
+			}
+			return e
+		}
+		if jsonErr != nil {
+			return fmt.Errorf("fetcher: %s %s: %w", method, path, jsonErr)
+		}
+		return nil
+	}
+}
+
+// retryAfter reads a Retry-After header in seconds, defaulting to one second.
+func retryAfter(v string) time.Duration {
+	if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+		return time.Duration(n) * time.Second
+	}
+	return time.Second
+}
+
+// sleep waits for d or until ctx is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func arrangementsPath(songID string) string {
+	return "/services/v2/songs/" + url.PathEscape(songID) + "/arrangements"
+}
+
+func arrangementPath(songID, id string) string {
+	return arrangementsPath(songID) + "/" + url.PathEscape(id)
+}
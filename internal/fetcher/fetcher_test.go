@@ -0,0 +1,142 @@
+package fetcher
+
+import (
+	"bytes"
+	"chordparser/internal/backup"
+	"chordparser/internal/pcofake"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countingLimiter counts the requests it paces.
+type countingLimiter struct{ n atomic.Int64 }
+
+func (l *countingLimiter) Wait(context.Context) error {
+	l.n.Add(1)
+	return nil
+}
+
+func TestClient_LibraryPaginated(t *testing.T) {
+	t.Parallel()
+	srv := pcofake.Start(pcofake.Options{RateLimit: -1, Username: "app", Password: "secret"})
+	defer srv.Close()
+	limiter := &countingLimiter{}
+	c := New(Options{BaseURL: srv.URL, AppID: "app", Secret: "secret", PerPage: 1, Limiter: limiter})
+
+	songs, err := c.Songs(context.Background())
+	if err != nil {
+		t.Fatalf("Songs: %v", err)
+	}
+	if len(songs) != 3 || songs[0].ID != "101" || songs[0].Title != "Amazing Grace" || songs[0].CCLINumber != 22025 {
+		t.Fatalf("songs = %+v", songs)
+	}
+
+	all, err := c.Library(context.Background(), 4)
+	if err != nil {
+		t.Fatalf("Library: %v", err)
+	}
+	var ids []string
+	for _, a := range all {
+		ids = append(ids, a.SongID+"/"+a.ID)
+	}
+	if want := []string{"101/1001", "101/1002", "102/1003", "103/1004"}; !slices.Equal(ids, want) {
+		t.Fatalf("arrangements = %v, want %v", ids, want)
+	}
+	if all[0].ChordChart == "" || all[0].ChordChartKey != "G" || len(all[0].Sequence) != 3 || all[0].UpdatedAt.IsZero() {
+		t.Fatalf("arrangement = %+v", all[0])
+	}
+	if got := limiter.n.Load(); got != int64(srv.Requests()) {
+		t.Fatalf("limiter paced %d requests, server got %d", got, srv.Requests())
+	}
+
+	_, err = New(Options{BaseURL: srv.URL, AppID: "app", Secret: "wrong"}).Songs(context.Background())
+	if StatusCode(err) != http.StatusUnauthorized {
+		t.Fatalf("wrong secret: %v", err)
+	}
+}
+
+func TestClient_PatchConflict(t *testing.T) {
+	t.Parallel()
+	srv := pcofake.New(pcofake.Options{RateLimit: -1})
+	var patches []map[string]json.RawMessage
+	var mu sync.Mutex
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPatch {
+			var body struct {
+				Data struct {
+					Attributes map[string]json.RawMessage `json:"attributes"`
+				} `json:"data"`
+			}
+			data, _ := io.ReadAll(r.Body)
+			_ = json.Unmarshal(data, &body)
+			mu.Lock()
+			patches = append(patches, body.Data.Attributes)
+			mu.Unlock()
+			r.Body = io.NopCloser(bytes.NewReader(data))
+		}
+		srv.ServeHTTP(w, r)
+	}))
+	defer ts.Close()
+	c := New(Options{BaseURL: ts.URL})
+	ctx := context.Background()
+
+	var w backup.Writer = c
+	cur, err := w.Get(ctx, "101", "1001")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	updated, err := w.Patch(ctx, "101", "1001", backup.Arrangement{ChordChart: "Verse\nLa\n", Sequence: cur.Sequence, Lyrics: cur.Lyrics}, cur.UpdatedAt)
+	if err != nil {
+		t.Fatalf("Patch: %v", err)
+	}
+	if a, _ := srv.Arrangement("101", "1001"); a.ChordChart != "Verse\nLa\n" || !a.UpdatedAt.Equal(updated) || !updated.After(cur.UpdatedAt) {
+		t.Fatalf("arrangement after Patch = %+v, updated_at %v", a, updated)
+	}
+	// Only the changed attribute is sent
+	if len(patches) != 1 || len(patches[0]) != 1 || patches[0]["chord_chart"] == nil {
+		t.Fatalf("PATCH attributes = %s", patches)
+	}
+	// Nothing changed, nothing sent
+	if again, err := w.Patch(ctx, "101", "1001", backup.Arrangement{ChordChart: "Verse\nLa\n", Sequence: cur.Sequence, Lyrics: cur.Lyrics}, updated); err != nil || !again.Equal(updated) || len(patches) != 1 {
+		t.Fatalf("unchanged Patch = %v, %v; %d PATCHes", again, err, len(patches))
+	}
+
+	// The old version is stale now
+	if _, err := w.Patch(ctx, "101", "1001", backup.Arrangement{ChordChart: "x"}, cur.UpdatedAt); !errors.Is(err, backup.ErrConflict) {
+		t.Fatalf("stale Patch = %v, want ErrConflict", err)
+	}
+	if _, err := c.Arrangement(ctx, "101", "9999"); StatusCode(err) != http.StatusNotFound {
+		t.Fatalf("missing arrangement = %v, want 404", err)
+	}
+}
+
+func TestClient_RetriesAfterRateLimit(t *testing.T) {
+	t.Parallel()
+	srv := pcofake.Start(pcofake.Options{RateLimit: 1, RatePeriod: time.Second})
+	defer srv.Close()
+	c := New(Options{BaseURL: srv.URL})
+
+	start := time.Now()
+	for _, id := range []string{"1001", "1002"} {
+		if _, err := c.Arrangement(context.Background(), "101", id); err != nil {
+			t.Fatalf("Arrangement %s: %v", id, err)
+		}
+	}
+	if srv.Requests() != 3 || time.Since(start) < 500*time.Millisecond {
+		t.Fatalf("expected one 429 and a wait: %d requests in %v", srv.Requests(), time.Since(start))
+	}
+
+	_, err := New(Options{BaseURL: srv.URL, Retries: -1}).Songs(context.Background())
+	if StatusCode(err) != http.StatusTooManyRequests {
+		t.Fatalf("without retries = %v, want 429", err)
+	}
+}
@@ -0,0 +1,159 @@
+package importer
+
+import (
+	"bytes"
+	"chordparser/internal/parser"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Format identifies a song file format that can be imported.
+type Format string
+
+const (
+	OnSong     Format = "onsong"
+	OpenSong   Format = "opensong"
+	OpenLyrics Format = "openlyrics"
+)
+
+// Formats lists the supported import formats.
+var Formats = []Format{OnSong, OpenSong, OpenLyrics}
+
+// Verse tags as used by OpenSong and OpenLyrics (and OnSong shorthand), mapped to canonical headers.
+var tagHeaders = map[string]string{
+	"v":  "VERSE",
+	"c":  "CHORUS",
+	"p":  "PRE-CHORUS",
+	"pc": "PRE-CHORUS",
+	"b":  "BRIDGE",
+	"br": "BRIDGE",
+	"t":  "TAG",
+	"i":  "INTRO",
+	"e":  "ENDING",
+	"o":  "GENERAL",
+}
+
+var (
+	// Verse tag (one of tagHeaders) with optional number and split suffix, e.g. "v1", "C", "v2a",
+	// "pc", "Br"
+	reVerseTag = regexp.MustCompile(`(?i)^(pc|br|[vcpbtieo])(?:(\d+)([a-z])?)?$`)
+	// OpenLyrics namespace, used to tell OpenLyrics from OpenSong XML
+	reOpenLyricsNS = regexp.MustCompile(`openlyrics\.info/namespace`)
+	// Root <song> element of an XML song file
+	reXMLSong = regexp.MustCompile(`(?s)^\s*(?:<\?xml[^>]*\?>\s*)?(?:<!--.*?-->\s*)*<song[\s>]`)
+)
+
+// DetectFormat guesses the import format of a file from its name and content.
+// It reports false for plain ChordPro/text charts, which go straight to the parser.
+func DetectFormat(name string, data []byte) (Format, bool) {
+	if strings.EqualFold(filepath.Ext(name), ".onsong") {
+		return OnSong, true
+	}
+	if reXMLSong.Match(data) {
+		if reOpenLyricsNS.Match(data) {
+			return OpenLyrics, true
+		}
+		return OpenSong, true
+	}
+	return "", false
+}
+
+// Import converts a song file in the given format into a parser.Song.
+func Import(format Format, data []byte) (parser.Song, error) {
+	return ImportWith(format, data, parser.Options{})
+}
+
+// ImportWith is like Import but applies the parser options (language, abbreviations, folds)
+// where a format leaves header detection to the parser: the OnSong body and OpenSong section
+// names that are not verse tags. OpenLyrics verse names are always tags.
+func ImportWith(format Format, data []byte, opts parser.Options) (parser.Song, error) {
+	switch format {
+	case OnSong:
+		return ParseOnSongWith(string(data), opts)
+	case OpenSong:
+		return ParseOpenSongWith(bytes.NewReader(data), opts)
+	case OpenLyrics:
+		return ParseOpenLyrics(bytes.NewReader(data))
+	}
+	return parser.Song{}, fmt.Errorf("importer: unsupported format %q", format)
+}
+
+// TagHeader maps a verse tag such as "v1", "C", "v2a" or "pc" to a canonical header
+// ("VERSE 1", "CHORUS", "VERSE 2", "PRE-CHORUS"). It reports false for unknown tags.
+func TagHeader(tag string) (string, bool) {
+	m := reVerseTag.FindStringSubmatch(strings.TrimSpace(tag))
+	if m == nil {
+		return "", false
+	}
+	base, ok := tagHeaders[strings.ToLower(m[1])]
+	if !ok {
+		return "", false
+	}
+	if n, err := strconv.Atoi(m[2]); err == nil && n > 0 && base != "GENERAL" {
+		return base + " " + strconv.Itoa(n), true
+	}
+	return base, true
+}
+
+// sequence maps a space-separated verse order (e.g., "v1 c v2 c") to headers,
+// skipping unknown tags.
+func sequence(order string) []string {
+	var seq []string
+	for _, tag := range strings.Fields(order) {
+		if h, ok := TagHeader(tag); ok {
+			seq = append(seq, h)
+		}
+	}
+	return seq
+}
+
+// appendSection adds content under header, merging with the previous section when it
+// has the same header (split verses such as "v1a" and "v1b").
+func appendSection(sections []parser.Section, header string, content []string) []parser.Section {
+	if n := len(sections); n > 0 && sections[n-1].Header == header {
+		sections[n-1].Content = append(sections[n-1].Content, content...)
+		return sections
+	}
+	return append(sections, parser.Section{Header: header, Content: content})
+}
+
+// mergeChordLine places the chords of a chord line inline into the lyric line below it,
+// at the same columns, e.g. "G   C" over "Amazing grace" gives "[G]Amaz[C]ing grace".
+func mergeChordLine(chords, lyric string) string {
+	type placed struct {
+		col   int
+		chord string
+	}
+	var ps []placed
+	runes := []rune(chords)
+	for i := 0; i < len(runes); {
+		if runes[i] == ' ' {
+			i++
+			continue
+		}
+		j := i
+		for j < len(runes) && runes[j] != ' ' {
+			j++
+		}
+		ps = append(ps, placed{col: i, chord: string(runes[i:j])})
+		i = j
+	}
+
+	lr := []rune(lyric)
+	var b strings.Builder
+	pos := 0
+	for _, p := range ps {
+		// Chord past the end of the lyric: pad so it still follows the text
+		for len(lr) < p.col {
+			lr = append(lr, ' ')
+		}
+		b.WriteString(string(lr[pos:p.col]))
+		b.WriteString("[" + p.chord + "]")
+		pos = p.col
+	}
+	b.WriteString(string(lr[pos:]))
+	return strings.TrimRight(b.String(), " ")
+}
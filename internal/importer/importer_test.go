@@ -0,0 +1,73 @@
+package importer
+
+import (
+	"os"
+	"testing"
+)
+
+func TestTagHeader(t *testing.T) {
+	t.Parallel()
+	cases := map[string]string{
+		"v1":  "VERSE 1",
+		"V2":  "VERSE 2",
+		"c":   "CHORUS",
+		"C2":  "CHORUS 2",
+		"b":   "BRIDGE",
+		"p":   "PRE-CHORUS",
+		"PC":  "PRE-CHORUS",
+		"t":   "TAG",
+		"i":   "INTRO",
+		"e":   "ENDING",
+		"v2a": "VERSE 2",
+		"o":   "GENERAL",
+		"Br":  "BRIDGE",
+		"pc2": "PRE-CHORUS 2",
+		"V10": "VERSE 10",
+	}
+	for in, want := range cases {
+		if got, ok := TagHeader(in); !ok || got != want {
+			t.Fatalf("TagHeader(%q) = %q, %v; want %q, true", in, got, ok, want)
+		}
+	}
+	for _, tag := range []string{"x1", "va", "pcx", "verse", "b2ab"} {
+		if got, ok := TagHeader(tag); ok {
+			t.Fatalf("TagHeader(%q) = %q, want no match", tag, got)
+		}
+	}
+}
+
+func TestDetectFormat(t *testing.T) {
+	t.Parallel()
+	cases := map[string]Format{
+		"testdata/amazing-grace.onsong":         OnSong,
+		"testdata/amazing-grace.opensong.xml":   OpenSong,
+		"testdata/amazing-grace.openlyrics.xml": OpenLyrics,
+	}
+	for name, want := range cases {
+		data, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatalf("read %s: %v", name, err)
+		}
+		if got, ok := DetectFormat(name, data); !ok || got != want {
+			t.Fatalf("DetectFormat(%q) = %q, %v; want %q, true", name, got, ok, want)
+		}
+	}
+	if got, ok := DetectFormat("song.cho", []byte("[Verse]\n[C]Hello")); ok {
+		t.Fatalf("DetectFormat(chordpro) = %q, want no match", got)
+	}
+}
+
+func TestMergeChordLine(t *testing.T) {
+	t.Parallel()
+	cases := []struct{ chords, lyric, want string }{
+		{"G   C", "Amazing grace", "[G]Amaz[C]ing grace"},
+		{"   D", "Me", "Me [D]"},
+		{"G", "", "[G]"},
+		{"", "Just words", "Just words"},
+	}
+	for _, c := range cases {
+		if got := mergeChordLine(c.chords, c.lyric); got != c.want {
+			t.Fatalf("mergeChordLine(%q, %q) = %q, want %q", c.chords, c.lyric, got, c.want)
+		}
+	}
+}
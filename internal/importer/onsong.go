@@ -0,0 +1,85 @@
+package importer
+
+import (
+	"chordparser/internal/normalize"
+	"chordparser/internal/parser"
+	"regexp"
+	"strings"
+)
+
+// OnSong metadata lines in the header block, e.g. "Key: G" or "CCLI: 22025"
+var reOnSongMeta = regexp.MustCompile(`(?i)^(title|artist|author|key|ccli|copyright|tempo|time|capo|keywords|flow)\s*:\s*(.*)$`)
+
+// ParseOnSong converts an OnSong chart into a parser.Song.
+// The header block (up to the first blank line) holds the title, the artist and
+// "Name: value" metadata; the body is ChordPro-like text handled by parser.Parse.
+// An OnSong "Flow:" line (e.g., "V1 C V2 C") becomes the song sequence.
+func ParseOnSong(text string) (parser.Song, error) {
+	return ParseOnSongWith(text, parser.Options{})
+}
+
+// ParseOnSongWith is like ParseOnSong but parses the body with the given options.
+func ParseOnSongWith(text string, opts parser.Options) (parser.Song, error) {
+	var song parser.Song
+	lines := strings.Split(normalize.Newlines(text), "\n")
+
+	i := 0
+	for i < len(lines) && strings.TrimSpace(lines[i]) == "" {
+		i++
+	}
+	plain := 0
+	for ; i < len(lines); i++ {
+		l := strings.TrimSpace(lines[i])
+		if l == "" {
+			i++
+			break
+		}
+		if m := reOnSongMeta.FindStringSubmatch(l); m != nil {
+			value := strings.TrimSpace(m[2])
+			switch strings.ToLower(m[1]) {
+			case "title":
+				song.Title = value
+			case "artist", "author":
+				song.Author = value
+			case "key":
+				song.Key = value
+			case "ccli":
+				song.CCLI = value
+			case "flow":
+				song.Sequence = sequence(value)
+			}
+			continue
+		}
+		// Section labels ("Verse 1:") and chord lines start the body
+		if plain >= 2 || strings.HasSuffix(l, ":") || strings.Contains(l, "[") {
+			break
+		}
+		// Untagged lines: first the title, then the artist
+		if plain == 0 && song.Title == "" {
+			song.Title = l
+		} else if song.Author == "" {
+			song.Author = l
+		}
+		plain++
+	}
+
+	// Parse the body as given and shift its positions so they refer to the whole chart
+	body := len(text)
+	if src := normalize.Lines(text); i < len(src) {
+		body = src[i].Start
+	}
+	song.Sections = parser.ParseWith(text[body:], opts)
+	for k := range song.Sections {
+		s := &song.Sections[k]
+		s.Pos = shift(s.Pos, i, body)
+		for j := range s.Lines {
+			s.Lines[j] = shift(s.Lines[j], i, body)
+		}
+	}
+	return song, nil
+}
+
+// shift moves a span found in a part of a chart that starts at the given line index and byte.
+func shift(sp parser.Span, line, offset int) parser.Span {
+	return parser.Span{Line: sp.Line + line, Start: sp.Start + offset, End: sp.End + offset}
+}
@@ -0,0 +1,75 @@
+package importer
+
+import (
+	"chordparser/internal/parser"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestParseOnSong(t *testing.T) {
+	t.Parallel()
+	data, err := os.ReadFile("testdata/amazing-grace.onsong")
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	got, err := ParseOnSong(string(data))
+	if err != nil {
+		t.Fatalf("ParseOnSong: %v", err)
+	}
+	want := parser.Song{
+		Title:    "Amazing Grace",
+		Author:   "John Newton",
+		Key:      "G",
+		CCLI:     "22025",
+		Sequence: []string{"VERSE 1", "CHORUS", "VERSE 2", "CHORUS"},
+		Sections: []parser.Section{
+			{Header: "VERSE 1", Content: []string{"[G]Amazing grace how [C]sweet the [G]sound", "That saved a wretch like [D]me", ""}},
+			{Header: "CHORUS", Content: []string{"My chains are [C]gone, I've been set [G]free", ""}},
+			{Header: "VERSE 2", Content: []string{"'Twas [G]grace that taught my [C]heart to [G]fear", ""}},
+		},
+	}
+	// Positions refer to the whole file, header block included
+	text := string(data)
+	if p := got.Sections[1].Pos; p.Line != 11 || text[p.Start:p.End] != "Chorus:" {
+		t.Fatalf("chorus position = %+v", p)
+	}
+	if l := got.Sections[0].Lines[1]; l.Line != 9 || text[l.Start:l.End] != want.Sections[0].Content[1] {
+		t.Fatalf("verse line 2 position = %+v", l)
+	}
+	got.Sections = withoutPositions(got.Sections)
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("song mismatch:\nwant: %#v\n got: %#v", want, got)
+	}
+}
+
+// withoutPositions clears section positions for comparisons of content only.
+func withoutPositions(sections []parser.Section) []parser.Section {
+	for i := range sections {
+		sections[i].Pos, sections[i].Lines = parser.Span{}, nil
+	}
+	return sections
+}
+
+func TestParseOnSong_NoMetadata(t *testing.T) {
+	t.Parallel()
+	got, err := ParseOnSong("Verse 1:\n[C]Hello\n")
+	if err != nil {
+		t.Fatalf("ParseOnSong: %v", err)
+	}
+	if got.Title != "" || len(got.Sections) != 1 || got.Sections[0].Header != "VERSE 1" {
+		t.Fatalf("unexpected song: %#v", got)
+	}
+}
+
+func TestParseOnSongWith_Options(t *testing.T) {
+	t.Parallel()
+	chart := "Title\n\nV1\nHello\nC:\nWorld\n"
+	if got, _ := ParseOnSong(chart); len(got.Sections) != 1 || got.Sections[0].Header != "GENERAL" {
+		t.Fatalf("without abbreviations: %#v", got.Sections)
+	}
+	got, err := ParseOnSongWith(chart, parser.Options{Abbreviations: true})
+	if err != nil || len(got.Sections) != 2 || got.Sections[0].Header != "VERSE 1" || got.Sections[1].Header != "CHORUS" {
+		t.Fatalf("with abbreviations: %#v, %v", got.Sections, err)
+	}
+}
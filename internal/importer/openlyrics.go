@@ -0,0 +1,133 @@
+package importer
+
+import (
+	"bytes"
+	"chordparser/internal/parser"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// openLyricsXML is the subset of an OpenLyrics song that is imported.
+// Element names match regardless of the OpenLyrics namespace.
+type openLyricsXML struct {
+	XMLName    xml.Name `xml:"song"`
+	Titles     []string `xml:"properties>titles>title"`
+	Authors    []string `xml:"properties>authors>author"`
+	CCLI       string   `xml:"properties>ccliNo"`
+	Key        string   `xml:"properties>key"`
+	VerseOrder string   `xml:"properties>verseOrder"`
+	Verses     []struct {
+		Name  string `xml:"name,attr"`
+		Lines []struct {
+			Inner []byte `xml:",innerxml"`
+		} `xml:"lines"`
+	} `xml:"lyrics>verse"`
+}
+
+// Source whitespace containing a newline, which is formatting rather than a line break
+var reXMLNewline = regexp.MustCompile(`[ \t]*\r?\n\s*`)
+
+// ParseOpenLyrics converts an OpenLyrics (0.8/0.9) XML song into a parser.Song.
+// Verse names ("v1", "c", "b", "v1a") become canonical headers, <br/> and <line>
+// end lines, <chord name="G"/> becomes an inline "[G]" and <comment> is dropped.
+func ParseOpenLyrics(r io.Reader) (parser.Song, error) {
+	var doc openLyricsXML
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return parser.Song{}, fmt.Errorf("importer: openlyrics: %w", err)
+	}
+
+	song := parser.Song{
+		CCLI:     strings.TrimSpace(doc.CCLI),
+		Key:      strings.TrimSpace(doc.Key),
+		Sequence: sequence(doc.VerseOrder),
+	}
+	if len(doc.Titles) > 0 {
+		song.Title = strings.TrimSpace(doc.Titles[0])
+	}
+	var authors []string
+	for _, a := range doc.Authors {
+		if a = strings.TrimSpace(a); a != "" {
+			authors = append(authors, a)
+		}
+	}
+	song.Author = strings.Join(authors, ", ")
+
+	var sections []parser.Section
+	for _, v := range doc.Verses {
+		header, ok := TagHeader(v.Name)
+		if !ok {
+			// A section name such as "Chorus", or else an untitled section
+			if header, _, ok = parser.HeaderLine(v.Name, parser.Options{}); !ok {
+				header = "GENERAL"
+			}
+		}
+		var content []string
+		for _, l := range v.Lines {
+			lines, err := openLyricsLines(l.Inner)
+			if err != nil {
+				return parser.Song{}, fmt.Errorf("importer: openlyrics: verse %q: %w", v.Name, err)
+			}
+			content = append(content, lines...)
+		}
+		sections = appendSection(sections, header, content)
+	}
+	song.Sections = parser.UniqueHeaders(sections)
+	return song, nil
+}
+
+// openLyricsLines converts the inner XML of a <lines> element to text lines.
+func openLyricsLines(inner []byte) ([]string, error) {
+	dec := xml.NewDecoder(io.MultiReader(
+		strings.NewReader("<lines>"), bytes.NewReader(inner), strings.NewReader("</lines>"),
+	))
+	var b strings.Builder
+	comment := 0
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "chord":
+				for _, a := range t.Attr {
+					if a.Name.Local == "name" && comment == 0 {
+						b.WriteString("[" + a.Value + "]")
+					}
+				}
+			case "br":
+				b.WriteByte('\n')
+			case "comment":
+				comment++
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "line":
+				b.WriteByte('\n')
+			case "comment":
+				comment--
+			}
+		case xml.CharData:
+			if comment == 0 {
+				b.WriteString(reXMLNewline.ReplaceAllString(string(t), " "))
+			}
+		}
+	}
+
+	text := strings.TrimRight(b.String(), "\n")
+	if strings.TrimSpace(text) == "" {
+		return nil, nil
+	}
+	lines := strings.Split(text, "\n")
+	for i := range lines {
+		lines[i] = strings.TrimSpace(lines[i])
+	}
+	return lines, nil
+}
@@ -0,0 +1,52 @@
+package importer
+
+import (
+	"chordparser/internal/parser"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseOpenLyrics(t *testing.T) {
+	t.Parallel()
+	f, err := os.Open("testdata/amazing-grace.openlyrics.xml")
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer f.Close()
+
+	got, err := ParseOpenLyrics(f)
+	if err != nil {
+		t.Fatalf("ParseOpenLyrics: %v", err)
+	}
+	want := parser.Song{
+		Title:    "Amazing Grace",
+		Author:   "John Newton, Traditional",
+		Key:      "G",
+		CCLI:     "22025",
+		Sequence: []string{"VERSE 1", "CHORUS", "VERSE 2", "CHORUS"},
+		Sections: []parser.Section{
+			{Header: "VERSE 1", Content: []string{"[G]Amazing grace how [C]sweet the [G]sound", "That saved a wretch like [D]me"}},
+			{Header: "CHORUS", Content: []string{"My chains are [C]gone", "I've been set [G]free"}},
+			{Header: "VERSE 2", Content: []string{"'Twas grace that taught", "My heart to fear"}},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("song mismatch:\nwant: %#v\n got: %#v", want, got)
+	}
+}
+
+func TestParseOpenLyrics_LineElements(t *testing.T) {
+	t.Parallel()
+	in := `<song xmlns="http://openlyrics.info/namespace/2009/song" version="0.8"><properties><titles><title>T</title></titles></properties>` +
+		`<lyrics><verse name="v1"><lines><line>One</line><line>Two</line></lines></verse></lyrics></song>`
+	got, err := ParseOpenLyrics(strings.NewReader(in))
+	if err != nil {
+		t.Fatalf("ParseOpenLyrics: %v", err)
+	}
+	want := []parser.Section{{Header: "VERSE 1", Content: []string{"One", "Two"}}}
+	if !reflect.DeepEqual(got.Sections, want) {
+		t.Fatalf("sections mismatch:\nwant: %#v\n got: %#v", want, got.Sections)
+	}
+}
@@ -0,0 +1,137 @@
+package importer
+
+import (
+	"chordparser/internal/normalize"
+	"chordparser/internal/parser"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// openSongXML is the subset of an OpenSong song file that is imported.
+type openSongXML struct {
+	XMLName      xml.Name `xml:"song"`
+	Title        string   `xml:"title"`
+	Author       string   `xml:"author"`
+	CCLI         string   `xml:"ccli"`
+	Key          string   `xml:"key"`
+	Presentation string   `xml:"presentation"`
+	Lyrics       string   `xml:"lyrics"`
+}
+
+// ParseOpenSong converts an OpenSong XML song into a parser.Song.
+// In the lyrics, "[V1]" starts a section, lines starting with "." are chords for the
+// lyric line below (merged inline as "[G]"), ";" lines are comments and lines starting
+// with a digit belong to that numbered verse of the current section.
+func ParseOpenSong(r io.Reader) (parser.Song, error) {
+	return ParseOpenSongWith(r, parser.Options{})
+}
+
+// ParseOpenSongWith is like ParseOpenSong but detects the headers of section names that are
+// not verse tags (e.g., "[Refrein]") with the given parser options.
+func ParseOpenSongWith(r io.Reader, opts parser.Options) (parser.Song, error) {
+	var doc openSongXML
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return parser.Song{}, fmt.Errorf("importer: opensong: %w", err)
+	}
+
+	song := parser.Song{
+		Title:    strings.TrimSpace(doc.Title),
+		Author:   strings.TrimSpace(doc.Author),
+		CCLI:     strings.TrimSpace(doc.CCLI),
+		Key:      strings.TrimSpace(doc.Key),
+		Sequence: sequence(doc.Presentation),
+	}
+	song.Sections = parseOpenSongLyrics(doc.Lyrics, opts)
+	return song, nil
+}
+
+func parseOpenSongLyrics(lyrics string, opts parser.Options) []parser.Section {
+	var sections []parser.Section
+	current := -1
+	base := "GENERAL"
+	// Section index of each numbered verse in the current block, for multi-verse lines
+	numbered := map[string]int{}
+	chords, hasChords := "", false
+
+	// appendLine adds a line to section idx, starting a GENERAL section if there is none yet.
+	appendLine := func(idx int, line string) {
+		if idx < 0 {
+			sections = append(sections, parser.Section{Header: base})
+			current, idx = len(sections)-1, len(sections)-1
+		}
+		sections[idx].Content = append(sections[idx].Content, strings.TrimRight(line, " "))
+	}
+	// withChords merges a pending chord line into the lyric line below it.
+	withChords := func(text string) string {
+		if hasChords {
+			hasChords = false
+			return mergeChordLine(chords, text)
+		}
+		return text
+	}
+
+	for _, line := range strings.Split(normalize.Newlines(lyrics), "\n") {
+		switch {
+		case strings.HasPrefix(line, "["):
+			if hasChords {
+				appendLine(current, strings.TrimSpace(chords))
+				hasChords = false
+			}
+			name := strings.TrimSpace(strings.Trim(strings.TrimSpace(line), "[]"))
+			if h, ok := TagHeader(name); ok {
+				base = h
+			} else if h, _, ok := parser.HeaderLine(name, opts); ok {
+				base = h
+			} else {
+				// An empty ("[]") or unknown tag starts an untitled section
+				base = "GENERAL"
+			}
+			sections = append(sections, parser.Section{Header: base})
+			current = len(sections) - 1
+			numbered = map[string]int{}
+		case strings.HasPrefix(line, "."):
+			if hasChords {
+				appendLine(current, strings.TrimSpace(chords))
+			}
+			chords, hasChords = line[1:], true
+		case strings.HasPrefix(line, ";"), strings.HasPrefix(line, "-"):
+			// Comments, page and column breaks
+		case line != "" && line[0] >= '1' && line[0] <= '9':
+			// Multi-verse line: "1 Amazing grace" belongs to verse 1 of this block, "12 ..." to verse 12
+			digits := len(line) - len(strings.TrimLeft(line, "0123456789"))
+			header := strings.Fields(base)[0] + " " + line[:digits]
+			idx, ok := numbered[header]
+			if !ok {
+				if current >= 0 && len(sections[current].Content) == 0 {
+					// First numbered verse takes over the empty block section
+					sections[current].Header = header
+					idx = current
+				} else {
+					sections = append(sections, parser.Section{Header: header})
+					idx = len(sections) - 1
+				}
+				numbered[header] = idx
+			}
+			appendLine(idx, withChords(line[digits:]))
+		default:
+			appendLine(current, withChords(strings.TrimPrefix(line, " ")))
+		}
+	}
+	if hasChords {
+		appendLine(current, strings.TrimSpace(chords))
+	}
+
+	// Drop trailing blank lines within sections and sections left empty
+	out := sections[:0]
+	for _, s := range sections {
+		for len(s.Content) > 0 && strings.TrimSpace(s.Content[len(s.Content)-1]) == "" {
+			s.Content = s.Content[:len(s.Content)-1]
+		}
+		if len(s.Content) > 0 {
+			out = append(out, s)
+		}
+	}
+	return parser.UniqueHeaders(out)
+}
@@ -0,0 +1,66 @@
+package importer
+
+import (
+	"chordparser/internal/parser"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseOpenSong(t *testing.T) {
+	t.Parallel()
+	f, err := os.Open("testdata/amazing-grace.opensong.xml")
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer f.Close()
+
+	got, err := ParseOpenSong(f)
+	if err != nil {
+		t.Fatalf("ParseOpenSong: %v", err)
+	}
+	want := parser.Song{
+		Title:    "Amazing Grace",
+		Author:   "John Newton",
+		Key:      "G",
+		CCLI:     "22025",
+		Sequence: []string{"VERSE 1", "CHORUS", "VERSE 2", "CHORUS"},
+		Sections: []parser.Section{
+			{Header: "VERSE 1", Content: []string{"[G]Amazing grace how [C]sweet the [G]sound"}},
+			{Header: "VERSE 2", Content: []string{"[G]'Twas grace that taught my [C]heart to [G]fear"}},
+			{Header: "CHORUS", Content: []string{"My chains are [C]gone, I've been set [G]free"}},
+			{Header: "BRIDGE", Content: []string{"[G]Oh [D]oh"}},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("song mismatch:\nwant: %#v\n got: %#v", want, got)
+	}
+}
+
+func TestParseOpenSong_InvalidXML(t *testing.T) {
+	t.Parallel()
+	if _, err := ParseOpenSong(strings.NewReader("<song><title>x</song>")); err == nil {
+		t.Fatalf("expected error for invalid XML")
+	}
+}
+
+func TestParseOpenSong_EmptyAndUnknownTags(t *testing.T) {
+	t.Parallel()
+	doc := "<song><title>x</title><lyrics>[]\n1 hello\n2 world\n[ ]\n lone\n[Refrein]\n la\n[va]\n nonsense\n[V]\n9 nine\n10 ten\n11 eleven</lyrics></song>"
+	got, err := ParseOpenSongWith(strings.NewReader(doc), parser.Options{Language: "nl"})
+	if err != nil {
+		t.Fatalf("ParseOpenSong: %v", err)
+	}
+	var headers []string
+	for _, s := range got.Sections {
+		headers = append(headers, s.Header)
+	}
+	want := []string{"GENERAL 1", "GENERAL 2", "GENERAL 3", "CHORUS", "GENERAL 4", "VERSE 9", "VERSE 10", "VERSE 11"}
+	if !reflect.DeepEqual(headers, want) {
+		t.Fatalf("headers = %q, want %q", headers, want)
+	}
+	if got := got.Sections[6].Content; !reflect.DeepEqual(got, []string{" ten"}) {
+		t.Fatalf("verse 10 = %q", got)
+	}
+}
@@ -0,0 +1,153 @@
+package langdetect
+
+import (
+	"embed"
+	"math"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// Languages supported by the embedded model, as ISO 639-1 codes.
+var Languages = []string{"de", "en", "es", "fr", "nl", "pt"}
+
+// Unknown is returned when the text is too short or too ambiguous to classify.
+const Unknown = ""
+
+const (
+	// minTrigrams is the minimum number of trigrams needed before a guess is made.
+	minTrigrams = 20
+	// minMargin is the minimum average log-probability gap per trigram between
+	// the best and second-best language.
+	minMargin = 0.05
+)
+
+//go:embed corpus/*.txt
+var corpusFS embed.FS
+
+var (
+	// Remove bracketed chords ("[Am]"), ChordPro directives ("{title: ...}") and HTML tags
+	reMarkup = regexp.MustCompile(`\[[^\]]*\]|\{[^}]*\}|<[^>]*>`)
+	// Naked chord tokens (e.g., "C", "F#m7", "G/B") on chord-only lines
+	reChordToken = regexp.MustCompile(`^[A-G](?:#|b)?(?:maj|min|m|dim|aug|sus|add)?\d*(?:/[A-G](?:#|b)?)?$`)
+)
+
+// profile holds trigram counts for one language.
+type profile struct {
+	lang   string
+	counts map[string]int
+	total  int
+}
+
+var (
+	loadOnce sync.Once
+	profiles []profile
+	vocab    int
+)
+
+// load builds the trigram profiles from the embedded corpus.
+func load() {
+	seen := make(map[string]struct{})
+	for _, lang := range Languages {
+		b, err := corpusFS.ReadFile(path.Join("corpus", lang+".txt"))
+		if err != nil {
+			panic("langdetect: missing corpus for " + lang)
+		}
+		p := profile{lang: lang, counts: make(map[string]int)}
+		for _, g := range trigrams(string(b)) {
+			p.counts[g]++
+			p.total++
+			seen[g] = struct{}{}
+		}
+		profiles = append(profiles, p)
+	}
+	vocab = len(seen)
+}
+
+// Detect guesses the language of a chord chart from its lyric body.
+// Chords, directives and markup are ignored. It returns Unknown when there is
+// not enough lyric text or no language is a clear winner.
+func Detect(text string) string {
+	lang, _ := DetectWithScore(text)
+	return lang
+}
+
+// DetectWithScore is like Detect but also returns the winning margin
+// (average log-probability gap per trigram over the runner-up).
+func DetectWithScore(text string) (string, float64) {
+	loadOnce.Do(load)
+
+	grams := trigrams(Lyrics(text))
+	if len(grams) < minTrigrams {
+		return Unknown, 0
+	}
+
+	type scored struct {
+		lang  string
+		score float64
+	}
+	scores := make([]scored, 0, len(profiles))
+	for _, p := range profiles {
+		var s float64
+		denom := float64(p.total + vocab + 1)
+		for _, g := range grams {
+			s += math.Log(float64(p.counts[g]+1) / denom)
+		}
+		scores = append(scores, scored{lang: p.lang, score: s / float64(len(grams))})
+	}
+	sort.Slice(scores, func(i, j int) bool {
+		if scores[i].score != scores[j].score {
+			return scores[i].score > scores[j].score
+		}
+		return scores[i].lang < scores[j].lang
+	})
+
+	margin := scores[0].score - scores[1].score
+	if margin < minMargin {
+		return Unknown, margin
+	}
+	return scores[0].lang, margin
+}
+
+// Lyrics extracts the lyric body of a chart: markup is removed and
+// lines consisting only of chords are dropped.
+func Lyrics(text string) string {
+	var b strings.Builder
+	for _, line := range strings.Split(text, "\n") {
+		line = reMarkup.ReplaceAllString(line, " ")
+		fields := strings.Fields(line)
+		var words []string
+		for _, f := range fields {
+			if f == "|" || reChordToken.MatchString(f) {
+				continue
+			}
+			words = append(words, f)
+		}
+		// A line that lost most of its tokens to chord matching is a chord line.
+		if len(words) == 0 || len(words)*2 < len(fields) {
+			continue
+		}
+		b.WriteString(strings.Join(words, " "))
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// trigrams returns the character trigrams of every word in s,
+// lowercased and padded with a space on both sides.
+func trigrams(s string) []string {
+	var out []string
+	words := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && r != '\''
+	})
+	for _, w := range words {
+		r := []rune(" " + w + " ")
+		for i := 0; i+3 <= len(r); i++ {
+			out = append(out, string(r[i:i+3]))
+		}
+	}
+	return out
+}
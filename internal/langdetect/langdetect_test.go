@@ -0,0 +1,46 @@
+package langdetect
+
+import "testing"
+
+func TestDetect_HeldOutLyrics(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]string{
+		"en": "[G]Amazing grace how [C]sweet the sound\nThat saved a wretch like me\nI once was lost but now am found\nWas blind but now I see",
+		"nl": "[G]Wat de toekomst [C]brengen moge\nMij geleidt des Heren hand\nMoedig sla ik dus de ogen\nNaar het onbekende land",
+		"de": "[G]Großer Gott wir [C]loben dich\nHerr wir preisen deine Stärke\nVor dir neigt die Erde sich\nUnd bewundert deine Werke",
+		"fr": "[G]À toi la gloire [C]ô ressuscité\nÀ toi la victoire pour l'éternité\nBrillant de lumière l'ange est descendu\nIl roule la pierre du tombeau vaincu",
+		"es": "[G]Cuán grande es [C]él, mi corazón entona\nLa canción de alabanza a ti mi Dios\nCuando contemplo las obras de tus manos\nEl cielo azul y las estrellas que creaste",
+		"pt": "[G]Quão grande és tu, [C]minha alma canta\nA ti senhor a canção do meu louvor\nQuando eu contemplo tudo o que criaste\nAs estrelas e o céu que fizeste com amor",
+	}
+	for want, text := range cases {
+		if got := Detect(text); got != want {
+			_, margin := DetectWithScore(text)
+			t.Fatalf("Detect(%s lyrics) = %q (margin %.3f), want %q", want, got, margin, want)
+		}
+	}
+}
+
+func TestDetect_TooShortIsUnknown(t *testing.T) {
+	t.Parallel()
+	if got := Detect("Tag"); got != Unknown {
+		t.Fatalf("Detect(%q) = %q, want Unknown", "Tag", got)
+	}
+}
+
+func TestDetect_ChordsOnlyIsUnknown(t *testing.T) {
+	t.Parallel()
+	in := "C G Am F\n[C] [G] | [Am] [F]\nF#m D/E G#maj7 Cb\nC G Am F\nC G Am F\nC G Am F"
+	if got := Detect(in); got != Unknown {
+		t.Fatalf("Detect(chords) = %q, want Unknown", got)
+	}
+}
+
+func TestLyrics_DropsChordsAndDirectives(t *testing.T) {
+	t.Parallel()
+	in := "{title: Song}\nC G Am F\n[C]Amazing [G]grace\n<b>Verse</b>"
+	want := "Amazing grace\nVerse\n"
+	if got := Lyrics(in); got != want {
+		t.Fatalf("Lyrics(%q) = %q, want %q", in, got, want)
+	}
+}
@@ -0,0 +1,239 @@
+package lint
+
+import (
+	"chordparser/internal/chord"
+	"chordparser/internal/normalize"
+	"chordparser/internal/parser"
+	"chordparser/internal/processor"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Severity of a finding, using the SARIF level names.
+type Severity string
+
+const (
+	Error   Severity = "error"
+	Warning Severity = "warning"
+	Note    Severity = "note"
+)
+
+// Rule describes a check.
+type Rule struct {
+	ID          string
+	Description string
+	Severity    Severity
+}
+
+// Rules lists all checks, in the order they are documented.
+var Rules = []Rule{
+	{"no-headers", "No section headers found; the whole chart becomes one GENERAL section.", Warning},
+	{"duplicate-header", "A section header occurs more than once and will be renumbered.", Warning},
+	{"unknown-bracket", "A bracketed token is not a chord.", Warning},
+	{"invalid-chord", "A bracketed chord cannot be parsed by the chord grammar.", Warning},
+	{"out-of-key", "A chord is neither diatonic to the song key nor a common borrowed chord.", Note},
+	{"mixed-chord-line", "A chord line also holds lyric words.", Warning},
+	{"repeat-mid-line", "A repeat marker appears in the middle of a line.", Warning},
+	{"empty-section", "A section header has no content.", Warning},
+}
+
+// Finding is a problem found in a chart. Line and Column are 1-based; Column is 0 when the
+// finding concerns the whole line.
+type Finding struct {
+	Rule     string   `json:"rule"`
+	Severity Severity `json:"severity"`
+	Line     int      `json:"line"`
+	Column   int      `json:"column,omitempty"`
+	Message  string   `json:"message"`
+}
+
+var (
+	// Bracketed token, e.g. "[G]" or "[Chorus]"
+	reBracket = regexp.MustCompile(`\[([^\[\]]*)\]`)
+	// Whole-line ChordPro directive, e.g. "{end_of_verse}"
+	reDirectiveLine = regexp.MustCompile(`^\s*\{[^}]*\}\s*$`)
+	// Repeat markers as removed by the processor: "(x2)", "x3", "2x", "×2"
+	reRepeat = regexp.MustCompile(`(?i)\(\s*(?:\d+\s*[x×]|[x×]\s*\d+)\s*\)|(?:^|\s)(?:\d+[x×]|[x×]\d+)(?:\s|$)`)
+)
+
+// Options controls linting.
+type Options struct {
+	// Parser holds the header rules to apply (language, abbreviations, folds).
+	Parser parser.Options
+	// Key is the song key (e.g., the PCO arrangement key) used to find chords foreign to the key.
+	// Empty means the chart's {key:} directive, if any.
+	Key string
+}
+
+// section tracks the open section while linting.
+type section struct {
+	header     string
+	line       int
+	hasContent bool
+}
+
+// Lint checks a chart and returns its findings ordered by line. It uses the parser's header
+// rules, the processor's chord line heuristics and the chord grammar, but changes nothing.
+func Lint(text string, opts Options) []Finding {
+	var findings []Finding
+	add := func(rule string, line, col int, format string, args ...any) {
+		findings = append(findings, Finding{
+			Rule:     rule,
+			Severity: ruleSeverity(rule),
+			Line:     line,
+			Column:   col,
+			Message:  fmt.Sprintf(format, args...),
+		})
+	}
+
+	folds := normalize.FoldsOrDefault(opts.Parser.Folds)
+	// The headers come from the parse tree, since whether a bare note letter such as "C" is a
+	// header depends on the chart's other headers
+	tree := parser.ParseTree(text, opts.Parser)
+	headerLines := map[int]*parser.TreeLine{}
+	for _, s := range tree.Sections {
+		if s.Header != nil {
+			headerLines[s.Header.Pos.Line] = s.Header
+		}
+	}
+	keyName := opts.Key
+	if keyName == "" {
+		keyName = tree.Song().Key
+	}
+	var key *chord.Key
+	if k, err := chord.ParseKey(keyName); err == nil {
+		key = &k
+	}
+	lines := strings.Split(normalize.Newlines(text), "\n")
+
+	var cur *section
+	firstSeen := map[string]int{}
+	headers, content := 0, false
+	closeSection := func() {
+		if cur != nil && !cur.hasContent {
+			add("empty-section", cur.line, 0, "section %q has no content", cur.header)
+		}
+	}
+
+	for i, raw := range lines {
+		n := i + 1
+		line := normalize.Fold(raw, folds)
+
+		if h, ok := headerLines[n]; ok {
+			header, rest := h.Header, h.Rest
+			closeSection()
+			headers++
+			if first, seen := firstSeen[header]; seen {
+				add("duplicate-header", n, 0, "duplicate header %q (first on line %d) will be renumbered", header, first)
+			} else {
+				firstSeen[header] = n
+			}
+			cur = &section{header: header, line: n, hasContent: rest != ""}
+			continue
+		}
+		if strings.TrimSpace(line) == "" || reDirectiveLine.MatchString(line) {
+			continue
+		}
+		content = true
+		if cur != nil {
+			cur.hasContent = true
+		}
+
+		for _, loc := range reBracket.FindAllStringSubmatchIndex(line, -1) {
+			tok := strings.TrimSpace(line[loc[2]:loc[3]])
+			if tok == "" || tok == "|" {
+				continue
+			}
+			col := column(line, loc[0])
+			p, bad := chord.Check(tok, key)
+			switch {
+			case !bad:
+			case p.Kind == chord.OutOfKey:
+				add("out-of-key", n, col, "%s", p.Message)
+			case p.Suggestion != "" || looksLikeChord(tok):
+				add("invalid-chord", n, col, "%s", p.Message)
+			default:
+				add("unknown-bracket", n, col, "bracketed token [%s] is not a chord", tok)
+			}
+		}
+		if chords, words := chordWords(line); chords >= 2 && words > 0 && chords >= words {
+			add("mixed-chord-line", n, 0, "chord line mixed with %d lyric word(s)", words)
+		}
+		for _, loc := range reRepeat.FindAllStringIndex(line, -1) {
+			if strings.TrimSpace(line[loc[1]:]) != "" {
+				m := line[loc[0]:loc[1]]
+				start := loc[0] + len(m) - len(strings.TrimLeft(m, " \t"))
+				add("repeat-mid-line", n, column(line, start), "repeat marker %q in the middle of the line", strings.TrimSpace(m))
+			}
+		}
+	}
+	closeSection()
+
+	if headers == 0 && content {
+		add("no-headers", 1, 0, "no section headers found; the chart becomes one GENERAL section")
+	}
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].Line != findings[j].Line {
+			return findings[i].Line < findings[j].Line
+		}
+		return findings[i].Column < findings[j].Column
+	})
+	return findings
+}
+
+// chordWords counts the naked chord tokens and the lyric words on a line with bracketed
+// chords removed. Bar lines are ignored.
+func chordWords(line string) (chords, words int) {
+	for _, tok := range strings.Fields(reBracket.ReplaceAllString(line, " ")) {
+		switch {
+		case tok == "|":
+		case processor.IsChord(tok):
+			chords++
+		default:
+			words++
+		}
+	}
+	return chords, words
+}
+
+// looksLikeChord reports whether an unparsable token was probably meant as a chord rather than
+// a word such as "Chorus": a note letter (or German H) not followed by a lowercase letter,
+// apart from the "b" of a flat or the "m" of a minor chord.
+func looksLikeChord(tok string) bool {
+	if len(tok) > 10 || tok[0] < 'A' || tok[0] > 'H' {
+		return false
+	}
+	if len(tok) == 1 {
+		return true
+	}
+	c := tok[1]
+	return c < 'a' || c > 'z' || c == 'b' || c == 'm'
+}
+
+// column converts a byte offset to a 1-based column in Unicode code points.
+func column(line string, offset int) int {
+	return utf8.RuneCountInString(line[:offset]) + 1
+}
+
+// ruleSeverity returns the default severity of a rule.
+func ruleSeverity(id string) Severity {
+	for _, r := range Rules {
+		if r.ID == id {
+			return r.Severity
+		}
+	}
+	return Warning
+}
+
+// Failed reports whether any finding is a warning or an error.
+func Failed(findings []Finding) bool {
+	for _, f := range findings {
+		if f.Severity == Warning || f.Severity == Error {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,114 @@
+package lint
+
+import (
+	"chordparser/internal/parser"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// rulesAt returns "line:rule" for each finding, for compact comparisons.
+func rulesAt(findings []Finding) []string {
+	out := []string{}
+	for _, f := range findings {
+		out = append(out, fmt.Sprintf("%02d:%s", f.Line, f.Rule))
+	}
+	return out
+}
+
+func TestLint_Findings(t *testing.T) {
+	t.Parallel()
+	chart := strings.Join([]string{
+		"Verse 1",                  // 1
+		"[G]Amazing [Chorus]grace", // 2: unknown-bracket
+		"G  C  D  and then",        // 3: mixed-chord-line
+		"Sing (x2) it again",       // 4: repeat-mid-line
+		"Chorus",                   // 5: empty-section
+		"",                         // 6
+		"Verse 1",                  // 7: duplicate-header
+		"How sweet the sound (x2)", // 8: repeat at end is fine
+		"Chorus",                   // 9: duplicate-header
+		"[C] [G] | [D]",            // 10
+		"{comment: softly}",        // 11
+	}, "\n")
+	got := rulesAt(Lint(chart, Options{}))
+	want := []string{"02:unknown-bracket", "03:mixed-chord-line", "04:repeat-mid-line", "05:empty-section", "07:duplicate-header", "09:duplicate-header"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("findings = %v, want %v", got, want)
+	}
+}
+
+func TestLint_PositionsAndMessages(t *testing.T) {
+	t.Parallel()
+	got := Lint("Verse\nLa [Chorus] la x2 la", Options{})
+	want := []Finding{
+		{Rule: "unknown-bracket", Severity: Warning, Line: 2, Column: 4, Message: "bracketed token [Chorus] is not a chord"},
+		{Rule: "repeat-mid-line", Severity: Warning, Line: 2, Column: 16, Message: `repeat marker "x2" in the middle of the line`},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("findings = %#v, want %#v", got, want)
+	}
+}
+
+func TestLint_NoHeaders(t *testing.T) {
+	t.Parallel()
+	got := Lint("Amazing grace\nHow sweet the sound\n", Options{})
+	if len(got) != 1 || got[0].Rule != "no-headers" || got[0].Line != 1 {
+		t.Fatalf("findings = %#v", got)
+	}
+	if !Failed(got) {
+		t.Fatalf("a warning should fail the lint")
+	}
+	if got := Lint("", Options{}); len(got) != 0 {
+		t.Fatalf("empty chart should have no findings, got %#v", got)
+	}
+}
+
+func TestLint_CleanChart(t *testing.T) {
+	t.Parallel()
+	chart := "{title: Song}\n\nVerse 1\n[G]Amazing [C/E]grace\n\nChorus: [Am7]How sweet\n\n{start_of_bridge: Bridge}\n[Dsus4]Oh\n{end_of_bridge}\n"
+	if got := Lint(chart, Options{}); len(got) != 0 {
+		t.Fatalf("expected no findings, got %#v", got)
+	}
+}
+
+func TestLint_RespectsParserOptions(t *testing.T) {
+	t.Parallel()
+	chart := "V1\nLa\nC\nLi\n"
+	if got := rulesAt(Lint(chart, Options{})); !reflect.DeepEqual(got, []string{"01:no-headers"}) {
+		t.Fatalf("without abbreviations: %v", got)
+	}
+	if got := Lint(chart, Options{Parser: parser.Options{Abbreviations: true}}); len(got) != 0 {
+		t.Fatalf("with abbreviations: %#v", got)
+	}
+	// A bare "C" is a header as the parser reads it: in a chart of abbreviated headers
+	if got := rulesAt(Lint(chart+"C\nLo\n", Options{Parser: parser.Options{Abbreviations: true}})); !reflect.DeepEqual(got, []string{"05:duplicate-header"}) {
+		t.Fatalf("bare note letters: %v", got)
+	}
+}
+
+func TestLint_ChordValidation(t *testing.T) {
+	t.Parallel()
+	chart := "{key: G}\nVerse\n[G]La [Cmj7]li [H]lo [C8]la [N.C.]\n[C#]Oh [Eb]yes [Amen]\n"
+	got := Lint(chart, Options{})
+	want := []Finding{
+		{Rule: "invalid-chord", Severity: Warning, Line: 3, Column: 7, Message: "Cmj7 is not a valid chord; did you mean Cmaj7?"},
+		{Rule: "invalid-chord", Severity: Warning, Line: 3, Column: 16, Message: "H is not a valid chord; did you mean B?"},
+		{Rule: "invalid-chord", Severity: Warning, Line: 3, Column: 22, Message: "C8 is not a valid chord"},
+		{Rule: "out-of-key", Severity: Note, Line: 4, Column: 1, Message: "C# is not in the key of G"},
+		{Rule: "invalid-chord", Severity: Warning, Line: 4, Column: 16, Message: "Amen is not a valid chord"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("findings:\n%#v\nwant:\n%#v", got, want)
+	}
+
+	// The arrangement key overrides the chart
+	got = Lint(chart, Options{Key: "Db"})
+	if n := len(got); n != 5 || got[0].Message != "G is not in the key of Db" {
+		t.Fatalf("findings with key Db: %#v", got)
+	}
+	if Failed([]Finding{{Severity: Note}}) {
+		t.Fatalf("notes should not fail the lint")
+	}
+}
@@ -0,0 +1,122 @@
+package lint
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+)
+
+// FileReport holds the findings of one chart file.
+type FileReport struct {
+	File     string    `json:"file"`
+	Findings []Finding `json:"findings"`
+}
+
+// WriteText writes one line per finding, e.g. "song.cho:3:5: warning: ... [unknown-bracket]".
+func WriteText(w io.Writer, reports []FileReport) error {
+	for _, r := range reports {
+		for _, f := range r.Findings {
+			pos := fmt.Sprintf("%s:%d", r.File, f.Line)
+			if f.Column > 0 {
+				pos += fmt.Sprintf(":%d", f.Column)
+			}
+			if _, err := fmt.Fprintf(w, "%s: %s: %s [%s]\n", pos, f.Severity, f.Message, f.Rule); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// WriteJSON writes the reports as an indented JSON array.
+func WriteJSON(w io.Writer, reports []FileReport) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(reports)
+}
+
+// SARIF 2.1.0 log structure (the subset used here).
+type (
+	sarifLog struct {
+		Schema  string     `json:"$schema"`
+		Version string     `json:"version"`
+		Runs    []sarifRun `json:"runs"`
+	}
+	sarifRun struct {
+		Tool       sarifTool     `json:"tool"`
+		ColumnKind string        `json:"columnKind"`
+		Results    []sarifResult `json:"results"`
+	}
+	sarifTool struct {
+		Driver sarifDriver `json:"driver"`
+	}
+	sarifDriver struct {
+		Name  string      `json:"name"`
+		Rules []sarifRule `json:"rules"`
+	}
+	sarifRule struct {
+		ID                   string       `json:"id"`
+		ShortDescription     sarifMessage `json:"shortDescription"`
+		DefaultConfiguration struct {
+			Level Severity `json:"level"`
+		} `json:"defaultConfiguration"`
+	}
+	sarifMessage struct {
+		Text string `json:"text"`
+	}
+	sarifResult struct {
+		RuleID    string          `json:"ruleId"`
+		RuleIndex int             `json:"ruleIndex"`
+		Level     Severity        `json:"level"`
+		Message   sarifMessage    `json:"message"`
+		Locations []sarifLocation `json:"locations"`
+	}
+	sarifLocation struct {
+		PhysicalLocation struct {
+			ArtifactLocation struct {
+				URI string `json:"uri"`
+			} `json:"artifactLocation"`
+			Region sarifRegion `json:"region"`
+		} `json:"physicalLocation"`
+	}
+	sarifRegion struct {
+		StartLine   int `json:"startLine"`
+		StartColumn int `json:"startColumn,omitempty"`
+	}
+)
+
+// WriteSARIF writes the reports as a SARIF 2.1.0 log, as consumed by code scanning tools.
+// File paths are used as relative artifact URIs.
+func WriteSARIF(w io.Writer, reports []FileReport) error {
+	run := sarifRun{
+		Tool:       sarifTool{Driver: sarifDriver{Name: "chordparser-lint"}},
+		ColumnKind: "unicodeCodePoints",
+		Results:    []sarifResult{},
+	}
+	index := map[string]int{}
+	for i, r := range Rules {
+		sr := sarifRule{ID: r.ID, ShortDescription: sarifMessage{Text: r.Description}}
+		sr.DefaultConfiguration.Level = r.Severity
+		run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sr)
+		index[r.ID] = i
+	}
+	for _, r := range reports {
+		for _, f := range r.Findings {
+			res := sarifResult{RuleID: f.Rule, RuleIndex: index[f.Rule], Level: f.Severity, Message: sarifMessage{Text: f.Message}}
+			var loc sarifLocation
+			loc.PhysicalLocation.ArtifactLocation.URI = filepath.ToSlash(r.File)
+			loc.PhysicalLocation.Region = sarifRegion{StartLine: f.Line, StartColumn: f.Column}
+			res.Locations = []sarifLocation{loc}
+			run.Results = append(run.Results, res)
+		}
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []sarifRun{run},
+	})
+}
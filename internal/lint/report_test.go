@@ -0,0 +1,93 @@
+package lint
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+var testReports = []FileReport{{
+	File: "songs/grace.cho",
+	Findings: []Finding{
+		{Rule: "unknown-bracket", Severity: Warning, Line: 2, Column: 4, Message: "bracketed token [N.C.] is not a chord"},
+		{Rule: "empty-section", Severity: Warning, Line: 5, Message: `section "CHORUS" has no content`},
+	},
+}}
+
+func TestWriteText(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	if err := WriteText(&buf, testReports); err != nil {
+		t.Fatalf("WriteText: %v", err)
+	}
+	want := "songs/grace.cho:2:4: warning: bracketed token [N.C.] is not a chord [unknown-bracket]\n" +
+		"songs/grace.cho:5: warning: section \"CHORUS\" has no content [empty-section]\n"
+	if buf.String() != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	if err := WriteJSON(&buf, testReports); err != nil {
+		t.Fatalf("WriteJSON: %v", err)
+	}
+	var got []FileReport
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil || len(got) != 1 || len(got[0].Findings) != 2 {
+		t.Fatalf("round trip = %#v, %v", got, err)
+	}
+}
+
+func TestWriteSARIF(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	if err := WriteSARIF(&buf, testReports); err != nil {
+		t.Fatalf("WriteSARIF: %v", err)
+	}
+	var log struct {
+		Version string `json:"version"`
+		Runs    []struct {
+			Tool struct {
+				Driver struct {
+					Name  string `json:"name"`
+					Rules []struct {
+						ID string `json:"id"`
+					} `json:"rules"`
+				} `json:"driver"`
+			} `json:"tool"`
+			Results []struct {
+				RuleID    string `json:"ruleId"`
+				RuleIndex int    `json:"ruleIndex"`
+				Level     string `json:"level"`
+				Locations []struct {
+					PhysicalLocation struct {
+						ArtifactLocation struct {
+							URI string `json:"uri"`
+						} `json:"artifactLocation"`
+						Region struct {
+							StartLine   int `json:"startLine"`
+							StartColumn int `json:"startColumn"`
+						} `json:"region"`
+					} `json:"physicalLocation"`
+				} `json:"locations"`
+			} `json:"results"`
+		} `json:"runs"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &log); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if log.Version != "2.1.0" || len(log.Runs) != 1 || len(log.Runs[0].Tool.Driver.Rules) != len(Rules) {
+		t.Fatalf("unexpected log: %s", buf.String())
+	}
+	run := log.Runs[0]
+	if len(run.Results) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(run.Results))
+	}
+	r := run.Results[0]
+	loc := r.Locations[0].PhysicalLocation
+	if run.Tool.Driver.Rules[r.RuleIndex].ID != r.RuleID || r.Level != "warning" ||
+		loc.ArtifactLocation.URI != "songs/grace.cho" || loc.Region.StartLine != 2 || loc.Region.StartColumn != 4 {
+		t.Fatalf("unexpected result: %+v", r)
+	}
+}
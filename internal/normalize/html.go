@@ -0,0 +1,193 @@
+package normalize
+
+import (
+	"html"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+var (
+	// Block and line-break tags of PCO rich-text fields, used to tell HTML from plain ChordPro.
+	// Inline tags and entities alone don't count: plain charts use <b>Verse 1</b> headers.
+	reLooksLikeHTML = regexp.MustCompile(`(?i)<(?:p|br|div|li|h[1-6])(?:\s[^>]*)?/?>`)
+	// Tag name and attributes of a start/end tag, e.g. `span style="font-weight:bold"`
+	reTag = regexp.MustCompile(`^<\s*(/?)\s*([a-zA-Z][a-zA-Z0-9]*)([^>]*?)(/?)\s*>$`)
+	// Runs of source whitespace
+	reHTMLSpace = regexp.MustCompile(`[ \t\r\n]+`)
+	// Bold inline styles, e.g. "font-weight: bold" or "font-weight:700"
+	reBoldStyle = regexp.MustCompile(`(?i)font-weight\s*:\s*(?:bold|bolder|[6-9]00)`)
+)
+
+// Block-level tags that start and end a line of text.
+var blockTags = map[string]bool{
+	"p": true, "div": true, "li": true, "tr": true, "pre": true, "blockquote": true,
+	"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+}
+
+// Tags whose content is never text.
+var skipTags = map[string]bool{"script": true, "style": true, "head": true, "title": true}
+
+// LooksLikeHTML reports whether s is rich text: it contains block or line-break tags
+// (<p>, <div>, <br>, <li>, headings), which carry its line structure. A plain chart with
+// inline tags such as <b>Verse 1</b> is not HTML.
+func LooksLikeHTML(s string) bool {
+	return reLooksLikeHTML.MatchString(s)
+}
+
+// HTMLToText converts a rich-text chord chart to plain text.
+// Block tags (<p>, <div>, <li>, headings) and <br> keep the line structure, an empty
+// paragraph becomes a blank line, other tags are dropped and entities are decoded.
+// It also returns, per output line, whether the line consists only of bold text
+// (<b>, <strong> or a bold <span style>), which marks it as a header candidate.
+func HTMLToText(s string) (string, []bool) {
+	c := htmlConverter{}
+	for len(s) > 0 {
+		i := strings.IndexByte(s, '<')
+		if i < 0 {
+			c.text(s)
+			break
+		}
+		c.text(s[:i])
+		s = s[i:]
+
+		if strings.HasPrefix(s, "<!--") {
+			end := strings.Index(s, "-->")
+			if end < 0 {
+				break
+			}
+			s = s[end+3:]
+			continue
+		}
+		end := strings.IndexByte(s, '>')
+		if end < 0 {
+			// Not a tag after all; keep the rest as text
+			c.text(s)
+			break
+		}
+		c.tag(s[:end+1])
+		s = s[end+1:]
+	}
+	c.flush(false)
+
+	// Drop trailing blank lines left by closing blocks
+	for len(c.lines) > 0 && strings.TrimSpace(c.lines[len(c.lines)-1]) == "" {
+		c.lines = c.lines[:len(c.lines)-1]
+		c.bold = c.bold[:len(c.bold)-1]
+	}
+	return strings.Join(c.lines, "\n"), c.bold
+}
+
+// htmlConverter accumulates output lines while walking the HTML.
+type htmlConverter struct {
+	lines []string
+	bold  []bool
+
+	cur       strings.Builder
+	hasText   bool // current line has visible text
+	allBold   bool // all visible text on the current line is bold
+	blockText bool // current block produced text (or a line break) so far
+
+	boldDepth int
+	spans     []bool // per open <span>, whether it is bold
+	skip      int
+}
+
+func (c *htmlConverter) text(raw string) {
+	if c.skip > 0 || raw == "" {
+		return
+	}
+	// Source whitespace (including newlines) collapses to one space, as in a browser;
+	// only &nbsp; keeps its width
+	t := html.UnescapeString(reHTMLSpace.ReplaceAllString(raw, " "))
+	if strings.HasPrefix(t, " ") && strings.HasSuffix(c.cur.String(), " ") {
+		t = t[1:]
+	}
+	if strings.TrimFunc(t, unicode.IsSpace) == "" {
+		// Keep spacing between words and &nbsp; indentation (chord alignment),
+		// but not source formatting before the first text of a line
+		if c.hasText || strings.ContainsRune(t, '\u00a0') {
+			c.cur.WriteString(t)
+		}
+		return
+	}
+	if !c.hasText {
+		c.allBold = true
+		if !strings.ContainsRune(c.cur.String(), '\u00a0') {
+			c.cur.Reset()
+			t = strings.TrimLeft(t, " ")
+		}
+	}
+	c.hasText = true
+	c.blockText = true
+	if c.boldDepth == 0 {
+		c.allBold = false
+	}
+	c.cur.WriteString(t)
+}
+
+func (c *htmlConverter) tag(raw string) {
+	m := reTag.FindStringSubmatch(raw)
+	if m == nil {
+		return
+	}
+	closing, name, attrs, selfClosing := m[1] == "/", strings.ToLower(m[2]), m[3], m[4] == "/"
+
+	if skipTags[name] {
+		if closing {
+			c.skip = max(0, c.skip-1)
+		} else if !selfClosing {
+			c.skip++
+		}
+		return
+	}
+
+	switch {
+	case name == "br":
+		c.flush(true)
+		c.blockText = true
+	case blockTags[name]:
+		if closing {
+			// An empty block (e.g., "<p></p>" or "<p>&nbsp;</p>") is a blank line
+			c.flush(!c.blockText)
+		} else {
+			c.flush(false)
+		}
+		c.blockText = false
+	case name == "b" || name == "strong":
+		if closing {
+			c.boldDepth = max(0, c.boldDepth-1)
+		} else if !selfClosing {
+			c.boldDepth++
+		}
+	case name == "span":
+		if closing {
+			if n := len(c.spans); n > 0 {
+				if c.spans[n-1] {
+					c.boldDepth = max(0, c.boldDepth-1)
+				}
+				c.spans = c.spans[:n-1]
+			}
+		} else if !selfClosing {
+			bold := reBoldStyle.MatchString(attrs)
+			if bold {
+				c.boldDepth++
+			}
+			c.spans = append(c.spans, bold)
+		}
+	}
+}
+
+// flush ends the current line. Empty lines are only emitted when force is set.
+func (c *htmlConverter) flush(force bool) {
+	if !c.hasText && !force {
+		c.cur.Reset()
+		return
+	}
+	line := strings.TrimRightFunc(c.cur.String(), unicode.IsSpace)
+	c.lines = append(c.lines, line)
+	c.bold = append(c.bold, c.hasText && c.allBold)
+	c.cur.Reset()
+	c.hasText = false
+	c.allBold = false
+}
@@ -0,0 +1,103 @@
+package normalize
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestHTMLToText_ParagraphsAndBreaks(t *testing.T) {
+	t.Parallel()
+	in := "<p>Line 1<br>Line 2<br/>Line 3</p><p>Line 4</p>"
+	want := "Line 1\nLine 2\nLine 3\nLine 4"
+	if got, _ := HTMLToText(in); got != want {
+		t.Fatalf("HTMLToText(%q) = %q, want %q", in, got, want)
+	}
+}
+
+func TestHTMLToText_EmptyParagraphIsBlankLine(t *testing.T) {
+	t.Parallel()
+	in := "<p>A</p><p>&nbsp;</p><p></p><p>B</p>"
+	want := "A\n\n\nB"
+	if got, _ := HTMLToText(in); got != want {
+		t.Fatalf("HTMLToText(%q) = %q, want %q", in, got, want)
+	}
+}
+
+func TestHTMLToText_DecodesEntities(t *testing.T) {
+	t.Parallel()
+	in := "<p>Rock &amp; roll &lt;3 &quot;yes&quot; &#233;</p>"
+	want := `Rock & roll <3 "yes" é`
+	if got, _ := HTMLToText(in); got != want {
+		t.Fatalf("HTMLToText(%q) = %q, want %q", in, got, want)
+	}
+}
+
+func TestHTMLToText_DropsInlineTagsAndSourceNewlines(t *testing.T) {
+	t.Parallel()
+	in := "<div>\n  <p><i>Amazing</i> <span style=\"color:red\">grace</span>\n how sweet</p>\n</div>"
+	want := "Amazing grace how sweet"
+	if got, _ := HTMLToText(in); got != want {
+		t.Fatalf("HTMLToText(%q) = %q, want %q", in, got, want)
+	}
+}
+
+func TestHTMLToText_KeepsNbspIndentation(t *testing.T) {
+	t.Parallel()
+	in := "<p>&nbsp;&nbsp;C&nbsp;&nbsp;G</p>"
+	want := "  C  G"
+	if got, _ := HTMLToText(in); got != want {
+		t.Fatalf("HTMLToText(%q) = %q, want %q", in, got, want)
+	}
+}
+
+func TestHTMLToText_MarksBoldOnlyLines(t *testing.T) {
+	t.Parallel()
+	in := "<p><b>Verse 1</b></p><p>Amazing <b>grace</b></p><p><strong>Chorus</strong>:</p>" +
+		"<p><span style=\"font-weight: bold\">Bridge</span></p><p><span style=\"font-weight:700\"><i>Tag</i></span></p>"
+	wantText := "Verse 1\nAmazing grace\nChorus:\nBridge\nTag"
+	wantBold := []bool{true, false, false, true, true}
+	gotText, gotBold := HTMLToText(in)
+	if gotText != wantText {
+		t.Fatalf("HTMLToText text = %q, want %q", gotText, wantText)
+	}
+	if !reflect.DeepEqual(gotBold, wantBold) {
+		t.Fatalf("HTMLToText bold = %v, want %v", gotBold, wantBold)
+	}
+}
+
+func TestHTMLToText_SkipsStyleAndComments(t *testing.T) {
+	t.Parallel()
+	in := "<style>p { color: red }</style><!-- note --><p>Text</p>"
+	want := "Text"
+	if got, _ := HTMLToText(in); got != want {
+		t.Fatalf("HTMLToText(%q) = %q, want %q", in, got, want)
+	}
+}
+
+func TestHTMLToText_PlainTextUnchanged(t *testing.T) {
+	t.Parallel()
+	in := "Verse 1\n[C]Amazing grace"
+	want := "Verse 1 [C]Amazing grace"
+	if got, _ := HTMLToText(in); got != want {
+		t.Fatalf("HTMLToText(%q) = %q, want %q", in, got, want)
+	}
+}
+
+func TestLooksLikeHTML(t *testing.T) {
+	t.Parallel()
+	cases := map[string]bool{
+		"<p>Verse 1</p>":         true,
+		"Line<br>Line":           true,
+		"<DIV>Verse 1</DIV>":     true,
+		"Rock&nbsp;on":           false,
+		"<span style=\"x\">a":    false,
+		"[C]Amazing grace":       false,
+		"Verse 1\n<b>Chorus</b>": false,
+		"1 < 2 > 0":              false,
+	}
+	for in, want := range cases {
+		if got := LooksLikeHTML(in); got != want {
+			t.Fatalf("LooksLikeHTML(%q) = %v, want %v", in, got, want)
+		}
+	}
+}
@@ -12,6 +12,31 @@ func Newlines(s string) string {
 	return s
 }
 
+// Line is a line of text with the byte range [Start, End) it occupies in the original string,
+// excluding its line terminator.
+type Line struct {
+	Text       string
+	Start, End int
+}
+
+// Lines splits s into lines at LF, CRLF and CR, like strings.Split(Newlines(s), "\n"),
+// but keeps where each line sits in s. The line number of lines[i] is i+1.
+func Lines(s string) []Line {
+	var lines []Line
+	start := 0
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\n' && s[i] != '\r' {
+			continue
+		}
+		lines = append(lines, Line{Text: s[start:i], Start: start, End: i})
+		if s[i] == '\r' && i+1 < len(s) && s[i+1] == '\n' {
+			i++
+		}
+		start = i + 1
+	}
+	return append(lines, Line{Text: s[start:], Start: start, End: len(s)})
+}
+
 // StripDecorations removes simple wrappers like [ ... ] and <b>...</b>.
 func StripDecorations(s string) string {
 	s = strings.TrimSpace(s)
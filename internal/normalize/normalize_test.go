@@ -1,6 +1,10 @@
 package normalize
 
-import "testing"
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
 
 func TestNewlines_CRLFToLF(t *testing.T) {
 	t.Parallel()
@@ -42,6 +46,24 @@ func TestNewlines_Empty(t *testing.T) {
 	}
 }
 
+func TestLines_KeepsByteRanges(t *testing.T) {
+	t.Parallel()
+	in := "ab\r\ncd\rÉ\n\n"
+	want := []Line{{"ab", 0, 2}, {"cd", 4, 6}, {"É", 7, 9}, {"", 10, 10}, {"", 11, 11}}
+	got := Lines(in)
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Lines(%q) = %v, want %v", in, got, want)
+	}
+	for i, l := range got {
+		if in[l.Start:l.End] != l.Text {
+			t.Fatalf("line %d: range [%d,%d) holds %q, want %q", i+1, l.Start, l.End, in[l.Start:l.End], l.Text)
+		}
+	}
+	if n := len(strings.Split(Newlines(in), "\n")); n != len(got) {
+		t.Fatalf("Lines gives %d lines, Newlines %d", len(got), n)
+	}
+}
+
 func TestStripDecorations_BracketsOnly(t *testing.T) {
 	t.Parallel()
 	in, want := "[Verse]", "Verse"
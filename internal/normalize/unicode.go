@@ -0,0 +1,144 @@
+package normalize
+
+import (
+	"strings"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Folds is a set of Unicode and typography folds for Fold to apply, one field per fold.
+// Charts pasted from Word or PDFs carry characters that break chord and header matching.
+type Folds struct {
+	// BOM removes byte order marks (U+FEFF) anywhere in the text.
+	BOM bool
+	// ZeroWidth removes zero-width spaces and joiners and soft hyphens.
+	ZeroWidth bool
+	// NFC composes characters to Unicode normalization form C (e.g., "e" + U+0301 to "é").
+	NFC bool
+	// FullWidth folds full-width ASCII (e.g., "［Ｃ］") and the ideographic space to ASCII.
+	FullWidth bool
+	// Spaces folds non-breaking and other Unicode spaces to an ASCII space.
+	Spaces bool
+	// Quotes folds smart single and double quotes to ASCII ' and ".
+	Quotes bool
+	// Dashes folds hyphen, en/em dash and minus variants to an ASCII hyphen.
+	Dashes bool
+	// Accidentals folds ♯ and ♭ following a note name (e.g., "C♯m", "B♭") to ASCII # and b.
+	Accidentals bool
+}
+
+// DefaultFolds enables every fold.
+var DefaultFolds = Folds{
+	BOM:         true,
+	ZeroWidth:   true,
+	NFC:         true,
+	FullWidth:   true,
+	Spaces:      true,
+	Quotes:      true,
+	Dashes:      true,
+	Accidentals: true,
+}
+
+var (
+	zeroWidthReplacer = strings.NewReplacer(
+		"\u200b", "", // zero width space
+		"\u200c", "", // zero width non-joiner
+		"\u200d", "", // zero width joiner
+		"\u2060", "", // word joiner
+		"\u00ad", "", // soft hyphen
+	)
+	spacesReplacer = strings.NewReplacer(
+		"\u00a0", " ", // no-break space
+		"\u2000", " ", "\u2001", " ", "\u2002", " ", "\u2003", " ", "\u2004", " ", "\u2005", " ",
+		"\u2006", " ", "\u2007", " ", "\u2008", " ", "\u2009", " ", "\u200a", " ",
+		"\u202f", " ", // narrow no-break space
+		"\u205f", " ", // medium mathematical space
+	)
+	quotesReplacer = strings.NewReplacer(
+		"\u2018", "'", "\u2019", "'", "\u201a", "'", "\u201b", "'", "\u2032", "'",
+		"\u201c", `"`, "\u201d", `"`, "\u201e", `"`, "\u201f", `"`, "\u2033", `"`,
+	)
+	dashesReplacer = strings.NewReplacer(
+		"\u2010", "-", "\u2011", "-", "\u2012", "-", "\u2013", "-",
+		"\u2014", "-", "\u2015", "-", "\u2212", "-",
+	)
+)
+
+// FoldsOrDefault returns the given folds, or DefaultFolds if f is nil (as in the Folds
+// options of the parser and processor).
+func FoldsOrDefault(f *Folds) Folds {
+	if f == nil {
+		return DefaultFolds
+	}
+	return *f
+}
+
+// Typography folds Unicode and typography variants to canonical forms using DefaultFolds.
+func Typography(s string) string {
+	return Fold(s, DefaultFolds)
+}
+
+// Fold applies the selected Unicode and typography folds to s.
+func Fold(s string, f Folds) string {
+	if f.BOM {
+		s = strings.ReplaceAll(s, "\ufeff", "")
+	}
+	if f.ZeroWidth {
+		s = zeroWidthReplacer.Replace(s)
+	}
+	if f.NFC {
+		s = norm.NFC.String(s)
+	}
+	if f.FullWidth {
+		s = foldFullWidth(s)
+	}
+	if f.Spaces {
+		s = spacesReplacer.Replace(s)
+	}
+	if f.Quotes {
+		s = quotesReplacer.Replace(s)
+	}
+	if f.Dashes {
+		s = dashesReplacer.Replace(s)
+	}
+	if f.Accidentals {
+		s = foldAccidentals(s)
+	}
+	return s
+}
+
+// foldFullWidth maps full-width ASCII (U+FF01..U+FF5E) and the ideographic space to ASCII.
+func foldFullWidth(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= '\uff01' && r <= '\uff5e':
+			return r - '\uff01' + '!'
+		case r == '\u3000':
+			return ' '
+		}
+		return r
+	}, s)
+}
+
+// foldAccidentals replaces ♯ and ♭ with # and b when they follow a note name (A-G),
+// so chords such as "C♯m" and "B♭/D" match the ASCII chord grammar. Other uses are kept.
+func foldAccidentals(s string) string {
+	if !strings.ContainsAny(s, "♯♭") {
+		return s
+	}
+	var b strings.Builder
+	b.Grow(len(s))
+	var prev rune
+	for _, r := range s {
+		if (r == '♯' || r == '♭') && prev >= 'A' && prev <= 'G' {
+			if r == '♯' {
+				r = '#'
+			} else {
+				r = 'b'
+			}
+		}
+		b.WriteRune(r)
+		prev = r
+	}
+	return b.String()
+}
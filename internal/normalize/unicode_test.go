@@ -0,0 +1,83 @@
+package normalize
+
+import "testing"
+
+func TestFold_BOM(t *testing.T) {
+	t.Parallel()
+	in, want := "\ufeffVerse 1", "Verse 1"
+	if got := Fold(in, Folds{BOM: true}); got != want {
+		t.Fatalf("Fold(%q) = %q, want %q", in, got, want)
+	}
+}
+
+func TestFold_ZeroWidth(t *testing.T) {
+	t.Parallel()
+	in, want := "Cho\u200brus\u200d G\u2060m gra\u00adce", "Chorus Gm grace"
+	if got := Fold(in, Folds{ZeroWidth: true}); got != want {
+		t.Fatalf("Fold(%q) = %q, want %q", in, got, want)
+	}
+}
+
+func TestFold_NFC(t *testing.T) {
+	t.Parallel()
+	in, want := "Refra\u0303o", "Refr\u00e3o"
+	if got := Fold(in, Folds{NFC: true}); got != want {
+		t.Fatalf("Fold(%q) = %q, want %q", in, got, want)
+	}
+}
+
+func TestFold_FullWidth(t *testing.T) {
+	t.Parallel()
+	in, want := "［Ｃ］\u3000Ａｍ", "[C] Am"
+	if got := Fold(in, Folds{FullWidth: true}); got != want {
+		t.Fatalf("Fold(%q) = %q, want %q", in, got, want)
+	}
+}
+
+func TestFold_Spaces(t *testing.T) {
+	t.Parallel()
+	in, want := "C\u00a0G\u2009Am\u202fF", "C G Am F"
+	if got := Fold(in, Folds{Spaces: true}); got != want {
+		t.Fatalf("Fold(%q) = %q, want %q", in, got, want)
+	}
+}
+
+func TestFold_Quotes(t *testing.T) {
+	t.Parallel()
+	in, want := "\u201cI\u2019m free\u201d", `"I'm free"`
+	if got := Fold(in, Folds{Quotes: true}); got != want {
+		t.Fatalf("Fold(%q) = %q, want %q", in, got, want)
+	}
+}
+
+func TestFold_Dashes(t *testing.T) {
+	t.Parallel()
+	in, want := "Chorus \u2013 Hallelujah \u2014 Pre\u2010Chorus", "Chorus - Hallelujah - Pre-Chorus"
+	if got := Fold(in, Folds{Dashes: true}); got != want {
+		t.Fatalf("Fold(%q) = %q, want %q", in, got, want)
+	}
+}
+
+func TestFold_Accidentals(t *testing.T) {
+	t.Parallel()
+	in, want := "C♯m B♭/D ♯", "C#m Bb/D ♯"
+	if got := Fold(in, Folds{Accidentals: true}); got != want {
+		t.Fatalf("Fold(%q) = %q, want %q", in, got, want)
+	}
+}
+
+func TestFold_NoneLeavesTextUnchanged(t *testing.T) {
+	t.Parallel()
+	in := "\ufeff\u201cC♯\u00a0\u2013\u201d"
+	if got := Fold(in, Folds{}); got != in {
+		t.Fatalf("Fold(%q) = %q, want unchanged", in, got)
+	}
+}
+
+func TestTypography_AllFolds(t *testing.T) {
+	t.Parallel()
+	in, want := "\ufeff［C♯］\u00a0Je\u0301sus\u2019 love \u2013 x2", "[C#] Jésus' love - x2"
+	if got := Typography(in); got != want {
+		t.Fatalf("Typography(%q) = %q, want %q", in, got, want)
+	}
+}
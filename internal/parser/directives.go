@@ -0,0 +1,108 @@
+package parser
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	// ChordPro directive filling a whole line, e.g. "{title: Amazing Grace}", "{sov}" or
+	// "{start_of_verse label="Verse 1"}"
+	reDirective = regexp.MustCompile(`^\s*\{\s*([A-Za-z][A-Za-z0-9_-]*)\s*(?::\s*(.*?)|\s+(.*?))?\s*\}\s*$`)
+	// Label attribute of an environment directive, e.g. `label="Verse 1"`
+	reLabelAttr = regexp.MustCompile(`^label\s*=\s*"([^"]*)"$`)
+)
+
+// Short forms of the ChordPro environment directives.
+var directiveAliases = map[string]string{
+	"sov": "start_of_verse", "eov": "end_of_verse",
+	"soc": "start_of_chorus", "eoc": "end_of_chorus",
+	"sob": "start_of_bridge", "eob": "end_of_bridge",
+	"sot": "start_of_tab", "eot": "end_of_tab",
+	"sog": "start_of_grid", "eog": "end_of_grid",
+	"t": "title", "st": "subtitle",
+}
+
+// ChordPro metadata directives. They describe the song rather than a section, so they never become content.
+var metaDirectives = map[string]bool{
+	"title": true, "sorttitle": true, "subtitle": true, "artist": true, "composer": true,
+	"lyricist": true, "arranger": true, "copyright": true, "album": true, "year": true,
+	"key": true, "time": true, "tempo": true, "duration": true, "capo": true, "meta": true,
+	"ccli": true,
+}
+
+// directive is a parsed ChordPro directive line.
+type directive struct {
+	name  string // canonical (long) name, lowercased
+	value string
+}
+
+// parseDirective parses a line holding a single ChordPro directive.
+func parseDirective(line string) (directive, bool) {
+	m := reDirective.FindStringSubmatch(line)
+	if m == nil {
+		return directive{}, false
+	}
+	name := strings.ToLower(m[1])
+	if long, ok := directiveAliases[name]; ok {
+		name = long
+	}
+	value := m[2]
+	if m[3] != "" {
+		value = m[3]
+	}
+	if l := reLabelAttr.FindStringSubmatch(value); l != nil {
+		value = l[1]
+	}
+	return directive{name: name, value: strings.TrimSpace(value)}, true
+}
+
+// environment returns the environment type of a start_of_*/end_of_* directive (e.g., "verse")
+// and whether it starts one.
+func (d directive) environment() (env string, start bool, ok bool) {
+	if env, ok := strings.CutPrefix(d.name, "start_of_"); ok && env != "" {
+		return env, true, true
+	}
+	if env, ok := strings.CutPrefix(d.name, "end_of_"); ok && env != "" {
+		return env, false, true
+	}
+	return "", false, false
+}
+
+// environmentHeader returns the section header for an environment with an optional label.
+// Labels that are section keywords in any language give the canonical header ("Verse 1" gives
+// "VERSE 1"), other labels are used as-is in upper case ("Solo" gives "SOLO"), and an unlabelled
+// environment is named after its type ("chorus" gives "CHORUS").
+func environmentHeader(env, label string) string {
+	if label != "" {
+		if base, num, ok := detectHeader(label, keywordCanonical, true); ok {
+			return formatHeader(base, num)
+		}
+		return strings.ToUpper(strings.Join(strings.Fields(label), " "))
+	}
+	return strings.ToUpper(strings.ReplaceAll(env, "_", " "))
+}
+
+// applyMeta stores the value of a metadata directive on the song.
+func (s *Song) applyMeta(d directive) {
+	name, value := d.name, d.value
+	if name == "meta" {
+		// "{meta: ccli 22025}"
+		name, value, _ = strings.Cut(value, " ")
+		name, value = strings.ToLower(name), strings.TrimSpace(value)
+	}
+	switch name {
+	case "title":
+		s.Title = value
+	case "artist":
+		if s.Author != "" {
+			s.Author += ", " + value
+		} else {
+			s.Author = value
+		}
+	case "key":
+		s.Key = value
+	case "ccli":
+		s.CCLI = value
+	}
+}
@@ -0,0 +1,286 @@
+package parser
+
+import (
+	"chordparser/internal/normalize"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Header detection rules. A line is a header candidate when, after removing decorations,
+// an optional number and an optional trailing annotation, the remaining tokens are exactly
+// one keyword (whole-token match, no trailing lyrics). Candidates are then scored:
+//
+//	+2 whole keyword match (always present for a candidate)
+//	+1 decorated: [brackets], (parentheses), <b>bold</b> or a trailing colon
+//	+1 explicit number ("Verse 2", "2e couplet")
+//	+1 capitalized keyword ("Chorus", "CHORUS")
+//	-1 ambiguous keyword that is also an everyday lyric word ("tag", "slot")
+//
+// and accepted when the score reaches headerThreshold.
+const (
+	// maxHeaderRunes is the longest trimmed line that can still be a header.
+	maxHeaderRunes = 40
+	// maxKeywordTokens is the most tokens a single keyword spans (e.g., "pre chorus").
+	maxKeywordTokens = 3
+	// headerThreshold is the minimum score for a candidate to be accepted.
+	headerThreshold = 2
+)
+
+// Keywords that double as everyday lyric words and need extra evidence to count as a header.
+var ambiguousKeywords = map[string]bool{
+	"tag":  true,
+	"slot": true,
+}
+
+var (
+	// Leading ordinals as used in numbered headers, e.g. "1. Strophe" (DE),
+	// "1er couplet"/"2e couplet" (FR), "1º verso"/"1ª estrofe" (ES/PT) and "2de couplet" (NL).
+	reLeadingOrdinal = regexp.MustCompile(`(?i)^(\d+)(?:\.|\.?[ºª°]|e|er|re|ère|ere|ème|eme|de|ste)$`)
+	// Trailing repeat annotations on headers, e.g. "Chorus x2", "Chorus 2x"
+	reRepeatAnnotation = regexp.MustCompile(`^(?:\d+[x×]|[x×]\d+)$`)
+	// Digits glued to a keyword, e.g. "Verse1"
+	reGluedNumber = regexp.MustCompile(`^(\D+?)(\d+)$`)
+	// Bold tags anywhere on the line, e.g. "<b>Verse 2</b>:"
+	reBoldTag = regexp.MustCompile(`(?i)</?b>`)
+	// Abbreviated header filling the whole line, e.g. "V1", "PC", "Br", "C3"
+	reAbbreviation = regexp.MustCompile(`^([A-Za-z]{1,4})(\d*)$`)
+	// Inline label followed by content, split at the first colon, en/em dash or spaced hyphen,
+	// e.g. "Verse 1: Amazing grace", "Chorus – Hallelujah", "Bridge - Oh"
+	reInlineLabel = regexp.MustCompile(`^\s*(.+?)\s*(?::|[–—]|\s-\s)\s*(\S.*?)\s*$`)
+)
+
+// Chord extensions that make a note-letter abbreviation with a number read as a chord ("B7", "C6",
+// "C2" for Csus2).
+var chordExtensions = map[int]bool{2: true, 4: true, 5: true, 6: true, 7: true, 9: true, 11: true, 13: true}
+
+// Chord extensions that are never section numbers in practice, so "B7" or "E9" stays a chord even
+// in a chart of abbreviated headers.
+var chordOnlyExtensions = map[int]bool{7: true, 9: true, 11: true, 13: true}
+
+// headerMatch is a header candidate with its canonical base, explicit number (0 if none) and score.
+type headerMatch struct {
+	base  string
+	num   int
+	score int
+}
+
+// detectHeader attempts to parse the given line as a section header.
+// It returns the canonical base header, an explicit number if present (0 if not),
+// and whether the line is a recognized header. Only the given keywords are considered.
+// A hinted line (e.g., bold-only in the HTML source) scores as decorated.
+func detectHeader(line string, keywords map[string]string, hinted bool) (string, int, bool) {
+	m, ok := scoreHeader(line, keywords, hinted)
+	if !ok || m.score < headerThreshold {
+		return "", 0, false
+	}
+	return m.base, m.num, true
+}
+
+// detectInlineHeader attempts to parse the given line as a section label followed by
+// content on the same line (e.g., "Verse 1: Amazing grace"). It returns the canonical base
+// header, an explicit number if present (0 if not), the trailing content and whether the
+// line starts with a recognized label. The separator counts as a decoration when scoring.
+func detectInlineHeader(line string, keywords map[string]string) (string, int, string, bool) {
+	if sm := reInlineLabel.FindStringSubmatch(line); sm != nil {
+		if m, ok := scoreHeader(sm[1], keywords, false); ok && m.score+1 >= headerThreshold {
+			return m.base, m.num, sm[2], true
+		}
+	}
+	// The dashes fold turns "Chorus—Hallelujah" into "Chorus-Hallelujah": an unspaced hyphen
+	// also separates a label when the content after it starts like a new line (a capital or a
+	// chord). Every hyphen is tried, so a hyphenated label ("Pre-Chorus-Lift") is found too.
+	s := strings.TrimSpace(line)
+	for i := 0; i < len(s); i++ {
+		if s[i] != '-' || i == 0 || s[i-1] == ' ' {
+			continue
+		}
+		rest := strings.TrimSpace(s[i+1:])
+		if r, _ := utf8.DecodeRuneInString(rest); rest == "" || (r != '[' && !unicode.IsUpper(r)) {
+			continue
+		}
+		if m, ok := scoreHeader(s[:i], keywords, false); ok && m.score+1 >= headerThreshold {
+			return m.base, m.num, rest, true
+		}
+	}
+	return "", 0, "", false
+}
+
+// detectAbbreviation attempts to parse the given line as an abbreviated header (e.g., "V1", "PC").
+// Matching is strict: the trimmed line must be exactly the abbreviation with an optional glued
+// number and colon, so chord lines ("C G Am F") and bracketed chords ("[C]") never match.
+// Abbreviations that are also note letters (C, B, E) could be a chord line of their own, so alone
+// or with a chord extension ("C2", "B7") they need a colon ("C:", "C2:"), unless bare is set: then
+// "C" or "C2" match too, but "B7", "E9", "C11" and "C13" still need a colon (see ParseTree).
+// It returns the canonical base header, an explicit number if present (0 if not), and whether it matched.
+func detectAbbreviation(line string, bare bool) (string, int, bool) {
+	s := strings.TrimSpace(line)
+	colon := strings.HasSuffix(s, ":")
+	sm := reAbbreviation.FindStringSubmatch(strings.TrimSpace(strings.TrimSuffix(s, ":")))
+	if sm == nil {
+		return "", 0, false
+	}
+	canon, ok := abbreviations[strings.ToLower(sm[1])]
+	if !ok {
+		return "", 0, false
+	}
+	note := len(sm[1]) == 1 && strings.ContainsAny(strings.ToUpper(sm[1]), "ABCDEFG")
+	num := 0
+	if sm[2] != "" {
+		if !normalize.IsDigits(sm[2]) {
+			return "", 0, false
+		}
+		num, _ = strconv.Atoi(sm[2])
+	}
+	// A lone "C" or a "C2" or "B7" is a chord rather than a Chorus, second Chorus or seventh Bridge
+	if note && !colon && (sm[2] == "" || chordExtensions[num]) && (!bare || chordOnlyExtensions[num]) {
+		return "", 0, false
+	}
+	return canon, num, true
+}
+
+// scoreHeader applies the header rules to a line. It reports false if the line
+// cannot be a header at all; otherwise the returned match carries its score.
+func scoreHeader(line string, keywords map[string]string, hinted bool) (headerMatch, bool) {
+	var m headerMatch
+
+	s := strings.TrimSpace(line)
+	if s == "" || utf8.RuneCountInString(s) > maxHeaderRunes {
+		return m, false
+	}
+
+	s, decorated := stripHeaderDecorations(s)
+	decorated = decorated || hinted
+	fields := dropAnnotation(strings.Fields(s))
+	if len(fields) == 0 {
+		return m, false
+	}
+
+	// Detect trailing number (e.g., "Chorus 2") or leading ordinal (e.g., "1. Strophe")
+	hasNumber := false
+	if len(fields) > 1 && normalize.IsDigits(fields[len(fields)-1]) {
+		m.num, _ = strconv.Atoi(fields[len(fields)-1])
+		fields = fields[:len(fields)-1]
+		hasNumber = true
+	} else if n, ok := leadingOrdinal(fields[0]); ok && len(fields) > 1 {
+		m.num = n
+		fields = fields[1:]
+		hasNumber = true
+	}
+
+	// Allow decorations on the keyword alone (e.g., "[Verse] 2")
+	for i := range fields {
+		fields[i] = normalize.StripDecorations(fields[i])
+	}
+
+	key, canon, n := matchKeyword(fields, keywords)
+	if n != len(fields) && !hasNumber && len(fields) == 1 {
+		// Digits glued to the keyword (e.g., "Verse1")
+		if g := reGluedNumber.FindStringSubmatch(fields[0]); g != nil && normalize.IsDigits(g[2]) {
+			if c, ok := keywords[normalize.Key(g[1])]; ok {
+				m.num, _ = strconv.Atoi(g[2])
+				key, canon, n = normalize.Key(g[1]), c, 1
+				hasNumber = true
+			}
+		}
+	}
+	if n == 0 || n != len(fields) {
+		return m, false
+	}
+
+	m.base = canon
+	m.score = 2
+	if decorated {
+		m.score++
+	}
+	if hasNumber {
+		m.score++
+	}
+	if r, _ := utf8.DecodeRuneInString(fields[0]); unicode.IsUpper(r) {
+		m.score++
+	}
+	if ambiguousKeywords[key] {
+		m.score--
+	}
+	return m, true
+}
+
+// matchKeyword finds the longest run of leading tokens that forms a keyword.
+// It returns the normalized key, its canonical header and the number of tokens used (0 if none).
+// Trying the longest run first keeps "pre chorus" from matching as "chorus" and makes the result deterministic.
+func matchKeyword(tokens []string, keywords map[string]string) (string, string, int) {
+	for n := min(len(tokens), maxKeywordTokens); n > 0; n-- {
+		key := normalize.Key(strings.Join(tokens[:n], " "))
+		if canon, ok := keywords[key]; ok {
+			return key, canon, n
+		}
+	}
+	return "", "", 0
+}
+
+// stripHeaderDecorations removes bold tags, trailing colons/periods and wrapping brackets
+// or parentheses, in any combination. It reports whether anything was removed.
+func stripHeaderDecorations(s string) (string, bool) {
+	decorated := false
+	if stripped := reBoldTag.ReplaceAllString(s, ""); stripped != s {
+		s = strings.TrimSpace(stripped)
+		decorated = true
+	}
+	for s != "" {
+		switch {
+		case strings.HasSuffix(s, ":"):
+			s = strings.TrimSpace(strings.TrimSuffix(s, ":"))
+		case strings.HasSuffix(s, ".") && !reLeadingOrdinal.MatchString(s):
+			s = strings.TrimSpace(strings.TrimSuffix(s, "."))
+		case wraps(s, '[', ']'), wraps(s, '(', ')'):
+			s = strings.TrimSpace(s[1 : len(s)-1])
+		default:
+			return s, decorated
+		}
+		decorated = true
+	}
+	return s, decorated
+}
+
+// wraps reports whether s starts with open and ends with the matching close,
+// with no other close in between (so "(a) (b)" is not wrapped).
+func wraps(s string, open, close byte) bool {
+	return len(s) >= 2 && s[0] == open && s[len(s)-1] == close && strings.IndexByte(s, close) == len(s)-1
+}
+
+// dropAnnotation removes one trailing annotation that may follow a header,
+// either a parenthetical such as "(x2)"/"(soft)" or a repeat marker such as "x2".
+func dropAnnotation(fields []string) []string {
+	if len(fields) < 2 {
+		return fields
+	}
+	last := fields[len(fields)-1]
+	if reRepeatAnnotation.MatchString(last) {
+		return fields[:len(fields)-1]
+	}
+	if !strings.HasSuffix(last, ")") {
+		return fields
+	}
+	for i := len(fields) - 1; i > 0; i-- {
+		if strings.HasPrefix(fields[i], "(") {
+			return fields[:i]
+		}
+	}
+	return fields
+}
+
+// leadingOrdinal parses an ordinal token such as "1.", "2e", "1er" or "3º".
+// It returns the number and whether the token is an ordinal.
+func leadingOrdinal(tok string) (int, bool) {
+	m := reLeadingOrdinal.FindStringSubmatch(tok)
+	if m == nil || !normalize.IsDigits(m[1]) {
+		return 0, false
+	}
+	n, err := strconv.Atoi(m[1])
+	if err != nil {
+		return 0, false
+	}
+	return n, true
+}
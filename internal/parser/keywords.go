@@ -0,0 +1,169 @@
+package parser
+
+import (
+	"slices"
+	"sort"
+)
+
+// Canonical, case-insensitive keywords per language (ISO 639-1 code) with variant normalization.
+// Variants normalize by removing spaces/hyphens/underscores and lowercasing.
+// Every language also accepts the English loanwords (see withLoanwords).
+var keywordsByLanguage = withLoanwords(map[string]map[string]string{
+	"en": {
+		"verse":        "VERSE",
+		"chorus":       "CHORUS",
+		"refrain":      "REFRAIN",
+		"prechorus":    "PRE-CHORUS",
+		"pre-chorus":   "PRE-CHORUS",
+		"postchorus":   "POST-CHORUS",
+		"post-chorus":  "POST-CHORUS",
+		"bridge":       "BRIDGE",
+		"intro":        "INTRO",
+		"outro":        "OUTRO",
+		"ending":       "ENDING",
+		"instrumental": "INSTRUMENTAL",
+		"interlude":    "INTERLUDE",
+		"tag":          "TAG",
+		"turnaround":   "TURNAROUND",
+		"vamp":         "VAMP",
+		"breakdown":    "BREAKDOWN",
+	},
+
+	// Dutch (mapped to English)
+	"nl": {
+		"refrein":       "CHORUS",
+		"couplet":       "VERSE",
+		"brug":          "BRIDGE",
+		"bridge":        "BRIDGE",
+		"intro":         "INTRO",
+		"outro":         "OUTRO",
+		"uitro":         "OUTRO",
+		"intermezzo":    "INTERLUDE",
+		"instrumentaal": "INSTRUMENTAL",
+		"slot":          "ENDING",
+	},
+
+	// German (mapped to English)
+	"de": {
+		"strophe":       "VERSE",
+		"refrain":       "REFRAIN",
+		"bridge":        "BRIDGE",
+		"intro":         "INTRO",
+		"outro":         "OUTRO",
+		"zwischenspiel": "INTERLUDE",
+		"vorspiel":      "INTRO",
+		"nachspiel":     "OUTRO",
+		"schluss":       "ENDING",
+		"schluß":        "ENDING",
+	},
+
+	// French (mapped to English)
+	"fr": {
+		"couplet":    "VERSE",
+		"refrain":    "REFRAIN",
+		"pont":       "BRIDGE",
+		"prérefrain": "PRE-CHORUS",
+		"prerefrain": "PRE-CHORUS",
+		"intro":      "INTRO",
+		"outro":      "OUTRO",
+		"interlude":  "INTERLUDE",
+	},
+
+	// Spanish (mapped to English)
+	"es": {
+		"verso":      "VERSE",
+		"estrofa":    "VERSE",
+		"coro":       "CHORUS",
+		"estribillo": "CHORUS",
+		"precoro":    "PRE-CHORUS",
+		"puente":     "BRIDGE",
+		"intro":      "INTRO",
+		"interludio": "INTERLUDE",
+	},
+
+	// Portuguese (mapped to English)
+	"pt": {
+		"verso":     "VERSE",
+		"estrofe":   "VERSE",
+		"refrão":    "CHORUS",
+		"refrao":    "CHORUS",
+		"prérefrão": "PRE-CHORUS",
+		"prerefrao": "PRE-CHORUS",
+		"ponte":     "BRIDGE",
+		"intro":     "INTRO",
+	},
+})
+
+// English section names that charts in every language commonly use, mapped like the "en" table.
+var loanwords = []string{
+	"verse", "chorus", "prechorus", "pre-chorus", "postchorus", "post-chorus", "bridge",
+	"intro", "outro", "ending", "instrumental", "interlude", "tag", "turnaround", "vamp",
+	"breakdown",
+}
+
+// Loanwords left out of a language because they are ordinary words in it.
+var loanwordExclusions = map[string][]string{
+	"de": {"tag"}, // "Tag" is the German word for day
+}
+
+// withLoanwords adds the English loanwords to every language's table. A language's own keyword
+// wins over a loanword of the same spelling.
+func withLoanwords(tables map[string]map[string]string) map[string]map[string]string {
+	en := tables["en"]
+	for lang, kw := range tables {
+		for _, w := range loanwords {
+			if _, ok := kw[w]; !ok && !slices.Contains(loanwordExclusions[lang], w) {
+				kw[w] = en[w]
+			}
+		}
+	}
+	return tables
+}
+
+// Abbreviated headers as used in PCO charts and sequences (e.g., "V1", "C", "PC", "Br").
+// Keys are lowercase; an optional number may be glued on ("V1", "C2").
+var abbreviations = map[string]string{
+	"v":    "VERSE",
+	"c":    "CHORUS",
+	"pc":   "PRE-CHORUS",
+	"b":    "BRIDGE",
+	"br":   "BRIDGE",
+	"t":    "TAG",
+	"int":  "INTRO",
+	"inst": "INSTRUMENTAL",
+	"e":    "ENDING",
+}
+
+// keywordCanonical holds the keywords of all languages combined.
+// It is used when the chart language is unknown.
+var keywordCanonical = mergeKeywords()
+
+// mergeKeywords combines all language tables into one.
+func mergeKeywords() map[string]string {
+	all := make(map[string]string)
+	for _, kw := range keywordsByLanguage {
+		for k, canon := range kw {
+			all[k] = canon
+		}
+	}
+	return all
+}
+
+// keywordsFor returns the keyword table for a language, or all keywords
+// if the language is empty or not supported.
+func keywordsFor(lang string) map[string]string {
+	if kw, ok := keywordsByLanguage[lang]; ok {
+		return kw
+	}
+	return keywordCanonical
+}
+
+// Languages returns the language codes that have a keyword table, sorted.
+func Languages() []string {
+	langs := make([]string, 0, len(keywordsByLanguage))
+	for lang := range keywordsByLanguage {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+	return langs
+}
@@ -6,164 +6,135 @@ import (
 	"strings"
 )
 
-// Canonical, case-insensitive keywords (EN + NL) with variant normalization.
-// Variants normalize by removing spaces/hyphens/underscores and lowercasing.
-var keywordCanonical = map[string]string{
-	// English
-	"verse":        "VERSE",
-	"chorus":       "CHORUS",
-	"refrain":      "REFRAIN",
-	"prechorus":    "PRE-CHORUS",
-	"pre-chorus":   "PRE-CHORUS",
-	"postchorus":   "POST-CHORUS",
-	"post-chorus":  "POST-CHORUS",
-	"bridge":       "BRIDGE",
-	"intro":        "INTRO",
-	"outro":        "OUTRO",
-	"ending":       "ENDING",
-	"instrumental": "INSTRUMENTAL",
-	"interlude":    "INTERLUDE",
-	"tag":          "TAG",
-	"turnaround":   "TURNAROUND",
-	"vamp":         "VAMP",
-	"breakdown":    "BREAKDOWN",
-
-	// Dutch (mapped to English)
-	"refrein":       "CHORUS",
-	"couplet":       "VERSE",
-	"brug":          "BRIDGE",
-	"uitro":         "OUTRO",
-	"intermezzo":    "INTERLUDE",
-	"instrumentaal": "INSTRUMENTAL",
-	"slot":          "ENDING",
-}
-
 // Section represents a parsed section.
 type Section struct {
 	Header  string   `json:"header"`
 	Content []string `json:"content"`
+	// Pos locates the header line in the parsed text (the first content line when the section
+	// has no header). It is zero for sections built outside the parser, such as by importers.
+	Pos Span `json:"pos,omitzero"`
+	// Lines locates the source line of each Content line (Lines[i] for Content[i]), or is nil
+	// when positions are unknown.
+	Lines []Span `json:"lines,omitempty"`
 }
 
-func Parse(text string) []Section {
-	text = normalize.Newlines(text)
-	lines := strings.Split(text, "\n")
-
-	var sections []Section
-	header := "GENERAL"
-	content := []string{}
-	foundAnyHeader := false
-
-	for _, line := range lines {
-		if base, num, ok := detectHeader(line); ok {
-			// flush previous content if any
-			if len(content) > 0 {
-				sections = append(sections, Section{Header: header, Content: content})
-			}
-			foundAnyHeader = true
-			if num > 0 {
-				header = base + " " + strconv.Itoa(num)
-			} else {
-				header = base
-			}
-			content = nil
-			continue
-		}
-		content = append(content, line)
-	}
-
-	// flush last accumulated content
-	if len(content) > 0 {
-		sections = append(sections, Section{Header: header, Content: content})
-	}
+// Span locates a line in the parsed text: its 1-based line number and the byte range
+// [Start, End) of the line, excluding the line terminator. Offsets refer to the text as given,
+// before newline normalization and Unicode folding.
+type Span struct {
+	Line  int `json:"line"`
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
 
-	// If at least one header was found, ensure uniqueness across duplicates.
-	// If none were found, keep the single "General" section unnumbered.
-	if foundAnyHeader {
-		sections = makeUniqueHeaders(sections)
-	}
+// Song is a parsed song with its metadata. Sequence optionally lists section headers
+// in the order they are sung (e.g., a PCO arrangement sequence or an OpenLyrics verse order).
+type Song struct {
+	Title    string    `json:"title,omitempty"`
+	Author   string    `json:"author,omitempty"`
+	Key      string    `json:"key,omitempty"`
+	CCLI     string    `json:"ccli,omitempty"`
+	Sequence []string  `json:"sequence,omitempty"`
+	Sections []Section `json:"sections"`
+}
 
-	return sections
+// Options controls optional parsing behaviour.
+type Options struct {
+	// Language restricts header keywords to a single language ("en", "nl", "de", "fr", "es", "pt").
+	// Empty means keywords of all languages are accepted.
+	Language string
+	// Abbreviations also accepts abbreviated headers such as "V1", "C", "PC", "Br" or "Int".
+	// The whole line must be the abbreviation. Note letters (C, B, E) alone or with a chord
+	// extension are only headers with a colon ("C:"), or when every other header of the chart is
+	// an abbreviation, so chord lines such as "C" or "C2" stay content in other charts.
+	Abbreviations bool
+	// Folds selects the Unicode and typography folds applied before parsing.
+	// Nil means normalize.DefaultFolds.
+	Folds *normalize.Folds
+	// HeaderHints marks lines (by index) that are likely headers, such as bold-only lines
+	// reported by normalize.HTMLToText. A hinted line scores as decorated.
+	HeaderHints []bool
 }
 
 // Parse splits a raw chord/lyrics text into ordered sections.
-// - Detects headers using EN/NL keywords (case-insensitive, supports variants).
+// - Detects headers using the keywords of every language in keywordsByLanguage
+// (case-insensitive, supports variants); see ParseWith to restrict them to one language.
 // - Understands numbered headers (e.g., "Verse 1").
 // - If no headers exist, returns one "General" section.
 // - Ensures duplicate headers are made unique by numbering at the end.
-func normalizeNewlines(s string) string {
-	// Normalize Windows CRLF and old Mac CR to LF
-	s = strings.ReplaceAll(s, "\r\n", "\n")
-	s = strings.ReplaceAll(s, "\r", "\n")
-	return s
+func Parse(text string) []Section {
+	return ParseWith(text, Options{})
 }
 
-// detectHeader attempts to parse the given line as a section header.
-// It returns the canonical base header, an explicit number if present (0 if not),
-// and whether the line is a recognized header.
-func detectHeader(line string) (string, int, bool) {
-	// Strip simple bold tags to support headers like <b>Verse 2</b>:
-	line = strings.ReplaceAll(line, "<b>", "")
-	line = strings.ReplaceAll(line, "</b>", "")
-	trimmed := strings.TrimSpace(line)
-	if trimmed == "" {
-		return "", 0, false
-	}
-
-	// Drop trailing colons (common in headers like "Verse 1:")
-	for strings.HasSuffix(trimmed, ":") {
-		trimmed = strings.TrimSuffix(trimmed, ":")
-		trimmed = strings.TrimSpace(trimmed)
-	}
-
-	if trimmed == "" {
-		return "", 0, false
-	}
+// ParseWith is like Parse but applies the given options.
+func ParseWith(text string, opts Options) []Section {
+	return ParseSongWith(text, opts).Sections
+}
 
-	fields := strings.Fields(trimmed)
+// ParseSong is like Parse but also returns the song metadata from ChordPro directives
+// ({title}, {artist}, {key} and {meta: ccli ...}).
+func ParseSong(text string) Song {
+	return ParseSongWith(text, Options{})
+}
 
-	// Detect trailing number (e.g., "Chorus 2")
-	var num int
-	var err error
-	last := fields[len(fields)-1]
+// ParseSongWith is like ParseSong but applies the given options.
+func ParseSongWith(text string, opts Options) Song {
+	return ParseTree(text, opts).Song()
+}
 
-	hasNumber := false
-	if normalize.IsDigits(last) {
-		hasNumber = true
-		num, err = strconv.Atoi(last)
-		if err != nil {
-			// Shouldn't happen due to isDigits, but guard anyway.
-			hasNumber = false
-			num = 0
+// HeaderLine reports whether a single chart line starts a section, using the same rules as
+// ParseWith (header hints aside), including ChordPro {start_of_*} environments. Without the rest
+// of the chart, a bare note letter such as "C" is never a header. It returns the section header
+// before numbering duplicates (e.g., "VERSE 1") and any content that shares the line with an
+// inline label.
+func HeaderLine(line string, opts Options) (header, rest string, ok bool) {
+	line = normalize.Fold(line, normalize.FoldsOrDefault(opts.Folds))
+	if d, ok := parseDirective(line); ok {
+		if env, start, ok := d.environment(); ok && start {
+			return environmentHeader(env, d.value), "", true
 		}
+		return "", "", false
 	}
+	return detectLine(line, keywordsFor(opts.Language), opts.Abbreviations, false)
+}
 
-	// Base name to match against keywords (may contain decorations)
-	name := trimmed
-	if hasNumber {
-		name = strings.TrimSpace(strings.TrimSuffix(trimmed, last))
+// detectLine applies the header rules to a line outside ChordPro environments: a full-line
+// header, an abbreviation (when enabled) or an inline label followed by content.
+func detectLine(line string, keywords map[string]string, abbreviations, hinted bool) (string, string, bool) {
+	base, num, ok := detectHeader(line, keywords, hinted)
+	rest := ""
+	if !ok && abbreviations {
+		base, num, ok = detectAbbreviation(line, false)
 	}
-
-	// Try direct match on full name (original and decoration-stripped)
-	if canon, ok := keywordCanonical[normalize.Key(name)]; ok {
-		return canon, num, true
+	if !ok {
+		// Label with the first content on the same line (e.g., "Verse 1: Amazing grace")
+		base, num, rest, ok = detectInlineHeader(line, keywords)
 	}
-
-	// Finally, inspect the first "word" only, allowing decorations and "includes" matching.
-	first := fields[0]
-	first = normalize.StripDecorations(first)
-	firstNorm := normalize.Key(first)
-
-	if canon, ok := keywordCanonical[firstNorm]; ok {
-		return canon, num, true
+	if !ok {
+		return "", "", false
 	}
-	// If the first token contains a keyword (e.g., "[Pre-Chorus]"), accept it.
-	for k, canon := range keywordCanonical {
-		if strings.Contains(firstNorm, k) {
-			return canon, num, true
-		}
+	return formatHeader(base, num), rest, true
+}
+
+// formatHeader joins a canonical base and an explicit number (0 if none), e.g. "VERSE 2".
+func formatHeader(base string, num int) string {
+	if num > 0 {
+		return base + " " + strconv.Itoa(num)
 	}
+	return base
+}
+
+// normalizeNewlines converts Windows (CRLF) and old Mac (CR) line ends to LF.
+func normalizeNewlines(s string) string {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	s = strings.ReplaceAll(s, "\r", "\n")
+	return s
+}
 
-	return "", 0, false
+// UniqueHeaders makes duplicate headers unique the same way Parse does,
+// for sections built outside the parser (e.g., by importers).
+func UniqueHeaders(sections []Section) []Section {
+	return makeUniqueHeaders(sections)
 }
 
 // makeUniqueHeaders ensures headers are unique by adding/incrementing numbers
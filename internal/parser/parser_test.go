@@ -1,7 +1,12 @@
 package parser
 
 import (
+	"chordparser/internal/normalize"
+	"os"
 	"reflect"
+	"slices"
+	"strconv"
+	"strings"
 	"testing"
 )
 
@@ -184,3 +189,586 @@ func TestParse_DuplicateExplicitNumbersAvoidCollision(t *testing.T) {
 		t.Fatalf("headers mismatch:\nwant: %#v\n got: %#v", want, gotHeaders)
 	}
 }
+
+func TestParse_GermanKeywords(t *testing.T) {
+	t.Parallel()
+
+	txt := "1. Strophe\nLine\nRefrain\nLine\nStrophe 2:\nLine\nZwischenspiel\nLine\nSchluss\nLine"
+	got := Parse(txt)
+	want := []string{"VERSE 1", "REFRAIN", "VERSE 2", "INTERLUDE", "ENDING"}
+	var gotHeaders []string
+	for _, s := range got {
+		gotHeaders = append(gotHeaders, s.Header)
+	}
+	if !reflect.DeepEqual(gotHeaders, want) {
+		t.Fatalf("headers mismatch:\nwant: %#v\n got: %#v", want, gotHeaders)
+	}
+}
+
+func TestParse_FrenchKeywords(t *testing.T) {
+	t.Parallel()
+
+	txt := "1er couplet\nLine\nRefrain\nLine\n2e couplet\nLine\nPont\nLine\nCouplet 3\nLine"
+	got := Parse(txt)
+	want := []string{"VERSE 1", "REFRAIN", "VERSE 2", "BRIDGE", "VERSE 3"}
+	var gotHeaders []string
+	for _, s := range got {
+		gotHeaders = append(gotHeaders, s.Header)
+	}
+	if !reflect.DeepEqual(gotHeaders, want) {
+		t.Fatalf("headers mismatch:\nwant: %#v\n got: %#v", want, gotHeaders)
+	}
+}
+
+func TestParse_SpanishKeywords(t *testing.T) {
+	t.Parallel()
+
+	txt := "Verso 1\nLine\nPrecoro\nLine\nCoro\nLine\n2º verso\nLine\nPuente\nLine"
+	got := Parse(txt)
+	want := []string{"VERSE 1", "PRE-CHORUS", "CHORUS", "VERSE 2", "BRIDGE"}
+	var gotHeaders []string
+	for _, s := range got {
+		gotHeaders = append(gotHeaders, s.Header)
+	}
+	if !reflect.DeepEqual(gotHeaders, want) {
+		t.Fatalf("headers mismatch:\nwant: %#v\n got: %#v", want, gotHeaders)
+	}
+}
+
+func TestParse_PortugueseKeywords(t *testing.T) {
+	t.Parallel()
+
+	txt := "Verso 1:\nLine\nRefrão\nLine\n2ª Verso\nLine\nPonte\nLine\nRefrao\nLine"
+	got := Parse(txt)
+	want := []string{"VERSE 1", "CHORUS 1", "VERSE 2", "BRIDGE", "CHORUS 2"}
+	var gotHeaders []string
+	for _, s := range got {
+		gotHeaders = append(gotHeaders, s.Header)
+	}
+	if !reflect.DeepEqual(gotHeaders, want) {
+		t.Fatalf("headers mismatch:\nwant: %#v\n got: %#v", want, gotHeaders)
+	}
+}
+
+func TestLeadingOrdinal(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]int{"1.": 1, "2e": 2, "1er": 1, "1re": 1, "2ème": 2, "3º": 3, "1ª": 1, "2de": 2, "8ste": 8}
+	for in, want := range cases {
+		got, ok := leadingOrdinal(in)
+		if !ok || got != want {
+			t.Fatalf("leadingOrdinal(%q) = %d, %v; want %d, true", in, got, ok, want)
+		}
+	}
+	for _, in := range []string{"1", "0.", "e1", "Verse", "1x"} {
+		if _, ok := leadingOrdinal(in); ok {
+			t.Fatalf("leadingOrdinal(%q) unexpectedly matched", in)
+		}
+	}
+}
+
+func TestParseWith_LanguageRestrictsKeywords(t *testing.T) {
+	t.Parallel()
+
+	txt := "Strophe 1\nGuten Tag\nTag\nUnd noch ein Tag"
+	got := ParseWith(txt, Options{Language: "de"})
+	if len(got) != 1 {
+		t.Fatalf("expected 1 section, got %d", len(got))
+	}
+	if got[0].Header != "VERSE 1" {
+		t.Fatalf("expected header 'VERSE 1', got %q", got[0].Header)
+	}
+	want := []string{"Guten Tag", "Tag", "Und noch ein Tag"}
+	if !reflect.DeepEqual(got[0].Content, want) {
+		t.Fatalf("content mismatch: want %#v, got %#v", want, got[0].Content)
+	}
+}
+
+func TestParseWith_LanguagesAcceptEnglishLoanwords(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		txt  string
+		want []string
+	}{
+		"nl": {"Couplet 1\nIk zing\nPre-Chorus\nEn dan\nChorus\nHalleluja\nTag\nAmen", []string{"VERSE 1", "PRE-CHORUS", "CHORUS", "TAG"}},
+		"de": {"Strophe 1\nIch singe\nPre-Chorus\nUnd dann\nChorus\nHalleluja", []string{"VERSE 1", "PRE-CHORUS", "CHORUS"}},
+		"fr": {"Couplet 1\nJe chante\nChorus\nAlléluia\nBridge\nGloire", []string{"VERSE 1", "CHORUS", "BRIDGE"}},
+		"es": {"Verso 1\nYo canto\nChorus\nAleluya\nTag\nAmén", []string{"VERSE 1", "CHORUS", "TAG"}},
+		"pt": {"Verso 1\nEu canto\nPre-Chorus\nE então\nChorus\nAleluia", []string{"VERSE 1", "PRE-CHORUS", "CHORUS"}},
+	}
+	for lang, c := range cases {
+		var got []string
+		for _, s := range ParseWith(c.txt, Options{Language: lang}) {
+			got = append(got, s.Header)
+		}
+		if !reflect.DeepEqual(got, c.want) {
+			t.Fatalf("%s: headers = %q, want %q", lang, got, c.want)
+		}
+	}
+}
+
+func TestKeywords_EveryLanguageHasTheLoanwords(t *testing.T) {
+	t.Parallel()
+
+	for _, lang := range Languages() {
+		kw := keywordsFor(lang)
+		for _, w := range loanwords {
+			got, ok := kw[w]
+			if slices.Contains(loanwordExclusions[lang], w) {
+				if ok {
+					t.Fatalf("de: %q should not be a keyword", w)
+				}
+				continue
+			}
+			if want := keywordsFor("en")[w]; got != want || want == "" {
+				t.Fatalf("%s: %q = %q, want %q", lang, w, got, want)
+			}
+		}
+	}
+}
+
+func TestParseWith_EmptyLanguageUsesAllKeywords(t *testing.T) {
+	t.Parallel()
+
+	txt := "Strophe\nA\nTag\nB"
+	got := ParseWith(txt, Options{})
+	want := []string{"VERSE", "TAG"}
+	var gotHeaders []string
+	for _, s := range got {
+		gotHeaders = append(gotHeaders, s.Header)
+	}
+	if !reflect.DeepEqual(gotHeaders, want) {
+		t.Fatalf("headers mismatch:\nwant: %#v\n got: %#v", want, gotHeaders)
+	}
+}
+
+func TestKeywordsByLanguage_SharedKeywordsAgree(t *testing.T) {
+	t.Parallel()
+
+	for lang, kw := range keywordsByLanguage {
+		for k, canon := range kw {
+			if keywordCanonical[k] != canon {
+				t.Fatalf("keyword %q (%s) maps to %q, but merged table has %q", k, lang, canon, keywordCanonical[k])
+			}
+		}
+	}
+}
+
+func TestDetectHeader_RegressionCorpus(t *testing.T) {
+	t.Parallel()
+
+	b, err := os.ReadFile("testdata/headers.txt")
+	if err != nil {
+		t.Fatalf("read corpus: %v", err)
+	}
+	for i, row := range strings.Split(string(b), "\n") {
+		if row == "" || strings.HasPrefix(row, "#") {
+			continue
+		}
+		want, line, ok := strings.Cut(row, "\t")
+		if !ok {
+			t.Fatalf("corpus line %d: missing tab", i+1)
+		}
+		got := "-"
+		if base, num, ok := detectHeader(line, keywordCanonical, false); ok {
+			got = base
+			if num > 0 {
+				got += " " + strconv.Itoa(num)
+			}
+		}
+		if got != want {
+			t.Errorf("corpus line %d: detectHeader(%q) = %q, want %q", i+1, line, got, want)
+		}
+	}
+}
+
+func TestDetectHeader_LongestMatchIsDeterministic(t *testing.T) {
+	t.Parallel()
+
+	for i := 0; i < 50; i++ {
+		if base, _, ok := detectHeader("Pre Chorus", keywordCanonical, false); !ok || base != "PRE-CHORUS" {
+			t.Fatalf("run %d: expected PRE-CHORUS, got %q (ok=%v)", i, base, ok)
+		}
+	}
+}
+
+func TestParse_InlineLabels(t *testing.T) {
+	t.Parallel()
+
+	txt := "Verse 1: Amazing grace how sweet\nThe sound\nChorus – Hallelujah\nPraise him\nBridge - Oh oh\n[Tag]: Amen"
+	got := withoutPositions(Parse(txt))
+	want := []Section{
+		{Header: "VERSE 1", Content: []string{"Amazing grace how sweet", "The sound"}},
+		{Header: "CHORUS", Content: []string{"Hallelujah", "Praise him"}},
+		{Header: "BRIDGE", Content: []string{"Oh oh"}},
+		{Header: "TAG", Content: []string{"Amen"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("sections mismatch:\nwant: %#v\n got: %#v", want, got)
+	}
+}
+
+func TestParse_InlineLabelRequiresKeyword(t *testing.T) {
+	t.Parallel()
+
+	txt := "Note: play softly\nTagline: nothing here\nPre-Chorus line"
+	got := Parse(txt)
+	if len(got) != 1 || got[0].Header != "GENERAL" {
+		t.Fatalf("expected one GENERAL section, got %#v", got)
+	}
+}
+
+func TestParse_InlineLabelAfterFoldedDash(t *testing.T) {
+	t.Parallel()
+
+	// The default dashes fold turns en/em dashes into a hyphen before header detection
+	cases := []struct {
+		txt, header string
+		content     []string
+	}{
+		{"Chorus\u2014Hallelujah\nAmen", "CHORUS", []string{"Hallelujah", "Amen"}},
+		{"Chorus\u2013Hallelujah", "CHORUS", []string{"Hallelujah"}},
+		{"Pre-Chorus\u2014[G]Lift him up", "PRE-CHORUS", []string{"[G]Lift him up"}},
+		{"Verse 2-Oh what love", "VERSE 2", []string{"Oh what love"}},
+		{"Tag-along with me", "GENERAL", []string{"Tag-along with me"}},
+	}
+	for _, c := range cases {
+		got := Parse(c.txt)
+		if len(got) != 1 || got[0].Header != c.header || !reflect.DeepEqual(got[0].Content, c.content) {
+			t.Fatalf("Parse(%q) = %#v, want %s %q", c.txt, got, c.header, c.content)
+		}
+	}
+}
+
+func TestDetectInlineHeader(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		line, base, rest string
+		num              int
+	}{
+		{"Verse 1: Amazing grace", "VERSE", "Amazing grace", 1},
+		{"Chorus — Hallelujah", "CHORUS", "Hallelujah", 0},
+		{"Pre-Chorus - [G]Lift him up", "PRE-CHORUS", "[G]Lift him up", 0},
+		{"2e couplet : Il est là", "VERSE", "Il est là", 2},
+		{"<b>Refrein</b>: Groot is uw trouw", "CHORUS", "Groot is uw trouw", 0},
+	}
+	for _, c := range cases {
+		base, num, rest, ok := detectInlineHeader(c.line, keywordCanonical)
+		if !ok || base != c.base || num != c.num || rest != c.rest {
+			t.Fatalf("detectInlineHeader(%q) = %q, %d, %q, %v; want %q, %d, %q, true", c.line, base, num, rest, ok, c.base, c.num, c.rest)
+		}
+	}
+}
+
+func TestParseWith_Abbreviations(t *testing.T) {
+	t.Parallel()
+
+	txt := "Int\nl1\nV1\nl2\nPC\nl3\nC:\nl4\nV2\nl5\nBr\nl6\nC2:\nl7\nT\nl8\nInst\nl9\nE:\nl10"
+	got := ParseWith(txt, Options{Abbreviations: true})
+	want := []string{"INTRO", "VERSE 1", "PRE-CHORUS", "CHORUS 1", "VERSE 2", "BRIDGE", "CHORUS 2", "TAG", "INSTRUMENTAL", "ENDING"}
+	var gotHeaders []string
+	for _, s := range got {
+		gotHeaders = append(gotHeaders, s.Header)
+	}
+	if !reflect.DeepEqual(gotHeaders, want) {
+		t.Fatalf("headers mismatch:\nwant: %#v\n got: %#v", want, gotHeaders)
+	}
+}
+
+func TestParseWith_AbbreviationsStrict(t *testing.T) {
+	t.Parallel()
+
+	txt := "V1\nC G Am F\n[C]\nC/G\nCm\nBr and more\nB7\nE9"
+	got := ParseWith(txt, Options{Abbreviations: true})
+	if len(got) != 1 || got[0].Header != "VERSE 1" {
+		t.Fatalf("expected one VERSE 1 section, got %#v", got)
+	}
+	want := []string{"C G Am F", "[C]", "C/G", "Cm", "Br and more", "B7", "E9"}
+	if !reflect.DeepEqual(got[0].Content, want) {
+		t.Fatalf("content mismatch: want %#v, got %#v", want, got[0].Content)
+	}
+}
+
+func TestParseWith_AbbreviationsNoteLetters(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		txt, header string
+	}{
+		// Alone, a note letter is a chord, whatever follows, unless it has a colon
+		{"C\nAmazing grace", ""},
+		{"B\n\n[G]How sweet the sound", ""},
+		{"E\nG D Em", ""},
+		{"C\n[G] [D]", ""},
+		{"B\nE\nC", ""},
+		{"E", ""},
+		{"C:\nAmazing grace", "CHORUS"},
+		{"E:\nG", "ENDING"},
+		// A number makes it a header, unless the number is a chord extension
+		{"C3\nG", "CHORUS 3"},
+		{"B1\nG", "BRIDGE 1"},
+		{"C2\nAmazing grace", ""},
+		{"E2\nAmazing grace", ""},
+		{"C4\nG  D  Em\nAmazing grace", ""},
+		{"C2", ""},
+		{"B7\nAmazing grace", ""},
+		{"C2:\nG", "CHORUS 2"},
+	}
+	for _, c := range cases {
+		got := ParseWith(c.txt, Options{Abbreviations: true})
+		header := got[0].Header
+		if header == "GENERAL" {
+			header = ""
+		}
+		if header != c.header {
+			t.Fatalf("%q: header %q, want %q", c.txt, header, c.header)
+		}
+	}
+	for _, line := range []string{"C", "B", "E", "G4", "A4", "E2", "C2", "B7"} {
+		if _, _, ok := HeaderLine(line, Options{Abbreviations: true}); ok {
+			t.Fatalf("chord %q read as a header", line)
+		}
+	}
+}
+
+func TestParseWith_AbbreviationsBareNoteLetters(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		txt  string
+		want []string
+	}{
+		// In a chart whose other headers are abbreviations, a bare note letter is a header too
+		{"V1\nLa\nC\nLo\n\nB\nLi\nE2\nLu", []string{"VERSE 1", "CHORUS", "BRIDGE", "ENDING 2"}},
+		{"C\nLa\nV1\nLo", []string{"CHORUS", "VERSE 1"}},
+		// ... but not right below a header, where it is the section's first chord line
+		{"V1\nC\nAmazing grace", []string{"VERSE 1"}},
+		{"V1\nLa\nB7\nLo", []string{"VERSE 1"}},
+		// A spelled header, or no other header at all, leaves it a chord
+		{"Verse 1\nLa\nC\nLo", []string{"VERSE 1"}},
+		{"V1\nLa\nChorus\nLi\nC\nLo", []string{"VERSE 1", "CHORUS"}},
+		{"C\nLa\nB\nLo", []string{"GENERAL"}},
+	}
+	for _, c := range cases {
+		var got []string
+		for _, s := range ParseWith(c.txt, Options{Abbreviations: true}) {
+			got = append(got, s.Header)
+		}
+		if !reflect.DeepEqual(got, c.want) {
+			t.Fatalf("%q: headers %q, want %q", c.txt, got, c.want)
+		}
+	}
+}
+
+func TestParseWith_AbbreviationsSingleChordLines(t *testing.T) {
+	t.Parallel()
+
+	// In a chord-over-lyrics chart a line can hold a single chord; it stays in its section
+	txt := "Verse 1\nC\nAmazing grace how sweet\nG\nthe sound"
+	got := ParseWith(txt, Options{Abbreviations: true})
+	want := []Section{{Header: "VERSE 1", Content: []string{"C", "Amazing grace how sweet", "G", "the sound"}}}
+	if len(got) != 1 || got[0].Header != want[0].Header || !reflect.DeepEqual(got[0].Content, want[0].Content) {
+		t.Fatalf("got %#v, want %#v", got, want)
+	}
+}
+
+func TestParse_AbbreviationsOffByDefault(t *testing.T) {
+	t.Parallel()
+
+	txt := "V1\nline\nC\nline"
+	got := Parse(txt)
+	if len(got) != 1 || got[0].Header != "GENERAL" {
+		t.Fatalf("expected one GENERAL section, got %#v", got)
+	}
+}
+
+func TestParse_FoldsTypography(t *testing.T) {
+	t.Parallel()
+
+	txt := "\ufeff［Chorus］\nC♯m\u00a0E\n"
+	got := Parse(txt)
+	if len(got) != 1 || got[0].Header != "CHORUS" {
+		t.Fatalf("expected one CHORUS section, got %#v", got)
+	}
+	if got[0].Content[0] != "C#m E" {
+		t.Fatalf("expected folded chord line %q, got %q", "C#m E", got[0].Content[0])
+	}
+}
+
+func TestParseWith_FoldsCanBeDisabled(t *testing.T) {
+	t.Parallel()
+
+	txt := "［Chorus］\nLine"
+	got := ParseWith(txt, Options{Folds: &normalize.Folds{}})
+	if len(got) != 1 || got[0].Header != "GENERAL" {
+		t.Fatalf("expected one GENERAL section, got %#v", got)
+	}
+}
+
+func TestParseWith_HeaderHintsFromHTML(t *testing.T) {
+	t.Parallel()
+
+	text, bold := normalize.HTMLToText("<p><b>tag</b></p><p>Amen&nbsp;amen</p><p>tag</p>")
+	got := ParseWith(text, Options{HeaderHints: bold})
+	if len(got) != 1 || got[0].Header != "TAG" {
+		t.Fatalf("expected one TAG section, got %#v", got)
+	}
+	want := []string{"Amen amen", "tag"}
+	if !reflect.DeepEqual(got[0].Content, want) {
+		t.Fatalf("content mismatch: want %#v, got %#v", want, got[0].Content)
+	}
+}
+
+func TestParseSong_ChordProEnvironments(t *testing.T) {
+	t.Parallel()
+
+	text := strings.Join([]string{
+		"{title: Amazing Grace}",
+		"{artist: John Newton}",
+		"{key: G}",
+		"{meta: ccli 22025}",
+		"",
+		"{start_of_verse: Verse 1}",
+		"[G]Amazing grace",
+		"Chorus",
+		"{comment: softly}",
+		"{end_of_verse}",
+		"",
+		"{soc}",
+		"My chains are gone",
+		"{eoc}",
+		"{start_of_bridge label=\"Solo\"}",
+		"[C] [G]",
+		"{end_of_bridge}",
+		"",
+	}, "\n")
+	got := ParseSong(text)
+	if got.Title != "Amazing Grace" || got.Author != "John Newton" || got.Key != "G" || got.CCLI != "22025" {
+		t.Fatalf("metadata mismatch: %#v", got)
+	}
+	want := []Section{
+		{Header: "VERSE 1", Content: []string{"[G]Amazing grace", "Chorus", "{comment: softly}"}},
+		{Header: "CHORUS", Content: []string{"My chains are gone"}},
+		{Header: "SOLO", Content: []string{"[C] [G]"}},
+	}
+	if !reflect.DeepEqual(withoutPositions(got.Sections), want) {
+		t.Fatalf("sections mismatch:\nwant %#v\ngot  %#v", want, got.Sections)
+	}
+}
+
+func TestParse_MetadataDirectivesAreNotContent(t *testing.T) {
+	t.Parallel()
+
+	got := withoutPositions(Parse("{t: Song}\n\nVerse\nLa la\n{comment: x}"))
+	want := []Section{{Header: "VERSE", Content: []string{"La la", "{comment: x}"}}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("sections mismatch:\nwant %#v\ngot  %#v", want, got)
+	}
+}
+
+func TestHeaderLine(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		line, header, rest string
+		ok                 bool
+	}{
+		{"[Verse 2]", "VERSE 2", "", true},
+		{"Chorus: Hallelujah", "CHORUS", "Hallelujah", true},
+		{"{start_of_chorus}", "CHORUS", "", true},
+		{"{title: Song}", "", "", false},
+		{"Amazing grace", "", "", false},
+		{"PC", "", "", false},
+	}
+	for _, c := range cases {
+		h, rest, ok := HeaderLine(c.line, Options{})
+		if h != c.header || rest != c.rest || ok != c.ok {
+			t.Fatalf("HeaderLine(%q) = %q, %q, %v; want %q, %q, %v", c.line, h, rest, ok, c.header, c.rest, c.ok)
+		}
+	}
+	if h, _, ok := HeaderLine("PC", Options{Abbreviations: true}); !ok || h != "PRE-CHORUS" {
+		t.Fatalf("abbreviation not detected: %q, %v", h, ok)
+	}
+	if _, _, ok := HeaderLine("C", Options{Abbreviations: true}); ok {
+		t.Fatalf("chord C read as a header")
+	}
+	if h, _, ok := HeaderLine("C:", Options{Abbreviations: true}); !ok || h != "CHORUS" {
+		t.Fatalf("C with a colon: %q, %v", h, ok)
+	}
+}
+
+func TestParse_Positions(t *testing.T) {
+	t.Parallel()
+
+	// CRLF and CR line ends, a BOM and a non-breaking space are normalized or folded in the
+	// content, but positions still point into the original text.
+	text := "\ufeffIntro words\r\n\r\nVerse 1: Amazing\u00a0grace\rhow sweet\n{soc}\nHallelujah\n{eoc}\n"
+	got := Parse(text)
+	want := []Section{
+		{Header: "GENERAL", Content: []string{"Intro words", ""},
+			Pos: Span{1, 0, 14}, Lines: []Span{{1, 0, 14}, {2, 16, 16}}},
+		{Header: "VERSE 1", Content: []string{"Amazing grace", "how sweet"},
+			Pos: Span{3, 18, 41}, Lines: []Span{{3, 18, 41}, {4, 42, 51}}},
+		{Header: "CHORUS", Content: []string{"Hallelujah"},
+			Pos: Span{5, 52, 57}, Lines: []Span{{6, 58, 68}}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("sections mismatch:\nwant %#v\ngot  %#v", want, got)
+	}
+	if s := text[got[2].Lines[0].Start:got[2].Lines[0].End]; s != "Hallelujah" {
+		t.Fatalf("chorus line range holds %q", s)
+	}
+}
+
+// withoutPositions clears section positions for comparisons of content only.
+func withoutPositions(sections []Section) []Section {
+	for i := range sections {
+		sections[i].Pos, sections[i].Lines = Span{}, nil
+	}
+	return sections
+}
+
+func TestParseTree_RoundTrip(t *testing.T) {
+	t.Parallel()
+
+	headers, err := os.ReadFile("testdata/headers.txt")
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	charts := []string{
+		"",
+		"\n",
+		"Just lyrics",
+		"\ufeff[Verse 1]  \r\nAmazing\u00a0grace\r\n\r\n\r\n<b>CHORUS:</b>\rHow sweet (x2)\r",
+		"{title: Song}\n\n{start_of_verse: Verse 1}\n[G]La\n\n{end_of_verse}\n\n\n{soc}\nLi\n{eoc}\n",
+		"Verse 1: Amazing grace\n\tHow sweet   the sound\nverse\n\n",
+		string(headers),
+	}
+	for _, chart := range charts {
+		tree := ParseTree(chart, Options{Abbreviations: true})
+		if got := tree.String(); got != chart {
+			t.Fatalf("round trip of %q printed %q", chart, got)
+		}
+		if got, want := tree.Song(), ParseSongWith(chart, Options{Abbreviations: true}); !reflect.DeepEqual(got, want) {
+			t.Fatalf("Song of %q:\nwant %#v\ngot  %#v", chart, want, got)
+		}
+	}
+}
+
+func TestParseTree_KeepsSpelling(t *testing.T) {
+	t.Parallel()
+
+	tree := ParseTree("{key: G}\n\n<b>Refrein:</b>\r\nLa\n", Options{})
+	if n := len(tree.Sections); n != 2 {
+		t.Fatalf("got %d sections, want 2", n)
+	}
+	meta, h := tree.Sections[0].Lines, tree.Sections[1].Header
+	if len(meta) != 2 || meta[0].Kind != LineMeta || meta[1].Kind != LineLayout {
+		t.Fatalf("leading lines = %#v", meta)
+	}
+	if h.Kind != LineHeader || h.Header != "CHORUS" || h.Text != "<b>Refrein:</b>" || h.EOL != "\r\n" || h.Pos.Line != 3 {
+		t.Fatalf("header line = %#v", h)
+	}
+}
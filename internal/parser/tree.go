@@ -0,0 +1,221 @@
+package parser
+
+import (
+	"chordparser/internal/normalize"
+	"io"
+	"strings"
+)
+
+// LineKind classifies a line of a Tree.
+type LineKind int
+
+const (
+	// LineContent is a lyric, chord or comment line, or a blank line inside a section.
+	LineContent LineKind = iota
+	// LineHeader starts a section: a header, an inline label with content or a ChordPro {start_of_*}.
+	LineHeader
+	// LineMeta is a metadata directive ({title}, {key}, ...) or the end of a ChordPro environment.
+	LineMeta
+	// LineLayout is a blank line after a metadata directive or environment end; it is not content.
+	LineLayout
+)
+
+// TreeLine is a line of a Tree, kept exactly as written.
+type TreeLine struct {
+	Kind LineKind
+	// Text is the line as written, without its line end.
+	Text string
+	// EOL is the line end as written: "\n", "\r\n", "\r", or "" for the last line.
+	EOL string
+	// Header is the section header of a LineHeader before duplicates are numbered (e.g., "VERSE 1"),
+	// and Rest the content that shares the line with an inline label. Both describe the line as
+	// parsed; a rule that rewrites a header line's Text must update them too.
+	Header, Rest string
+	// Pos is where the line was in the parsed text; it is zero for lines added later.
+	Pos Span
+}
+
+// TreeSection is the header line of a section (nil for text before the first header) and the
+// lines up to the next header.
+type TreeSection struct {
+	Header *TreeLine
+	Lines  []*TreeLine
+}
+
+// Tree is a lossless parse of a chart: every line, blank line and line end is kept as written,
+// so that rules can rewrite only the lines they touch. Printing an unchanged tree reproduces
+// the input byte for byte.
+type Tree struct {
+	Sections []*TreeSection
+	folds    normalize.Folds
+}
+
+// ParseTree parses a chart into a Tree using the same rules as ParseSongWith.
+func ParseTree(text string, opts Options) *Tree {
+	keywords := keywordsFor(opts.Language)
+	t := &Tree{folds: normalize.FoldsOrDefault(opts.Folds)}
+	// inEnv is set inside a ChordPro environment ({start_of_verse} ... {end_of_verse}), where every
+	// line is content; outside is set after an environment end or metadata, where blank lines are
+	// layout rather than content.
+	inEnv, outside := false, false
+	// notes holds the bare note-letter abbreviations ("C", "B", "E2"), which are headers only in a
+	// chart whose other headers are all abbreviations (abbreviated set, spelled not).
+	var notes []*TreeLine
+	abbreviated, spelled := false, false
+
+	// Lines are split before folding (folds never add or remove line breaks) so that every line
+	// keeps its position in the original text.
+	src := normalize.Lines(text)
+	lines := make([]*TreeLine, len(src))
+	for i, l := range src {
+		n := &TreeLine{Kind: LineContent, Text: l.Text, Pos: Span{Line: i + 1, Start: l.Start, End: l.End}}
+		lines[i] = n
+		if i+1 < len(src) {
+			n.EOL = text[l.End:src[i+1].Start]
+		}
+		line := normalize.Fold(l.Text, t.folds)
+
+		switch d, isDirective := parseDirective(line); {
+		case isDirective && isEnvironment(d):
+			env, begin, _ := d.environment()
+			if begin {
+				n.Kind, n.Header = LineHeader, environmentHeader(env, d.value)
+				spelled = true
+			} else {
+				n.Kind = LineMeta
+			}
+			inEnv, outside = begin, !begin
+		case isDirective && metaDirectives[d.name]:
+			n.Kind = LineMeta
+			outside = true
+		case outside && strings.TrimSpace(line) == "":
+			n.Kind = LineLayout
+		default:
+			// Other directives (e.g., {comment: ...}) are content
+			outside = false
+			if inEnv {
+				break
+			}
+			hinted := i < len(opts.HeaderHints) && opts.HeaderHints[i]
+			if h, rest, ok := detectLine(line, keywords, opts.Abbreviations, hinted); ok {
+				n.Kind, n.Header, n.Rest = LineHeader, h, rest
+				if _, _, ok := detectAbbreviation(line, true); ok && rest == "" {
+					abbreviated = true
+				} else {
+					spelled = true
+				}
+			} else if opts.Abbreviations {
+				if base, num, ok := detectAbbreviation(line, true); ok {
+					n.Header = formatHeader(base, num)
+					notes = append(notes, n)
+				}
+			}
+		}
+	}
+
+	// A note letter right below a header is the section's first chord line, as in "V1", "C",
+	// "Amazing grace"
+	promote := abbreviated && !spelled
+	for _, n := range notes {
+		if promote && (n.Pos.Line == 1 || lines[n.Pos.Line-2].Kind != LineHeader) {
+			n.Kind = LineHeader
+		} else {
+			n.Header = ""
+		}
+	}
+
+	cur := &TreeSection{}
+	t.Sections = append(t.Sections, cur)
+	for _, n := range lines {
+		if n.Kind == LineHeader {
+			cur = &TreeSection{Header: n}
+			t.Sections = append(t.Sections, cur)
+			continue
+		}
+		cur.Lines = append(cur.Lines, n)
+	}
+	return t
+}
+
+// isEnvironment reports whether a directive starts or ends a ChordPro environment.
+func isEnvironment(d directive) bool {
+	_, _, ok := d.environment()
+	return ok
+}
+
+// Song returns the song the tree describes, as ParseSongWith does for the parsed text.
+// Content lines are folded as they would be when parsing.
+func (t *Tree) Song() Song {
+	var song Song
+	foundAnyHeader := false
+	for _, ts := range t.Sections {
+		s := Section{Header: "GENERAL"}
+		if h := ts.Header; h != nil {
+			foundAnyHeader = true
+			s.Header, s.Pos = h.Header, h.Pos
+			if h.Rest != "" {
+				s.Content, s.Lines = append(s.Content, h.Rest), append(s.Lines, h.Pos)
+			}
+		}
+		for _, l := range ts.Lines {
+			switch l.Kind {
+			case LineContent:
+				s.Content = append(s.Content, normalize.Fold(l.Text, t.folds))
+				s.Lines = append(s.Lines, l.Pos)
+			case LineMeta:
+				if d, ok := parseDirective(normalize.Fold(l.Text, t.folds)); ok && metaDirectives[d.name] {
+					song.applyMeta(d)
+				}
+			}
+		}
+		if len(s.Content) == 0 {
+			continue
+		}
+		if ts.Header == nil {
+			s.Pos = s.Lines[0]
+		}
+		song.Sections = append(song.Sections, s)
+	}
+
+	// If at least one header was found, ensure uniqueness across duplicates.
+	// If none were found, keep the single "General" section unnumbered.
+	if foundAnyHeader {
+		song.Sections = makeUniqueHeaders(song.Sections)
+	}
+	return song
+}
+
+// Fold applies the tree's Unicode and typography folds to a line, as parsing does.
+func (t *Tree) Fold(line string) string {
+	return normalize.Fold(line, t.folds)
+}
+
+// String prints the tree. An unchanged tree prints exactly the parsed text.
+func (t *Tree) String() string {
+	var b strings.Builder
+	_, _ = t.WriteTo(&b)
+	return b.String()
+}
+
+// WriteTo writes the printed tree to w.
+func (t *Tree) WriteTo(w io.Writer) (int64, error) {
+	var total int64
+	write := func(l *TreeLine) error {
+		n, err := io.WriteString(w, l.Text+l.EOL)
+		total += int64(n)
+		return err
+	}
+	for _, s := range t.Sections {
+		if s.Header != nil {
+			if err := write(s.Header); err != nil {
+				return total, err
+			}
+		}
+		for _, l := range s.Lines {
+			if err := write(l); err != nil {
+				return total, err
+			}
+		}
+	}
+	return total, nil
+}
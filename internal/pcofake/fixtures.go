@@ -0,0 +1,80 @@
+package pcofake
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"time"
+)
+
+//go:embed fixtures/*.json
+var sample embed.FS
+
+// Song is a song in a fixture file, with its arrangements.
+type Song struct {
+	ID           string        `json:"id"`
+	Title        string        `json:"title"`
+	Author       string        `json:"author,omitempty"`
+	CCLINumber   int           `json:"ccli_number,omitempty"`
+	UpdatedAt    time.Time     `json:"updated_at"`
+	Arrangements []Arrangement `json:"arrangements"`
+}
+
+// Arrangement is an arrangement of a fixture song. Its writable fields are the ones PATCH
+// accepts: name, chord_chart, chord_chart_key, sequence and lyrics.
+type Arrangement struct {
+	ID            string    `json:"id"`
+	Name          string    `json:"name"`
+	ChordChart    string    `json:"chord_chart"`
+	ChordChartKey string    `json:"chord_chart_key,omitempty"`
+	Sequence      []string  `json:"sequence"`
+	Lyrics        string    `json:"lyrics"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// Sample returns the fixture files shipped with the package: three songs with four
+// arrangements whose charts need cleaning.
+func Sample() fs.FS {
+	sub, _ := fs.Sub(sample, "fixtures")
+	return sub
+}
+
+// LoadFixtures reads the songs of every *.json file in fsys (one song per file), in file name
+// order. Song IDs and the arrangement IDs must be unique.
+func LoadFixtures(fsys fs.FS) ([]Song, error) {
+	names, err := fs.Glob(fsys, "*.json")
+	if err != nil {
+		return nil, err
+	}
+	var songs []Song
+	seen := map[string]string{}
+	for _, name := range names {
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil, err
+		}
+		var s Song
+		if err := json.Unmarshal(data, &s); err != nil {
+			return nil, fmt.Errorf("pcofake: %s: %w", name, err)
+		}
+		ids := []string{"song " + s.ID}
+		for _, a := range s.Arrangements {
+			ids = append(ids, "arrangement "+a.ID)
+		}
+		for _, id := range ids {
+			if id == "song " || id == "arrangement " {
+				return nil, fmt.Errorf("pcofake: %s: missing id", name)
+			}
+			if other, ok := seen[id]; ok {
+				return nil, fmt.Errorf("pcofake: %s: %s is also in %s", name, id, other)
+			}
+			seen[id] = name
+		}
+		songs = append(songs, s)
+	}
+	if len(songs) == 0 {
+		return nil, fmt.Errorf("pcofake: no *.json fixture files")
+	}
+	return songs, nil
+}
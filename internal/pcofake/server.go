@@ -0,0 +1,514 @@
+package pcofake
+
+import (
+	"chordparser/internal/webhook"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Defaults of the options, as in the PCO API.
+const (
+	DefaultPerPage    = 25
+	MaxPerPage        = 100
+	DefaultRateLimit  = 100
+	DefaultRatePeriod = 20 * time.Second
+)
+
+// Options configures the fake server.
+type Options struct {
+	// Songs are the songs to serve; nil means the Sample fixtures.
+	Songs []Song
+	// PerPage is the default page size of list endpoints; zero means DefaultPerPage.
+	PerPage int
+	// RateLimit is the number of requests allowed per RatePeriod, after which requests get
+	// 429 Too Many Requests until the period ends; negative means unlimited and zero means
+	// DefaultRateLimit per DefaultRatePeriod.
+	RateLimit  int
+	RatePeriod time.Duration
+	// Webhooks are the URLs that receive a signed arrangement.updated delivery (see
+	// internal/webhook) whenever an arrangement changes, signed with WebhookSecret.
+	Webhooks      []string
+	WebhookSecret string
+	// Username and Password, when set, are required as HTTP basic auth (PCO's application ID
+	// and secret).
+	Username, Password string
+	// Log receives failed webhook deliveries; nil discards them.
+	Log *log.Logger
+}
+
+// Server is a fake Planning Center Services API serving songs and arrangements from memory:
+//
+//	GET   /services/v2/songs                                 songs, paginated
+//	GET   /services/v2/songs/{song}                          one song
+//	GET   /services/v2/songs/{song}/arrangements             its arrangements, paginated
+//	GET   /services/v2/songs/{song}/arrangements/{id}        one arrangement
+//	PATCH /services/v2/songs/{song}/arrangements/{id}        update an arrangement
+//
+// Responses are JSON:API documents as PCO sends them, errors included. Like PCO, a PATCH is
+// not conditional: it overwrites whatever the arrangement holds.
+type Server struct {
+	// URL is the base URL of a server started with Start.
+	URL string
+
+	opts   Options
+	mux    *http.ServeMux
+	ts     *httptest.Server
+	client *http.Client
+	hooks  sync.WaitGroup
+
+	mu       sync.Mutex
+	songs    []Song
+	requests int
+	window   time.Time // start of the current rate limit period
+	count    int       // requests in the current period
+}
+
+// New returns a fake server to mount on an http.Server.
+func New(opts Options) *Server {
+	if opts.Songs == nil {
+		songs, err := LoadFixtures(Sample())
+		if err != nil {
+			panic(err) // the embedded fixtures are broken
+		}
+		opts.Songs = songs
+	}
+	if opts.PerPage <= 0 {
+		opts.PerPage = DefaultPerPage
+	}
+	if opts.RateLimit == 0 {
+		opts.RateLimit = DefaultRateLimit
+	}
+	if opts.RatePeriod <= 0 {
+		opts.RatePeriod = DefaultRatePeriod
+	}
+	s := &Server{opts: opts, client: &http.Client{Timeout: 10 * time.Second}}
+	for _, song := range opts.Songs {
+		s.songs = append(s.songs, copySong(song))
+	}
+
+	s.mux = http.NewServeMux()
+	s.mux.HandleFunc("GET /services/v2/songs", s.listSongs)
+	s.mux.HandleFunc("GET /services/v2/songs/{song}", s.getSong)
+	s.mux.HandleFunc("GET /services/v2/songs/{song}/arrangements", s.listArrangements)
+	s.mux.HandleFunc("GET /services/v2/songs/{song}/arrangements/{id}", s.getArrangement)
+	s.mux.HandleFunc("PATCH /services/v2/songs/{song}/arrangements/{id}", s.patchArrangement)
+	for _, path := range []string{"/services/v2/songs", "/services/v2/songs/{song}", "/services/v2/songs/{song}/arrangements", "/services/v2/songs/{song}/arrangements/{id}"} {
+		s.mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+			writeError(w, http.StatusMethodNotAllowed, fmt.Sprintf("method %s is not allowed on %s", r.Method, r.URL.Path))
+		})
+	}
+	s.mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, http.StatusNotFound, "no resource at "+r.URL.Path)
+	})
+	return s
+}
+
+// Start starts a fake server on a local port (see httptest.NewServer) and sets its URL.
+func Start(opts Options) *Server {
+	s := New(opts)
+	s.ts = httptest.NewServer(s)
+	s.URL = s.ts.URL
+	return s
+}
+
+// Close stops a server started with Start and waits for its webhook deliveries.
+func (s *Server) Close() {
+	if s.ts != nil {
+		s.ts.Close()
+	}
+	s.hooks.Wait()
+}
+
+// ServeHTTP checks the credentials and the rate limit, then serves the API.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.requests++
+	s.mu.Unlock()
+	if s.opts.Username != "" {
+		user, password, ok := r.BasicAuth()
+		if !ok || user != s.opts.Username || password != s.opts.Password {
+			writeError(w, http.StatusUnauthorized, "missing or invalid basic auth credentials")
+			return
+		}
+	}
+	if retry, ok := s.allow(w); !ok {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retry.Seconds()))))
+		writeError(w, http.StatusTooManyRequests, fmt.Sprintf("rate limit of %d requests per %s exceeded", s.opts.RateLimit, s.opts.RatePeriod))
+		return
+	}
+	s.mux.ServeHTTP(w, r)
+}
+
+// allow counts a request against the rate limit and sets PCO's rate limit headers. When the
+// limit is exceeded it returns the time until the period ends.
+func (s *Server) allow(w http.ResponseWriter) (time.Duration, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.opts.RateLimit < 0 {
+		return 0, true
+	}
+	now := time.Now()
+	if now.Sub(s.window) >= s.opts.RatePeriod {
+		s.window, s.count = now, 0
+	}
+	s.count++
+	w.Header().Set("X-PCO-API-Request-Rate-Limit", strconv.Itoa(s.opts.RateLimit))
+	w.Header().Set("X-PCO-API-Request-Rate-Count", strconv.Itoa(s.count))
+	w.Header().Set("X-PCO-API-Request-Rate-Period", strconv.Itoa(int(s.opts.RatePeriod.Seconds())))
+	if s.count > s.opts.RateLimit {
+		return s.window.Add(s.opts.RatePeriod).Sub(now), false
+	}
+	return 0, true
+}
+
+// Requests returns the number of requests received, rejected ones included.
+func (s *Server) Requests() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests
+}
+
+// Song returns a copy of a song's current state.
+func (s *Server) Song(id string) (Song, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if song := s.song(id); song != nil {
+		return copySong(*song), true
+	}
+	return Song{}, false
+}
+
+// Arrangement returns a copy of an arrangement's current state.
+func (s *Server) Arrangement(songID, id string) (Arrangement, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if a := s.arrangement(songID, id); a != nil {
+		return copyArrangement(*a), true
+	}
+	return Arrangement{}, false
+}
+
+// Update changes an arrangement as a PCO user would: fn edits it, its updated_at advances and
+// the webhooks are notified. Tests use it to cause conflicts.
+func (s *Server) Update(songID, id string, fn func(*Arrangement)) error {
+	s.mu.Lock()
+	a := s.arrangement(songID, id)
+	if a == nil {
+		s.mu.Unlock()
+		return fmt.Errorf("pcofake: no arrangement %s of song %s", id, songID)
+	}
+	fn(a)
+	touch(a)
+	s.mu.Unlock()
+	s.notify(songID, id)
+	return nil
+}
+
+func (s *Server) listSongs(w http.ResponseWriter, r *http.Request) {
+	page, ok := s.page(w, r)
+	if !ok {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data := make([]any, 0, len(s.songs))
+	for _, song := range s.songs {
+		data = append(data, songResource(baseURL(r), song))
+	}
+	writeList(w, r, data, page)
+}
+
+func (s *Server) getSong(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	song := s.song(r.PathValue("song"))
+	if song == nil {
+		writeError(w, http.StatusNotFound, "no song "+r.PathValue("song"))
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"data": songResource(baseURL(r), *song)})
+}
+
+func (s *Server) listArrangements(w http.ResponseWriter, r *http.Request) {
+	page, ok := s.page(w, r)
+	if !ok {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	song := s.song(r.PathValue("song"))
+	if song == nil {
+		writeError(w, http.StatusNotFound, "no song "+r.PathValue("song"))
+		return
+	}
+	data := make([]any, 0, len(song.Arrangements))
+	for _, a := range song.Arrangements {
+		data = append(data, arrangementResource(baseURL(r), song.ID, a))
+	}
+	writeList(w, r, data, page)
+}
+
+func (s *Server) getArrangement(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	songID := r.PathValue("song")
+	a := s.arrangement(songID, r.PathValue("id"))
+	if a == nil {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("no arrangement %s of song %s", r.PathValue("id"), songID))
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"data": arrangementResource(baseURL(r), songID, *a)})
+}
+
+// patchArrangement updates the writable attributes of an arrangement.
+func (s *Server) patchArrangement(w http.ResponseWriter, r *http.Request) {
+	songID, id := r.PathValue("song"), r.PathValue("id")
+	var body struct {
+		Data struct {
+			Type       string                     `json:"type"`
+			ID         string                     `json:"id"`
+			Attributes map[string]json.RawMessage `json:"attributes"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON body: "+err.Error())
+		return
+	}
+	if (body.Data.Type != "" && body.Data.Type != "Arrangement") || (body.Data.ID != "" && body.Data.ID != id) {
+		writeError(w, http.StatusConflict, fmt.Sprintf("the body is %s %s, not Arrangement %s", body.Data.Type, body.Data.ID, id))
+		return
+	}
+
+	s.mu.Lock()
+	a := s.arrangement(songID, id)
+	if a == nil {
+		s.mu.Unlock()
+		writeError(w, http.StatusNotFound, fmt.Sprintf("no arrangement %s of song %s", id, songID))
+		return
+	}
+	updated := copyArrangement(*a)
+	for name, raw := range body.Data.Attributes {
+		var err error
+		switch name {
+		case "name":
+			err = json.Unmarshal(raw, &updated.Name)
+		case "chord_chart":
+			err = json.Unmarshal(raw, &updated.ChordChart)
+		case "chord_chart_key":
+			err = json.Unmarshal(raw, &updated.ChordChartKey)
+		case "sequence":
+			err = json.Unmarshal(raw, &updated.Sequence)
+		case "lyrics":
+			err = json.Unmarshal(raw, &updated.Lyrics)
+		default:
+			err = fmt.Errorf("is not writable")
+		}
+		if err != nil {
+			s.mu.Unlock()
+			writeError(w, http.StatusUnprocessableEntity, fmt.Sprintf("attribute %s: %v", name, err))
+			return
+		}
+	}
+	touch(&updated)
+	*a = updated
+	writeJSON(w, http.StatusOK, map[string]any{"data": arrangementResource(baseURL(r), songID, updated)})
+	s.mu.Unlock()
+	s.notify(songID, id)
+}
+
+// notify sends an arrangement.updated delivery to every webhook URL in the background.
+func (s *Server) notify(songID, id string) {
+	if len(s.opts.Webhooks) == 0 {
+		return
+	}
+	body, err := webhook.Delivery("arrangement.updated", webhook.Target{SongID: songID, ArrangementID: id})
+	if err != nil {
+		s.logf("webhook: %v", err)
+		return
+	}
+	for _, url := range s.opts.Webhooks {
+		s.hooks.Add(1)
+		go func() {
+			defer s.hooks.Done()
+			req, err := webhook.NewRequest(url, s.opts.WebhookSecret, body)
+			if err != nil {
+				s.logf("webhook %s: %v", url, err)
+				return
+			}
+			resp, err := s.client.Do(req)
+			if err != nil {
+				s.logf("webhook %s: %v", url, err)
+				return
+			}
+			resp.Body.Close()
+			if resp.StatusCode >= 300 {
+				s.logf("webhook %s: %s", url, resp.Status)
+			}
+		}()
+	}
+}
+
+func (s *Server) logf(format string, args ...any) {
+	if s.opts.Log != nil {
+		s.opts.Log.Printf(format, args...)
+	}
+}
+
+// song returns the song with an ID; the caller holds s.mu.
+func (s *Server) song(id string) *Song {
+	for i := range s.songs {
+		if s.songs[i].ID == id {
+			return &s.songs[i]
+		}
+	}
+	return nil
+}
+
+// arrangement returns an arrangement of a song; the caller holds s.mu.
+func (s *Server) arrangement(songID, id string) *Arrangement {
+	song := s.song(songID)
+	if song == nil {
+		return nil
+	}
+	for i := range song.Arrangements {
+		if song.Arrangements[i].ID == id {
+			return &song.Arrangements[i]
+		}
+	}
+	return nil
+}
+
+// page is the requested slice of a list.
+type page struct {
+	offset, perPage int
+}
+
+// page reads the per_page and offset query parameters.
+func (s *Server) page(w http.ResponseWriter, r *http.Request) (page, bool) {
+	p := page{perPage: s.opts.PerPage}
+	q := r.URL.Query()
+	if v := q.Get("per_page"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("per_page must be a positive integer, got %q", v))
+			return p, false
+		}
+		p.perPage = min(n, MaxPerPage)
+	}
+	if v := q.Get("offset"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("offset must be a non-negative integer, got %q", v))
+			return p, false
+		}
+		p.offset = n
+	}
+	return p, true
+}
+
+// writeList answers with one page of a list, linking to the next page as PCO does.
+func writeList(w http.ResponseWriter, r *http.Request, data []any, p page) {
+	total := len(data)
+	start, end := min(p.offset, total), min(p.offset+p.perPage, total)
+	meta := map[string]any{"total_count": total, "count": end - start}
+	links := map[string]any{"self": baseURL(r) + r.URL.RequestURI()}
+	if end < total {
+		meta["next"] = map[string]int{"offset": end}
+		q := r.URL.Query()
+		q.Set("per_page", strconv.Itoa(p.perPage))
+		q.Set("offset", strconv.Itoa(end))
+		links["next"] = baseURL(r) + r.URL.Path + "?" + q.Encode()
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"data": data[start:end], "included": []any{}, "meta": meta, "links": links})
+}
+
+func songResource(base string, s Song) map[string]any {
+	return map[string]any{
+		"type": "Song",
+		"id":   s.ID,
+		"attributes": map[string]any{
+			"title":       s.Title,
+			"author":      s.Author,
+			"ccli_number": s.CCLINumber,
+			"updated_at":  s.UpdatedAt,
+		},
+		"links": map[string]string{"self": base + "/services/v2/songs/" + s.ID},
+	}
+}
+
+func arrangementResource(base, songID string, a Arrangement) map[string]any {
+	sequence := a.Sequence
+	if sequence == nil {
+		sequence = []string{}
+	}
+	return map[string]any{
+		"type": "Arrangement",
+		"id":   a.ID,
+		"attributes": map[string]any{
+			"name":            a.Name,
+			"chord_chart":     a.ChordChart,
+			"chord_chart_key": a.ChordChartKey,
+			"sequence":        sequence,
+			"lyrics":          a.Lyrics,
+			"updated_at":      a.UpdatedAt,
+		},
+		"relationships": map[string]any{
+			"song": map[string]any{"data": map[string]string{"type": "Song", "id": songID}},
+		},
+		"links": map[string]string{"self": base + "/services/v2/songs/" + songID + "/arrangements/" + a.ID},
+	}
+}
+
+// touch advances an arrangement's updated_at to now, in whole seconds as PCO reports it, and
+// always past the previous value so that a client comparing it sees every change.
+func touch(a *Arrangement) {
+	now := time.Now().UTC().Truncate(time.Second)
+	if !now.After(a.UpdatedAt) {
+		now = a.UpdatedAt.Add(time.Second)
+	}
+	a.UpdatedAt = now
+}
+
+func baseURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host
+}
+
+func copySong(s Song) Song {
+	s.Arrangements = slices.Clone(s.Arrangements)
+	for i, a := range s.Arrangements {
+		s.Arrangements[i] = copyArrangement(a)
+	}
+	return s
+}
+
+func copyArrangement(a Arrangement) Arrangement {
+	a.Sequence = slices.Clone(a.Sequence)
+	return a
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/vnd.api+json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// writeError answers with a JSON:API error document, as PCO does.
+func writeError(w http.ResponseWriter, status int, detail string) {
+	writeJSON(w, status, map[string]any{"errors": []map[string]string{{
+		"status": strconv.Itoa(status),
+		"title":  http.StatusText(status),
+		"detail": detail,
+	}}})
+}
@@ -0,0 +1,193 @@
+package pcofake
+
+import (
+	"chordparser/internal/webhook"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+type document struct {
+	Data  json.RawMessage `json:"data"`
+	Links struct {
+		Next string `json:"next"`
+	} `json:"links"`
+	Meta struct {
+		TotalCount int `json:"total_count"`
+	} `json:"meta"`
+	Errors []struct {
+		Status, Title, Detail string
+	} `json:"errors"`
+}
+
+type resource struct {
+	Type       string         `json:"type"`
+	ID         string         `json:"id"`
+	Attributes map[string]any `json:"attributes"`
+}
+
+func do(t *testing.T, method, url, body string, header http.Header) (*http.Response, document) {
+	t.Helper()
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var doc document
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		t.Fatalf("%s %s: decode: %v", method, url, err)
+	}
+	return resp, doc
+}
+
+func TestServer_Pagination(t *testing.T) {
+	t.Parallel()
+	s := Start(Options{PerPage: 2})
+	defer s.Close()
+
+	var ids []string
+	url, pages := s.URL+"/services/v2/songs", 0
+	for url != "" {
+		resp, doc := do(t, "GET", url, "", nil)
+		if resp.StatusCode != http.StatusOK || doc.Meta.TotalCount != 3 {
+			t.Fatalf("GET %s: %d %+v", url, resp.StatusCode, doc)
+		}
+		var songs []resource
+		if err := json.Unmarshal(doc.Data, &songs); err != nil {
+			t.Fatal(err)
+		}
+		for _, s := range songs {
+			ids = append(ids, s.ID)
+		}
+		url = doc.Links.Next
+		pages++
+	}
+	if want := []string{"101", "102", "103"}; pages != 2 || !reflect.DeepEqual(ids, want) {
+		t.Fatalf("%d pages of songs %v, want 2 pages of %v", pages, ids, want)
+	}
+
+	_, doc := do(t, "GET", s.URL+"/services/v2/songs/101/arrangements?per_page=1&offset=1", "", nil)
+	var arrangements []resource
+	if err := json.Unmarshal(doc.Data, &arrangements); err != nil || len(arrangements) != 1 || arrangements[0].ID != "1002" || doc.Links.Next != "" {
+		t.Fatalf("arrangements page = %s, next %q", doc.Data, doc.Links.Next)
+	}
+	if resp, _ := do(t, "GET", s.URL+"/services/v2/songs?per_page=x", "", nil); resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("invalid per_page: %d", resp.StatusCode)
+	}
+}
+
+func TestServer_Patch(t *testing.T) {
+	t.Parallel()
+	s := Start(Options{})
+	defer s.Close()
+	url := s.URL + "/services/v2/songs/101/arrangements/1001"
+
+	_, doc := do(t, "GET", url, "", nil)
+	var a resource
+	if err := json.Unmarshal(doc.Data, &a); err != nil || a.Attributes["chord_chart_key"] != "G" {
+		t.Fatalf("GET arrangement: %s", doc.Data)
+	}
+	before, _ := s.Arrangement("101", "1001")
+
+	patch := `{"data":{"type":"Arrangement","id":"1001","attributes":{"chord_chart":"VERSE 1\nClean\n","sequence":["Verse 1"]}}}`
+	if resp, _ := do(t, "PATCH", url, patch, nil); resp.StatusCode != http.StatusOK {
+		t.Fatalf("PATCH: %d", resp.StatusCode)
+	}
+	got, _ := s.Arrangement("101", "1001")
+	if got.ChordChart != "VERSE 1\nClean\n" || !reflect.DeepEqual(got.Sequence, []string{"Verse 1"}) || got.Lyrics == "" {
+		t.Fatalf("patched arrangement = %+v", got)
+	}
+	if !got.UpdatedAt.After(before.UpdatedAt) {
+		t.Fatalf("updated_at %s did not advance past %s", got.UpdatedAt, before.UpdatedAt)
+	}
+
+	for _, c := range []struct {
+		body   string
+		status int
+	}{
+		{`{"data":{"attributes":{"title":"x"}}}`, http.StatusUnprocessableEntity},
+		{`{"data":{"attributes":{"sequence":"Verse 1"}}}`, http.StatusUnprocessableEntity},
+		{`{"data":{"type":"Song","attributes":{}}}`, http.StatusConflict},
+		{`{`, http.StatusBadRequest},
+	} {
+		if resp, _ := do(t, "PATCH", url, c.body, nil); resp.StatusCode != c.status {
+			t.Fatalf("PATCH %s: %d, want %d", c.body, resp.StatusCode, c.status)
+		}
+	}
+	if resp, _ := do(t, "PATCH", s.URL+"/services/v2/songs/101/arrangements/9", `{"data":{"attributes":{}}}`, nil); resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("PATCH of a missing arrangement: %d", resp.StatusCode)
+	}
+}
+
+func TestServer_RateLimitAndAuth(t *testing.T) {
+	t.Parallel()
+	s := Start(Options{RateLimit: 2, RatePeriod: time.Minute, Username: "app", Password: "secret"})
+	defer s.Close()
+	auth := http.Header{"Authorization": {"Basic YXBwOnNlY3JldA=="}}
+
+	if resp, _ := do(t, "GET", s.URL+"/services/v2/songs", "", nil); resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("without credentials: %d", resp.StatusCode)
+	}
+	for i := range 2 {
+		if resp, _ := do(t, "GET", s.URL+"/services/v2/songs", "", auth); resp.StatusCode != http.StatusOK {
+			t.Fatalf("request %d: %d", i, resp.StatusCode)
+		}
+	}
+	resp, doc := do(t, "GET", s.URL+"/services/v2/songs", "", auth)
+	if resp.StatusCode != http.StatusTooManyRequests || resp.Header.Get("Retry-After") == "" || doc.Errors[0].Status != "429" {
+		t.Fatalf("over the limit: %d %v", resp.StatusCode, resp.Header)
+	}
+	if resp.Header.Get("X-PCO-API-Request-Rate-Count") != "3" || s.Requests() != 4 {
+		t.Fatalf("rate count %s, %d requests", resp.Header.Get("X-PCO-API-Request-Rate-Count"), s.Requests())
+	}
+}
+
+func TestServer_EmitsWebhooks(t *testing.T) {
+	t.Parallel()
+	q := webhook.NewQueue(time.Hour)
+	receiver := httptest.NewServer(webhook.NewHandler(q, webhook.Options{Secrets: []string{"hook"}}))
+	defer receiver.Close()
+	s := Start(Options{Webhooks: []string{receiver.URL}, WebhookSecret: "hook"})
+
+	patch := `{"data":{"attributes":{"lyrics":"new"}}}`
+	if resp, _ := do(t, "PATCH", s.URL+"/services/v2/songs/102/arrangements/1003", patch, nil); resp.StatusCode != http.StatusOK {
+		t.Fatalf("PATCH: %d", resp.StatusCode)
+	}
+	if err := s.Update("103", "1004", func(a *Arrangement) { a.ChordChartKey = "E" }); err != nil {
+		t.Fatal(err)
+	}
+	s.Close() // waits for the deliveries
+	if q.Len() != 2 || q.Add(webhook.Target{SongID: "102", ArrangementID: "1003"}) || q.Add(webhook.Target{SongID: "103", ArrangementID: "1004"}) {
+		t.Fatalf("the receiver did not queue both arrangements (%d queued)", q.Len())
+	}
+}
+
+func TestLoadFixtures(t *testing.T) {
+	t.Parallel()
+	songs, err := LoadFixtures(Sample())
+	if err != nil || len(songs) != 3 || len(songs[0].Arrangements) != 2 {
+		t.Fatalf("sample fixtures: %d songs, %v", len(songs), err)
+	}
+	dup := fstest.MapFS{
+		"a.json": {Data: []byte(`{"id":"1","arrangements":[{"id":"10"}]}`)},
+		"b.json": {Data: []byte(`{"id":"2","arrangements":[{"id":"10"}]}`)},
+	}
+	if _, err := LoadFixtures(dup); err == nil || !strings.Contains(err.Error(), "arrangement 10 is also in a.json") {
+		t.Fatalf("duplicate arrangement: %v", err)
+	}
+	if _, err := LoadFixtures(fstest.MapFS{}); err == nil {
+		t.Fatalf("expected an error without fixtures")
+	}
+}
@@ -0,0 +1,310 @@
+package pcosync
+
+import (
+	"chordparser/internal/audit"
+	"chordparser/internal/backup"
+	"chordparser/internal/fetcher"
+	"chordparser/internal/langdetect"
+	"chordparser/internal/parser"
+	"chordparser/internal/pool"
+	"chordparser/internal/processor"
+	"chordparser/internal/state"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Options configures a sync.
+type Options struct {
+	// Client reads and writes the arrangements.
+	Client *fetcher.Client
+	// Archive receives a snapshot of every arrangement before it is written, and holds the
+	// rolled-back arrangements, which are skipped (see backup.Archive.Hold). It is required
+	// unless DryRun is set.
+	Archive *backup.Archive
+	// RunID names the run in the archive; empty means backup.NewRunID of the current time.
+	RunID string
+	// State, if set, skips arrangements whose updated_at and chord chart are unchanged since
+	// their last successful sync.
+	State *state.Store
+	// Workers is the number of arrangements synced at once (1 if 0 or less). The client's
+	// limiter paces their requests.
+	Workers int
+	// Language is the chart language: "auto" to detect it per chart, a code such as "nl", or
+	// empty for all languages (see parser.Options and processor.Options).
+	Language string
+	// Abbreviations also accepts abbreviated headers such as "V1", "PC" or "C:" (see
+	// parser.Options).
+	Abbreviations bool
+	// DryRun cleans the charts and reports what would change without writing anything.
+	DryRun bool
+	// Audit, if set, records every write to PCO and every failure (see internal/audit).
+	Audit *audit.Log
+}
+
+// Result is the outcome of syncing one arrangement.
+type Result struct {
+	SongID, ArrangementID string
+	// Lines is the number of chart lines the cleaning rewrote or removed; zero means the chart
+	// was already clean and nothing was written.
+	Lines int
+	// Rules names the cleaning rules that changed the chart (see processor.Changes).
+	Rules []string
+	// Written is set when the cleaned chart was written back to PCO.
+	Written bool
+	// Skipped is set when the arrangement was unchanged since its last sync (see Options.State).
+	Skipped bool
+	// Held is set when the arrangement was rolled back and is left alone until an operator
+	// releases it (see backup.Archive.Hold).
+	Held bool
+	Err  error
+}
+
+// Library syncs every arrangement of the library.
+func Library(ctx context.Context, opts Options) ([]Result, error) {
+	if err := check(opts); err != nil {
+		return nil, err
+	}
+	arrangements, err := opts.Client.Library(ctx, opts.Workers)
+	if err != nil {
+		return nil, err
+	}
+	return run(ctx, opts, arrangements), nil
+}
+
+// Song syncs one arrangement of a song, or all of its arrangements when arrangementID is empty.
+func Song(ctx context.Context, opts Options, songID, arrangementID string) ([]Result, error) {
+	if err := check(opts); err != nil {
+		return nil, err
+	}
+	var arrangements []fetcher.Arrangement
+	if arrangementID != "" {
+		a, err := opts.Client.Arrangement(ctx, songID, arrangementID)
+		if err != nil {
+			return nil, err
+		}
+		arrangements = append(arrangements, a)
+	} else {
+		var err error
+		if arrangements, err = opts.Client.Arrangements(ctx, songID); err != nil {
+			return nil, err
+		}
+	}
+	return run(ctx, opts, arrangements), nil
+}
+
+// check validates the options.
+func check(opts Options) error {
+	switch {
+	case opts.Client == nil:
+		return errors.New("pcosync: no PCO client")
+	case opts.Archive == nil && !opts.DryRun:
+		return errors.New("pcosync: a backup archive is required to write to PCO")
+	}
+	return nil
+}
+
+// run syncs the arrangements on the worker pool; results are in the order of arrangements.
+func run(ctx context.Context, opts Options, arrangements []fetcher.Arrangement) []Result {
+	if opts.RunID == "" {
+		opts.RunID = backup.NewRunID(time.Now())
+	}
+	return pool.Map(ctx, arrangements, pool.Options{Workers: opts.Workers}, func(ctx context.Context, a fetcher.Arrangement) Result {
+		return syncOne(ctx, opts, a)
+	}, func(a fetcher.Arrangement, err error) Result {
+		return Result{SongID: a.SongID, ArrangementID: a.ID, Err: err}
+	})
+}
+
+// syncOne cleans an arrangement's chord chart and, if that changed it, backs the arrangement up
+// and writes the cleaned chart back, on the condition that nobody changed it in the meantime.
+func syncOne(ctx context.Context, opts Options, a fetcher.Arrangement) Result {
+	res := Result{SongID: a.SongID, ArrangementID: a.ID}
+	if opts.Archive != nil {
+		held, err := opts.Archive.Held(a.ID)
+		if err != nil || held {
+			res.Held, res.Err = held, fail(opts, a, err)
+			return res
+		}
+	}
+	src := state.Source{UpdatedAt: a.UpdatedAt, OriginalHash: state.Hash([]byte(a.ChordChart)), OptionsHash: optionsHash(opts)}
+	if opts.State != nil && !opts.DryRun {
+		changed, err := opts.State.Changed(a.ID, src)
+		if err != nil {
+			res.Err = fail(opts, a, err)
+			return res
+		}
+		if !changed {
+			res.Skipped = true
+			return res
+		}
+		if err := opts.State.Begin(a.ID, src); err != nil {
+			res.Err = fail(opts, a, err)
+			return res
+		}
+	}
+
+	cleaned, changes := Clean(a.ChordChart, opts)
+	res.Lines, res.Rules = changes.Lines, changes.Rules
+	var stateErr error
+	if changes.Lines > 0 && !opts.DryRun {
+		var updated time.Time
+		updated, res.Err = write(ctx, opts, a, cleaned, changes.Rules)
+		res.Written = !updated.IsZero()
+		if res.Written && opts.State != nil {
+			// The write changed updated_at; the next run compares against the written version
+			stateErr = opts.State.Written(a.ID, updated, state.Hash([]byte(cleaned)))
+		}
+	}
+
+	if opts.State != nil && !opts.DryRun {
+		stateErr = errors.Join(stateErr, opts.State.Finish(a.ID, state.Hash([]byte(cleaned)), res.Err))
+	}
+	if stateErr != nil {
+		// The PATCH, if any, is audited already; the state store failing is a failure of its own
+		if err := fail(opts, a, stateErr); res.Err == nil {
+			res.Err = err
+		}
+	}
+	return res
+}
+
+// fail audits a sync that failed with err other than in its PATCH (see write), such as the
+// backup archive or state store failing, and returns err. Arrangements left alone because they
+// are held, unchanged or clean are not audited, and neither are dry runs.
+func fail(opts Options, a fetcher.Arrangement, err error) error {
+	if err == nil || opts.DryRun {
+		return err
+	}
+	_ = record(opts.Audit, audit.Entry{
+		Action: "sync", SongID: a.SongID, ArrangementID: a.ID,
+		BeforeHash: state.Hash([]byte(a.ChordChart)), Outcome: audit.Failed, Error: err.Error(),
+	})
+	return err
+}
+
+// Clean cleans a chord chart with minimal changes (see processor.CleanTree) and returns the
+// cleaned chart and what changed.
+func Clean(chart string, opts Options) (string, processor.Changes) {
+	language := opts.Language
+	if language == "auto" {
+		language = langdetect.Detect(chart)
+	}
+	tree := parser.ParseTree(chart, parser.Options{Language: language, Abbreviations: opts.Abbreviations})
+	changes := processor.CleanTree(tree, processor.Options{Language: language})
+	return tree.String(), changes
+}
+
+// optionsHash identifies the cleaning options, so that changing them cleans every arrangement
+// again (see state.Source).
+func optionsHash(opts Options) string {
+	return state.Hash(fmt.Appendf(nil, "language=%s abbreviations=%t", opts.Language, opts.Abbreviations))
+}
+
+// write backs up an arrangement, patches its chord chart, records the change as applied and
+// audits the PATCH; a failed backup is audited as a PATCH that was never sent. It returns the
+// arrangement's new updated_at, zero if it was not written.
+func write(ctx context.Context, opts Options, a fetcher.Arrangement, cleaned string, rules []string) (time.Time, error) {
+	var updated time.Time
+	err := opts.Archive.Save(backup.Snapshot{
+		RunID: opts.RunID, SongID: a.SongID, ArrangementID: a.ID,
+		ChordChart: a.ChordChart, Sequence: a.Sequence, Lyrics: a.Lyrics, UpdatedAt: a.UpdatedAt,
+	})
+	if err == nil {
+		fields := a.Fields()
+		fields.ChordChart = cleaned
+		updated, err = opts.Client.Patch(ctx, a.SongID, a.ID, fields, a.UpdatedAt)
+	}
+	e := entry("patch", a.SongID, a.ID, err)
+	e.Rules, e.BeforeHash, e.AfterHash = rules, state.Hash([]byte(a.ChordChart)), state.Hash([]byte(cleaned))
+	if auditErr := record(opts.Audit, e); err == nil {
+		err = auditErr
+	}
+	if updated.IsZero() {
+		return time.Time{}, err
+	}
+	return updated, errors.Join(err, opts.Archive.Applied(opts.RunID, a.ID, updated))
+}
+
+// Rollback restores the selected arrangements from the archive (see backup.Rollback). The
+// restores are backed up as run opts.RunID, so the rollback can be rolled back in turn, and
+// audited as "rollback" entries. The restored arrangements are held until released.
+func Rollback(ctx context.Context, opts Options, sel backup.Selection) ([]backup.Restore, error) {
+	if opts.Client == nil || opts.Archive == nil {
+		return nil, errors.New("pcosync: rolling back needs a PCO client and a backup archive")
+	}
+	if opts.RunID == "" {
+		opts.RunID = backup.NewRunID(time.Now())
+	}
+	restores, err := backup.Rollback(ctx, opts.Archive, sel, opts.Archive.Writer(opts.Client, opts.RunID))
+	for i, r := range restores {
+		if r.Skipped {
+			continue
+		}
+		e := entry("rollback", r.Snapshot.SongID, r.Snapshot.ArrangementID, r.Err)
+		e.AfterHash = state.Hash([]byte(r.Snapshot.ChordChart))
+		// The rollback's own snapshot holds the chart it replaced, if it got that far
+		if snaps, err := opts.Archive.Snapshots(backup.Selection{RunID: opts.RunID, ArrangementID: r.Snapshot.ArrangementID}); err == nil && len(snaps) > 0 {
+			e.BeforeHash = state.Hash([]byte(snaps[0].ChordChart))
+		}
+		if auditErr := record(opts.Audit, e); r.Err == nil {
+			restores[i].Err = auditErr
+		}
+	}
+	return restores, err
+}
+
+// entry returns the audit entry of a write that ended with err.
+func entry(action, songID, arrangementID string, err error) audit.Entry {
+	e := audit.Entry{
+		Action: action, SongID: songID, ArrangementID: arrangementID,
+		HTTPStatus: http.StatusOK, Outcome: audit.Succeeded,
+	}
+	if err != nil {
+		e.HTTPStatus, e.Outcome, e.Error = fetcher.StatusCode(err), audit.Failed, err.Error()
+		if errors.Is(err, backup.ErrConflict) {
+			e.Outcome = audit.Conflict
+		}
+	}
+	return e
+}
+
+// record appends e to the log, if there is one.
+func record(log *audit.Log, e audit.Entry) error {
+	if log == nil {
+		return nil
+	}
+	return log.Record(e)
+}
+
+// WriteSummary writes one line per arrangement and a total, and returns the number of failures.
+func WriteSummary(w io.Writer, results []Result) int {
+	failed, written, skipped, held := 0, 0, 0, 0
+	for _, r := range results {
+		name := fmt.Sprintf("song %s arrangement %s", r.SongID, r.ArrangementID)
+		switch {
+		case r.Err != nil:
+			failed++
+			fmt.Fprintf(w, "FAIL %s: %v\n", name, r.Err)
+		case r.Held:
+			held++
+			fmt.Fprintf(w, "held %s (rolled back; release with: cli rollback -release %s)\n", name, r.ArrangementID)
+		case r.Skipped:
+			skipped++
+			fmt.Fprintf(w, "skip %s (unchanged)\n", name)
+		case r.Written:
+			written++
+			fmt.Fprintf(w, "ok   %s: %d lines cleaned and written (%s)\n", name, r.Lines, strings.Join(r.Rules, ", "))
+		case r.Lines > 0:
+			fmt.Fprintf(w, "dry  %s: %d lines would be cleaned (%s)\n", name, r.Lines, strings.Join(r.Rules, ", "))
+		default:
+			fmt.Fprintf(w, "ok   %s: already clean\n", name)
+		}
+	}
+	fmt.Fprintf(w, "%d arrangements, %d written, %d skipped, %d held, %d failed\n", len(results), written, skipped, held, failed)
+	return failed
+}
@@ -0,0 +1,321 @@
+package pcosync
+
+import (
+	"bytes"
+	"chordparser/internal/audit"
+	"chordparser/internal/backup"
+	"chordparser/internal/fetcher"
+	"chordparser/internal/pcofake"
+	"chordparser/internal/processor"
+	"chordparser/internal/state"
+	"context"
+	"errors"
+	"net/http"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// editBefore is a transport that lets someone edit an arrangement in PCO right before the
+// client reads it on its own (as Patch does before writing).
+type editBefore struct {
+	srv        *pcofake.Server
+	songID, id string
+	once       sync.Once
+}
+
+func (e *editBefore) RoundTrip(r *http.Request) (*http.Response, error) {
+	if r.Method == http.MethodGet && strings.HasSuffix(r.URL.Path, "/arrangements/"+e.id) {
+		e.once.Do(func() {
+			_ = e.srv.Update(e.songID, e.id, func(a *pcofake.Arrangement) { a.ChordChart += "Edited in PCO\n" })
+		})
+	}
+	return http.DefaultTransport.RoundTrip(r)
+}
+
+func openArchive(t *testing.T) *backup.Archive {
+	t.Helper()
+	a, err := backup.Open(t.TempDir())
+	if err != nil {
+		t.Fatalf("backup.Open: %v", err)
+	}
+	return a
+}
+
+func TestLibrary_CleansBacksUpAndWrites(t *testing.T) {
+	t.Parallel()
+	srv := pcofake.Start(pcofake.Options{RateLimit: -1, Songs: []pcofake.Song{
+		{ID: "1", Title: "One", Arrangements: []pcofake.Arrangement{
+			{ID: "10", ChordChart: "Verse 1\nLa la (x2)\n\n\nChorus\nG  C\n", Sequence: []string{"Verse 1", "Chorus"}},
+			{ID: "11", ChordChart: "Verse\nLi li x3\n"},
+		}},
+		{ID: "2", Title: "Two", Arrangements: []pcofake.Arrangement{{ID: "20", ChordChart: "Verse\n[G]Clean\n"}}},
+	}})
+	defer srv.Close()
+	original, _ := srv.Arrangement("1", "10")
+	archive := openArchive(t)
+	// Arrangement 11 is edited in PCO between the listing and the write
+	client := fetcher.New(fetcher.Options{BaseURL: srv.URL, HTTPClient: &http.Client{Transport: &editBefore{srv: srv, songID: "1", id: "11"}}})
+	logPath := filepath.Join(t.TempDir(), "audit.jsonl")
+	log, err := audit.Open(logPath, "tester")
+	if err != nil {
+		t.Fatalf("audit.Open: %v", err)
+	}
+	defer log.Close()
+	opts := Options{Client: client, Archive: archive, RunID: "run1", Workers: 2, Audit: log}
+
+	results, err := Library(context.Background(), opts)
+	if err != nil {
+		t.Fatalf("Library: %v", err)
+	}
+	if len(results) != 3 || results[0].ArrangementID != "10" || results[2].ArrangementID != "20" {
+		t.Fatalf("results = %+v", results)
+	}
+	rules := []string{processor.RuleRepeats, processor.RuleNakedChords, processor.RuleBlankLines}
+	if r := results[0]; r.Err != nil || !r.Written || r.Lines != 3 || !reflect.DeepEqual(r.Rules, rules) {
+		t.Fatalf("arrangement 10: %+v", r)
+	}
+	if r := results[1]; !errors.Is(r.Err, backup.ErrConflict) || r.Written {
+		t.Fatalf("edited arrangement 11: %+v, want a conflict", r)
+	}
+	if r := results[2]; r.Err != nil || r.Written || r.Lines != 0 {
+		t.Fatalf("clean arrangement 20: %+v", r)
+	}
+
+	// The written chart is the cleaned one, and its original is in the archive
+	got, _ := srv.Arrangement("1", "10")
+	if got.ChordChart != "Verse 1\nLa la\n\nChorus\n[G] [C]\n" || len(got.Sequence) != 2 {
+		t.Fatalf("arrangement 10 after the sync: %+v", got)
+	}
+	snaps, err := archive.Snapshots(backup.Selection{RunID: "run1", ArrangementID: "10"})
+	if err != nil || len(snaps) != 1 || snaps[0].ChordChart != original.ChordChart || !snaps[0].AppliedAt.Equal(got.UpdatedAt) {
+		t.Fatalf("snapshot = %+v, %v", snaps, err)
+	}
+	// The conflicting arrangement was backed up but never applied, and kept its edit
+	if snaps, _ := archive.Snapshots(backup.Selection{ArrangementID: "11"}); len(snaps) != 1 || !snaps[0].AppliedAt.IsZero() {
+		t.Fatalf("conflict snapshot = %+v", snaps)
+	}
+	if a, _ := srv.Arrangement("1", "11"); !strings.HasSuffix(a.ChordChart, "Edited in PCO\n") {
+		t.Fatalf("edited arrangement was overwritten: %q", a.ChordChart)
+	}
+	if snaps, _ := archive.Snapshots(backup.Selection{ArrangementID: "20"}); len(snaps) != 0 {
+		t.Fatalf("a clean arrangement is not written, so not backed up: %+v", snaps)
+	}
+
+	// Rolling the run back restores the originals
+	restores, err := Rollback(context.Background(), Options{Client: client, Archive: archive, RunID: "undo1", Audit: log}, backup.Selection{RunID: "run1"})
+	if err != nil {
+		t.Fatalf("Rollback: %v", err)
+	}
+	for _, r := range restores {
+		if r.Err != nil {
+			t.Fatalf("restore %s: %v", r.Snapshot.ArrangementID, r.Err)
+		}
+	}
+	if a, _ := srv.Arrangement("1", "10"); a.ChordChart != original.ChordChart {
+		t.Fatalf("rollback gave %q, want %q", a.ChordChart, original.ChordChart)
+	}
+	// ... and backs up what it replaced, so it can be undone too
+	if snaps, _ := archive.Snapshots(backup.Selection{RunID: "undo1"}); len(snaps) != 1 || snaps[0].ChordChart != got.ChordChart || snaps[0].AppliedAt.IsZero() {
+		t.Fatalf("rollback snapshot = %+v", snaps)
+	}
+
+	// Every write is audited: the two PATCHes (in any order) and the rollback, but not the clean
+	// arrangement
+	entries, err := audit.QueryFile(logPath, audit.Filter{})
+	if err != nil || len(entries) != 3 {
+		t.Fatalf("audit entries = %+v, %v", entries, err)
+	}
+	byOutcome := map[string]audit.Entry{}
+	for _, e := range entries {
+		byOutcome[e.Action+" "+e.Outcome] = e
+		if e.Operator != "tester" {
+			t.Fatalf("operator = %q", e.Operator)
+		}
+	}
+	cleaned, undone := byOutcome["patch succeeded"], byOutcome["rollback succeeded"]
+	if cleaned.ArrangementID != "10" || !reflect.DeepEqual(cleaned.Rules, rules) || cleaned.HTTPStatus != http.StatusOK ||
+		cleaned.BeforeHash != state.Hash([]byte(original.ChordChart)) || cleaned.AfterHash != state.Hash([]byte(got.ChordChart)) {
+		t.Fatalf("patch entry = %+v", cleaned)
+	}
+	if conflict := byOutcome["patch conflict"]; conflict.ArrangementID != "11" || conflict.Error == "" {
+		t.Fatalf("conflict entry = %+v", conflict)
+	}
+	if undone.ArrangementID != "10" || undone.BeforeHash != cleaned.AfterHash || undone.AfterHash != cleaned.BeforeHash {
+		t.Fatalf("rollback entry = %+v", undone)
+	}
+}
+
+func TestLibrary_AuditsWritesAndFailures(t *testing.T) {
+	t.Parallel()
+	srv := pcofake.Start(pcofake.Options{RateLimit: -1, Songs: []pcofake.Song{
+		{ID: "1", Title: "One", Arrangements: []pcofake.Arrangement{
+			{ID: "10", ChordChart: "Verse\nLa la (x2)\n"},
+			{ID: "11", ChordChart: "Verse\n[G]Clean\n"},
+		}},
+	}})
+	defer srv.Close()
+	client := fetcher.New(fetcher.Options{BaseURL: srv.URL})
+	archive := openArchive(t)
+	st, err := state.Open(filepath.Join(t.TempDir(), "state.db"))
+	if err != nil {
+		t.Fatalf("state.Open: %v", err)
+	}
+	logPath := filepath.Join(t.TempDir(), "audit.jsonl")
+	log, err := audit.Open(logPath, "tester")
+	if err != nil {
+		t.Fatalf("audit.Open: %v", err)
+	}
+	defer log.Close()
+
+	// Each step syncs the library and lists the new entries as "arrangement action outcome"
+	seen := 0
+	step := func(runID string) []string {
+		t.Helper()
+		if _, err := Library(context.Background(), Options{Client: client, Archive: archive, State: st, Audit: log, RunID: runID}); err != nil {
+			t.Fatalf("Library: %v", err)
+		}
+		entries, err := audit.QueryFile(logPath, audit.Filter{})
+		if err != nil {
+			t.Fatalf("audit.QueryFile: %v", err)
+		}
+		var got []string
+		for _, e := range entries[seen:] {
+			s := e.ArrangementID + " " + e.Action + " " + e.Outcome
+			if e.Outcome == audit.Failed && (e.Error == "" || e.HTTPStatus != 0) {
+				t.Fatalf("failed entry without an error, or with a request that was never sent: %+v", e)
+			}
+			got = append(got, s)
+		}
+		seen = len(entries)
+		sort.Strings(got)
+		return got
+	}
+	edit := func() {
+		_ = srv.Update("1", "10", func(a *pcofake.Arrangement) { a.ChordChart += "Li li x3\n" })
+	}
+
+	steps := []struct {
+		name   string
+		before func()
+		runID  string
+		want   []string
+	}{
+		// Arrangement 11 is clean, then unchanged, then held: it is never audited
+		{"first run", nil, "r1", []string{"10 patch succeeded"}},
+		{"nothing changed", nil, "r2", nil},
+		// The run already has a snapshot of arrangement 10, so its backup fails before the PATCH
+		{"backup fails", edit, "r1", []string{"10 patch failed"}},
+		{"held", func() { _ = archive.Hold(backup.Hold{SongID: "1", ArrangementID: "11"}) }, "r4", []string{"10 patch succeeded"}},
+		{"state store fails", func() { edit(); st.Close() }, "r5", []string{"10 sync failed"}},
+	}
+	for _, s := range steps {
+		if s.before != nil {
+			s.before()
+		}
+		if got := step(s.runID); !reflect.DeepEqual(got, s.want) {
+			t.Fatalf("%s: audit entries %q, want %q", s.name, got, s.want)
+		}
+	}
+
+	// A failing audit log fails the write it could not record, but never an arrangement left alone
+	log.Close()
+	results, err := Library(context.Background(), Options{Client: client, Archive: archive, Audit: log, RunID: "r6"})
+	if err != nil || len(results) != 2 {
+		t.Fatalf("Library: %+v, %v", results, err)
+	}
+	if r := results[0]; !r.Written || r.Err == nil {
+		t.Fatalf("unaudited write = %+v", r)
+	}
+	if r := results[1]; !r.Held || r.Err != nil {
+		t.Fatalf("held arrangement = %+v", r)
+	}
+}
+
+func TestSong_DryRunAndState(t *testing.T) {
+	t.Parallel()
+	srv := pcofake.Start(pcofake.Options{RateLimit: -1})
+	defer srv.Close()
+	client := fetcher.New(fetcher.Options{BaseURL: srv.URL})
+	original, _ := srv.Arrangement("101", "1001")
+
+	results, err := Song(context.Background(), Options{Client: client, DryRun: true}, "101", "1001")
+	if err != nil || len(results) != 1 || results[0].Lines == 0 || results[0].Written {
+		t.Fatalf("dry run = %+v, %v", results, err)
+	}
+	if a, _ := srv.Arrangement("101", "1001"); a.ChordChart != original.ChordChart {
+		t.Fatalf("dry run wrote the arrangement: %q", a.ChordChart)
+	}
+	if _, err := Song(context.Background(), Options{Client: client}, "101", ""); err == nil {
+		t.Fatalf("writing without a backup archive should fail")
+	}
+
+	st, err := state.Open(filepath.Join(t.TempDir(), "state.db"))
+	if err != nil {
+		t.Fatalf("state.Open: %v", err)
+	}
+	defer st.Close()
+	opts := Options{Client: client, Archive: openArchive(t), State: st}
+	// The first run writes; the next one skips the written version, unless the cleaning
+	// options change
+	for i, step := range []struct {
+		language string
+		want     Result
+	}{{"", Result{Written: true}}, {"", Result{Skipped: true}}, {"en", Result{}}, {"en", Result{Skipped: true}}} {
+		opts.Language = step.language
+		want := step.want
+		results, err := Song(context.Background(), opts, "101", "")
+		if err != nil || len(results) != 2 {
+			t.Fatalf("run %d = %+v, %v", i, results, err)
+		}
+		if r := results[0]; r.Err != nil || r.Written != want.Written || r.Skipped != want.Skipped {
+			t.Fatalf("run %d: %+v, want %+v", i, r, want)
+		}
+	}
+
+	var buf bytes.Buffer
+	if failed := WriteSummary(&buf, results); failed != 0 || !strings.Contains(buf.String(), "dry  song 101 arrangement 1001") {
+		t.Fatalf("summary:\n%s", buf.String())
+	}
+}
+
+func TestRollback_HoldsUntilReleased(t *testing.T) {
+	t.Parallel()
+	srv := pcofake.Start(pcofake.Options{RateLimit: -1})
+	defer srv.Close()
+	client := fetcher.New(fetcher.Options{BaseURL: srv.URL})
+	archive := openArchive(t)
+	original, _ := srv.Arrangement("101", "1001")
+	ctx := context.Background()
+
+	if results, err := Song(ctx, Options{Client: client, Archive: archive, RunID: "run1"}, "101", "1001"); err != nil || !results[0].Written {
+		t.Fatalf("sync = %+v, %v", results, err)
+	}
+	restores, err := Rollback(ctx, Options{Client: client, Archive: archive, RunID: "undo1"}, backup.Selection{RunID: "run1"})
+	if err != nil || len(restores) != 1 || restores[0].Err != nil {
+		t.Fatalf("Rollback = %+v, %v", restores, err)
+	}
+
+	// The rollback's own write (and its webhook) must not get the chart cleaned again
+	results, err := Song(ctx, Options{Client: client, Archive: archive, RunID: "run2"}, "101", "")
+	if err != nil || len(results) != 2 || !results[0].Held || results[0].Written || results[1].Held {
+		t.Fatalf("sync after the rollback = %+v, %v", results, err)
+	}
+	if a, _ := srv.Arrangement("101", "1001"); a.ChordChart != original.ChordChart {
+		t.Fatalf("the rolled-back chart was cleaned again: %q", a.ChordChart)
+	}
+	var buf bytes.Buffer
+	if WriteSummary(&buf, results); !strings.Contains(buf.String(), "held song 101 arrangement 1001") {
+		t.Fatalf("summary:\n%s", buf.String())
+	}
+
+	if err := archive.Release("1001"); err != nil {
+		t.Fatalf("Release: %v", err)
+	}
+	if results, err := Song(ctx, Options{Client: client, Archive: archive, RunID: "run3"}, "101", "1001"); err != nil || !results[0].Written {
+		t.Fatalf("sync after the release = %+v, %v", results, err)
+	}
+}
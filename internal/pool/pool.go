@@ -0,0 +1,65 @@
+package pool
+
+import (
+	"context"
+	"sync"
+)
+
+// Limiter paces jobs, e.g. a *rate.Limiter so that all workers together stay within a rate
+// limit. fetcher.Client takes the same interface to pace each API request instead.
+type Limiter interface {
+	Wait(ctx context.Context) error
+}
+
+// Options configures a pool run.
+type Options struct {
+	// Workers is the maximum number of jobs running at once (1 if 0 or less).
+	Workers int
+	// Limiter, if set, is waited on before each job starts.
+	Limiter Limiter
+}
+
+// Map runs fn for every item on a bounded pool of workers and returns the results
+// in the order of items, regardless of the order in which jobs finish.
+// When ctx is cancelled, running jobs see the cancelled context and jobs that have not
+// started yet are skipped: their result is canceled(item, err) instead.
+func Map[T, R any](ctx context.Context, items []T, opts Options, fn func(context.Context, T) R, canceled func(T, error) R) []R {
+	workers := max(opts.Workers, 1)
+	workers = min(workers, max(len(items), 1))
+
+	results := make([]R, len(items))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] = run(ctx, items[i], opts.Limiter, fn, canceled)
+			}
+		}()
+	}
+
+	for i := range items {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return results
+}
+
+// run runs one job unless the context is done (before or while waiting for the limiter).
+func run[T, R any](ctx context.Context, item T, limiter Limiter, fn func(context.Context, T) R, canceled func(T, error) R) R {
+	if err := ctx.Err(); err != nil {
+		return canceled(item, err)
+	}
+	if limiter != nil {
+		if err := limiter.Wait(ctx); err != nil {
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				err = ctxErr
+			}
+			return canceled(item, err)
+		}
+	}
+	return fn(ctx, item)
+}
@@ -0,0 +1,100 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+func TestMap_DeterministicOrder(t *testing.T) {
+	t.Parallel()
+	items := make([]int, 200)
+	for i := range items {
+		items[i] = i
+	}
+	got := Map(context.Background(), items, Options{Workers: 8}, func(_ context.Context, n int) int {
+		// Finish out of order
+		time.Sleep(time.Duration(n%5) * 100 * time.Microsecond)
+		return n * n
+	}, func(int, error) int { return -1 })
+	for i, r := range got {
+		if r != i*i {
+			t.Fatalf("result %d = %d, want %d", i, r, i*i)
+		}
+	}
+}
+
+func TestMap_BoundedWorkers(t *testing.T) {
+	t.Parallel()
+	var running, peak atomic.Int32
+	items := make([]int, 50)
+	Map(context.Background(), items, Options{Workers: 3}, func(_ context.Context, _ int) struct{} {
+		n := running.Add(1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(time.Millisecond)
+		running.Add(-1)
+		return struct{}{}
+	}, func(int, error) struct{} { return struct{}{} })
+	if p := peak.Load(); p > 3 || p == 0 {
+		t.Fatalf("peak concurrency = %d, want 1..3", p)
+	}
+}
+
+func TestMap_Cancellation(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var once sync.Once
+	items := make([]int, 100)
+	for i := range items {
+		items[i] = i
+	}
+	got := Map(ctx, items, Options{Workers: 2}, func(_ context.Context, n int) error {
+		if n == 10 {
+			once.Do(cancel)
+		}
+		return nil
+	}, func(_ int, err error) error { return err })
+
+	if got[0] != nil {
+		t.Fatalf("first job should have run, got %v", got[0])
+	}
+	if !errors.Is(got[len(got)-1], context.Canceled) {
+		t.Fatalf("last job should be canceled, got %v", got[len(got)-1])
+	}
+}
+
+func TestMap_SharedLimiter(t *testing.T) {
+	t.Parallel()
+	// 1 token up front, then one every 5ms: 5 jobs take at least 20ms in total
+	limiter := rate.NewLimiter(rate.Every(5*time.Millisecond), 1)
+	start := time.Now()
+	Map(context.Background(), make([]int, 5), Options{Workers: 5, Limiter: limiter}, func(context.Context, int) int { return 0 },
+		func(int, error) int { return 0 })
+	if d := time.Since(start); d < 20*time.Millisecond {
+		t.Fatalf("limiter not shared across workers: 5 jobs took %v", d)
+	}
+}
+
+func TestMap_LimiterCancelled(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	limiter := rate.NewLimiter(rate.Every(time.Hour), 0)
+	got := Map(ctx, []int{1}, Options{Limiter: limiter}, func(context.Context, int) error { return nil },
+		func(_ int, err error) error { return err })
+	if !errors.Is(got[0], context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", got[0])
+	}
+}
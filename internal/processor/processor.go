@@ -1,15 +1,16 @@
 package processor
 
 import (
+	"chordparser/internal/normalize"
+	"chordparser/internal/parser"
 	"regexp"
+	"sort"
 	"strings"
 )
 
 var (
 	// Remove trailing parenthetical repeats like "(x2)" or "( 3x )" at end of line
 	reParenRepeatEnd = regexp.MustCompile(`\s*\(\s*(?:\d+\s*[x×]|[x×]\s*\d+)\s*\)\s*$`)
-	// Remove trailing parenthetical directives to sections, e.g., "(To Chorus)", "(naar refrein)" at end of line
-	reToRefEnd = regexp.MustCompile(`(?i)\s*\(\s*(?:to|naar)\b[^)]*\)\s*$`)
 	// Remove standalone repeat tokens like "x3", "3x", "×2" anywhere
 	reRepeatToken = regexp.MustCompile(`(?:^|\s)(?:\d+\s*[x×]|[x×]\s*\d+)(?:\s|$)`)
 	// Collapse multiple spaces
@@ -18,55 +19,158 @@ var (
 	reChord = regexp.MustCompile(`^(?:[A-G](?:#|b)?(?:(?:maj|min|m|dim|aug|sus)\d*|\d*)?(?:/[A-G](?:#|b)?)?)$`)
 )
 
+// Trailing parenthetical directives to sections per language (ISO 639-1 code), removed at end of line.
+// English and Dutch accept any target, e.g., "(To Chorus)", "(naar refrein)"; the other languages
+// require a section keyword after the preposition since their prepositions also start plain lyrics.
+var directivesByLanguage = map[string]*regexp.Regexp{
+	"en": regexp.MustCompile(`(?i)\s*\(\s*to\b[^)]*\)\s*$`),
+	"nl": regexp.MustCompile(`(?i)\s*\(\s*naar\b[^)]*\)\s*$`),
+	// e.g., "(zum Refrain)", "(zur Strophe 2)", "(nach Bridge)"
+	"de": regexp.MustCompile(`(?i)\s*\(\s*(?:zu[mr]?|nach)\s+(?:(?:der|dem|den)\s+)?(?:strophe|refrain|bridge|intro|outro|zwischenspiel|vorspiel|nachspiel|schluss|schluß)(?:\s[^)]*)?\)\s*$`),
+	// e.g., "(au refrain)", "(vers le pont)"
+	"fr": regexp.MustCompile(`(?i)\s*\(\s*(?:au|aux|à|vers)\s+(?:(?:le|la|l')\s*)?(?:couplet|refrain|pont|pré-?refrain|intro|outro|interlude)(?:\s[^)]*)?\)\s*$`),
+	// e.g., "(al coro)", "(a la estrofa 2)"
+	"es": regexp.MustCompile(`(?i)\s*\(\s*(?:al|a)\s+(?:(?:la|el)\s+)?(?:verso|estrofa|coro|estribillo|pre-?coro|puente|intro|interludio)(?:\s[^)]*)?\)\s*$`),
+	// e.g., "(ao refrão)", "(para a ponte)"
+	"pt": regexp.MustCompile(`(?i)\s*\(\s*(?:ao|à|para)\s+(?:(?:o|a)\s+)?(?:verso|estrofe|refrão|refrao|pré-?refrão|ponte|intro)(?:\s[^)]*)?\)\s*$`),
+}
+
+// Options controls optional cleaning behaviour.
+type Options struct {
+	// Language restricts the section directives that are removed to a single language
+	// ("en", "nl", "de", "fr", "es", "pt"). Empty means directives of all languages are removed.
+	Language string
+	// Folds selects the Unicode and typography folds applied before cleaning.
+	// Nil means normalize.DefaultFolds.
+	Folds *normalize.Folds
+}
+
 // CleanText normalizes a song text by removing repeat notations, trailing section directives,
 // wrapping naked chord-only lines in brackets, and collapsing multiple blank lines.
 func CleanText(in string) string {
-	lines := strings.Split(in, "\n")
-
-	out := make([]string, 0, len(lines))
-	prevBlank := false
-
-	for _, line := range lines {
-		s := line
+	return CleanTextWith(in, Options{})
+}
 
-		// Remove trailing "(To ...)" or "(naar ...)" parentheticals and repeat markers
-		s = reToRefEnd.ReplaceAllString(s, "")
-		s = reParenRepeatEnd.ReplaceAllString(s, "")
+// CleanTextWith is like CleanText but applies the given options.
+func CleanTextWith(in string, opts Options) string {
+	out, _ := cleanLines(strings.Split(in, "\n"), opts)
+	// Ensure exactly one trailing newline
+	return strings.Join(out, "\n") + "\n"
+}
 
-		// Remove standalone repeat tokens like "x3", "3x", "×2"
-		s = reRepeatToken.ReplaceAllStringFunc(s, func(m string) string {
-			// keep a single leading space if there was one
-			if strings.HasPrefix(m, " ") || strings.HasPrefix(m, "\t") {
-				return " "
-			}
-			return ""
-		})
+// cleanLines cleans lines one at a time and collapses blank lines. Besides the kept lines it
+// returns, for each of them, the index of the input line it came from.
+func cleanLines(lines []string, opts Options) (out []string, from []int) {
+	directives := directivesFor(opts.Language)
+	folds := normalize.FoldsOrDefault(opts.Folds)
 
-		// Tidy spaces
-		s = strings.TrimSpace(reMultiSpaces.ReplaceAllString(s, " "))
+	out = make([]string, 0, len(lines))
+	from = make([]int, 0, len(lines))
+	prevBlank := false
 
-		// Wrap chords on chord-only lines
-		s = wrapChordsIfChordLine(s)
+	for i, line := range lines {
+		s, _ := cleanLine(normalize.Fold(line, folds), directives)
 
 		// Collapse multiple blank lines
 		if s == "" {
 			if !prevBlank && (len(out) == 0 || out[len(out)-1] != "") {
 				out = append(out, "")
+				from = append(from, i)
 			}
 			prevBlank = true
 		} else {
 			out = append(out, s)
+			from = append(from, i)
 			prevBlank = false
 		}
 	}
 
 	// Remove trailing blank lines
 	for len(out) > 0 && out[len(out)-1] == "" {
-		out = out[:len(out)-1]
+		out, from = out[:len(out)-1], from[:len(from)-1]
 	}
+	return out, from
+}
 
-	// Ensure exactly one trailing newline
-	return strings.Join(out, "\n") + "\n"
+// cleanLine applies rules 1 to 3 to a folded line and tidies its spaces. It also reports which
+// rules changed the line, as opposed to only its spacing.
+func cleanLine(s string, directives []*regexp.Regexp) (string, rules) {
+	var applied rules
+
+	// Remove trailing "(To ...)" or "(naar ...)" parentheticals and repeat markers
+	orig := s
+	for _, re := range directives {
+		s = re.ReplaceAllString(s, "")
+	}
+	if s != orig {
+		applied |= ruleDirectives
+	}
+	orig = s
+	s = reParenRepeatEnd.ReplaceAllString(s, "")
+
+	// Remove standalone repeat tokens like "x3", "3x", "×2"
+	s = reRepeatToken.ReplaceAllStringFunc(s, func(m string) string {
+		// keep a single leading space if there was one
+		if strings.HasPrefix(m, " ") || strings.HasPrefix(m, "\t") {
+			return " "
+		}
+		return ""
+	})
+	if s != orig {
+		applied |= ruleRepeats
+	}
+
+	// Tidy spaces
+	s = strings.TrimSpace(reMultiSpaces.ReplaceAllString(s, " "))
+
+	// Wrap chords on chord-only lines
+	wrapped := wrapChordsIfChordLine(s)
+	if wrapped != s {
+		applied |= ruleNakedChords
+	}
+	return wrapped, applied
+}
+
+// directivesFor returns the directive patterns for a language, or those of all
+// languages (in a stable order) if the language is empty or not supported.
+func directivesFor(lang string) []*regexp.Regexp {
+	if re, ok := directivesByLanguage[lang]; ok {
+		return []*regexp.Regexp{re}
+	}
+	langs := make([]string, 0, len(directivesByLanguage))
+	for l := range directivesByLanguage {
+		langs = append(langs, l)
+	}
+	sort.Strings(langs)
+	out := make([]*regexp.Regexp, 0, len(langs))
+	for _, l := range langs {
+		out = append(out, directivesByLanguage[l])
+	}
+	return out
+}
+
+// CleanSections applies CleanTextWith to the content of every section.
+// Headers and positions are kept: every remaining line keeps the source position of the line
+// it was cleaned from. Sections left without content keep an empty content list.
+func CleanSections(sections []parser.Section, opts Options) []parser.Section {
+	out := make([]parser.Section, 0, len(sections))
+	for _, s := range sections {
+		content, from := cleanLines(s.Content, opts)
+		var spans []parser.Span
+		if len(s.Lines) == len(s.Content) && len(content) > 0 {
+			spans = make([]parser.Span, len(from))
+			for i, j := range from {
+				spans[i] = s.Lines[j]
+			}
+		}
+		out = append(out, parser.Section{Header: s.Header, Content: content, Pos: s.Pos, Lines: spans})
+	}
+	return out
+}
+
+// IsChord reports whether a token (without brackets) is a chord such as "G", "F#m7" or "D/F#".
+func IsChord(tok string) bool {
+	return reChord.MatchString(tok)
 }
 
 func wrapChordsIfChordLine(s string) string {
@@ -1,6 +1,10 @@
 package processor
 
-import "testing"
+import (
+	"chordparser/internal/parser"
+	"reflect"
+	"testing"
+)
 
 func TestRemoveRepeats(t *testing.T) {
 	t.Parallel()
@@ -253,3 +257,108 @@ func Test_wrapChordsIfChordLine_OnlyBarToken(t *testing.T) {
 		t.Fatalf("unexpected:\n--- in ---\n%q\n--- got ---\n%q\n--- want ---\n%q", in, got, want)
 	}
 }
+
+func TestRemoveToRefEnd_OtherLanguages(t *testing.T) {
+	t.Parallel()
+	in := "Zeile (zum Refrain)\nLigne (au refrain)\nLínea (al coro)\nLinha (para a ponte)\nKeep (nach Hause)\n"
+	want := "Zeile\nLigne\nLínea\nLinha\nKeep (nach Hause)\n"
+	got := CleanText(in)
+	if got != want {
+		t.Fatalf("unexpected:\n--- got ---\n%q\n--- want ---\n%q", got, want)
+	}
+}
+
+func TestCleanTextWith_LanguageRestrictsDirectives(t *testing.T) {
+	t.Parallel()
+	in := "Line end (To Chorus)\nAndere regel (naar refrein)\n"
+	want := "Line end (To Chorus)\nAndere regel\n"
+	got := CleanTextWith(in, Options{Language: "nl"})
+	if got != want {
+		t.Fatalf("unexpected:\n--- got ---\n%q\n--- want ---\n%q", got, want)
+	}
+}
+
+func TestWrapNakedChords_UnicodeAccidentalsAndSpaces(t *testing.T) {
+	t.Parallel()
+	in := "C♯m\u00a0B♭/D\u200b G\n"
+	want := "[C#m] [Bb/D] [G]\n"
+	got := CleanText(in)
+	if got != want {
+		t.Fatalf("unexpected:\n--- got ---\n%q\n--- want ---\n%q", got, want)
+	}
+}
+
+func TestCleanSections(t *testing.T) {
+	t.Parallel()
+	in := []parser.Section{
+		{Header: "VERSE 1", Content: []string{"C G", "Amazing grace (x2)", "", "", ""}},
+		{Header: "CHORUS", Content: []string{"", "(To Bridge)"}},
+	}
+	want := []parser.Section{
+		{Header: "VERSE 1", Content: []string{"[C] [G]", "Amazing grace"}},
+		{Header: "CHORUS", Content: []string{}},
+	}
+	got := CleanSections(in, Options{})
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected:\n--- got ---\n%#v\n--- want ---\n%#v", got, want)
+	}
+}
+
+func TestCleanSections_KeepsPositions(t *testing.T) {
+	t.Parallel()
+	in := parser.Parse("Verse\nC G\n\n\nAmazing grace x2\n(To Chorus)\n")
+	got := CleanSections(in, Options{})
+	want := []parser.Section{{
+		Header:  "VERSE",
+		Content: []string{"[C] [G]", "", "Amazing grace"},
+		Pos:     parser.Span{Line: 1, Start: 0, End: 5},
+		Lines:   []parser.Span{{Line: 2, Start: 6, End: 9}, {Line: 3, Start: 10, End: 10}, {Line: 5, Start: 12, End: 28}},
+	}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected:\n--- got ---\n%#v\n--- want ---\n%#v", got, want)
+	}
+}
+
+func TestCleanTree_RewritesOnlyTouchedLines(t *testing.T) {
+	t.Parallel()
+	in := "\ufeff[Verse 1]  \r\n" +
+		"Amazing  grace ’tis  so (x2)\r\n" +
+		"How  sweet  the  sound\r\n" +
+		"C   G\r\n" +
+		"\r\n" +
+		"\r\n" +
+		"\r\n" +
+		"Chorus: Hallelujah x2\r\n" +
+		"(To Bridge)\r\n" +
+		"\r\n"
+	want := "\ufeff[Verse 1]  \r\n" +
+		"Amazing grace 'tis so\r\n" +
+		"How  sweet  the  sound\r\n" +
+		"[C] [G]\r\n" +
+		"\r\n" +
+		"Chorus: Hallelujah\r\n" +
+		"\r\n"
+	tree := parser.ParseTree(in, parser.Options{})
+	wantRules := []string{RuleRepeats, RuleDirectives, RuleNakedChords, RuleBlankLines}
+	if c := CleanTree(tree, Options{}); c.Lines != 7 || !reflect.DeepEqual(c.Rules, wantRules) {
+		t.Fatalf("CleanTree = %+v, want 7 lines changed by %v", c, wantRules)
+	}
+	if got := tree.String(); got != want {
+		t.Fatalf("unexpected:\n--- got ---\n%q\n--- want ---\n%q", got, want)
+	}
+	if got, wantSong := tree.Song().Sections[1].Content, []string{"Hallelujah", "", ""}; !reflect.DeepEqual(got, wantSong) {
+		t.Fatalf("chorus content = %q, want %q", got, wantSong)
+	}
+
+	// A clean chart is left alone
+	again := parser.ParseTree(want, parser.Options{})
+	if c := CleanTree(again, Options{}); c.Lines != 0 || c.Rules != nil || again.String() != want {
+		t.Fatalf("second pass changed %+v:\n%q", c, again.String())
+	}
+
+	// Only the rules that applied are reported
+	tree = parser.ParseTree("Verse\nG  C\n", parser.Options{})
+	if c := CleanTree(tree, Options{}); c.Lines != 1 || !reflect.DeepEqual(c.Rules, []string{RuleNakedChords}) {
+		t.Fatalf("naked chords: %+v", c)
+	}
+}
@@ -0,0 +1,97 @@
+package processor
+
+import (
+	"chordparser/internal/normalize"
+	"chordparser/internal/parser"
+	"strings"
+)
+
+// CleanTree applies the cleaning rules to a parse tree in place, for minimal-diff rewrites of a
+// chart. Only lines a rule applies to are rewritten (and then tidied and folded like CleanText
+// output); every other line keeps its spelling, spacing and line end. Repeated blank lines in a
+// section are removed, but a single blank line before the next header is layout and stays.
+// It reports the number of lines rewritten or removed, so zero means the chart is already clean,
+// and the rules that changed them.
+func CleanTree(t *parser.Tree, opts Options) Changes {
+	directives := directivesFor(opts.Language)
+	folds := normalize.FoldsOrDefault(opts.Folds)
+
+	changed, applied := 0, rules(0)
+	for _, s := range t.Sections {
+		if h := s.Header; h != nil && h.Rest != "" {
+			// An inline label ("Chorus: Hallelujah x2") shares its line with content
+			if rest, r := cleanLine(h.Rest, directives); r != 0 {
+				line := normalize.Fold(h.Text, folds)
+				if i := strings.Index(line, h.Rest); i >= 0 {
+					h.Text = strings.TrimRight(line[:i]+rest, " \t")
+					h.Rest = rest
+					changed++
+					applied |= r
+				}
+			}
+		}
+
+		lines := s.Lines[:0]
+		prevBlank := false
+		for _, l := range s.Lines {
+			if l.Kind != parser.LineContent {
+				lines = append(lines, l)
+				prevBlank = false
+				continue
+			}
+			if text, r := cleanLine(normalize.Fold(l.Text, folds), directives); r != 0 {
+				l.Text = text
+				changed++
+				applied |= r
+			}
+			blank := strings.TrimSpace(l.Text) == ""
+			// The empty "line" after a final line end is not a line of its own
+			if blank && prevBlank && l.Text+l.EOL != "" {
+				changed++
+				applied |= ruleBlankLines
+				continue
+			}
+			prevBlank = blank
+			lines = append(lines, l)
+		}
+		s.Lines = lines
+	}
+	return Changes{Lines: changed, Rules: applied.names()}
+}
+
+// Names of the cleaning rules, as reported in Changes.Rules (and recorded in the audit log).
+const (
+	RuleRepeats     = "repeats"      // rule 1: "(x2)", "3x"
+	RuleDirectives  = "directives"   // rule 2: "(To Chorus)"
+	RuleNakedChords = "naked-chords" // rule 3: chord-only lines wrapped in brackets
+	RuleBlankLines  = "blank-lines"  // rule 4: repeated blank lines removed
+)
+
+// Changes reports what CleanTree changed.
+type Changes struct {
+	// Lines is the number of lines rewritten or removed.
+	Lines int
+	// Rules names the rules that changed them, in rule order; nil if none did.
+	Rules []string
+}
+
+// rules is a set of cleaning rules.
+type rules uint8
+
+const (
+	ruleRepeats rules = 1 << iota
+	ruleDirectives
+	ruleNakedChords
+	ruleBlankLines
+)
+
+// names returns the names of the rules in the set, in rule order.
+func (r rules) names() []string {
+	var names []string
+	for i, name := range []string{RuleRepeats, RuleDirectives, RuleNakedChords, RuleBlankLines} {
+		if r&(1<<i) != 0 {
+			names = append(names, name)
+		}
+	}
+	return names
+}
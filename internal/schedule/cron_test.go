@@ -0,0 +1,60 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNext(t *testing.T) {
+	t.Parallel()
+	// 2026-03-13 is a Friday
+	from := time.Date(2026, 3, 13, 10, 7, 30, 0, time.UTC)
+	cases := []struct {
+		expr string
+		want time.Time
+	}{
+		{"* * * * *", time.Date(2026, 3, 13, 10, 8, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2026, 3, 13, 10, 15, 0, 0, time.UTC)},
+		{"0 6 * * MON-FRI", time.Date(2026, 3, 16, 6, 0, 0, 0, time.UTC)},
+		{"30 2 1,15 * *", time.Date(2026, 3, 15, 2, 30, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC)},
+		{"0 9 13 * 1", time.Date(2026, 3, 16, 9, 0, 0, 0, time.UTC)},
+		{"5/20 10 * * *", time.Date(2026, 3, 13, 10, 25, 0, 0, time.UTC)},
+		{"0 0 1 jan *", time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2026, 3, 13, 11, 0, 0, 0, time.UTC)},
+		{"@weekly", time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC)},
+		{"0 0 30 2 *", time.Time{}},
+	}
+	for _, c := range cases {
+		s, err := Parse(c.expr)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", c.expr, err)
+		}
+		if got := s.Next(from); !got.Equal(c.want) {
+			t.Fatalf("Next(%q) = %v, want %v", c.expr, got, c.want)
+		}
+	}
+}
+
+func TestNext_LocalTime(t *testing.T) {
+	t.Parallel()
+	loc, err := time.LoadLocation("Europe/Amsterdam")
+	if err != nil {
+		t.Skipf("no time zone data: %v", err)
+	}
+	s, _ := Parse("30 2 * * *")
+	// 02:30 does not exist on 2026-03-29 (clocks go forward), so the next run is the day after
+	got := s.Next(time.Date(2026, 3, 28, 12, 0, 0, 0, loc))
+	if want := time.Date(2026, 3, 30, 2, 30, 0, 0, loc); !got.Equal(want) {
+		t.Fatalf("Next = %v, want %v", got, want)
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	t.Parallel()
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "*/0 * * * *", "5-1 * * * *", "* * * foo *", "@sometimes"} {
+		if _, err := Parse(expr); err == nil {
+			t.Fatalf("Parse(%q) should fail", expr)
+		}
+	}
+}
@@ -0,0 +1,222 @@
+package state
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// Outcome is the result of the last sync of an item.
+type Outcome string
+
+const (
+	// InProgress marks an item whose sync started but did not finish (e.g., after a crash).
+	InProgress Outcome = "in_progress"
+	Succeeded  Outcome = "succeeded"
+	Failed     Outcome = "failed"
+)
+
+// Bucket holding one JSON record per item ID.
+var recordsBucket = []byte("records")
+
+// Record is the sync state of one item (a PCO arrangement, or a chart file in batch runs).
+type Record struct {
+	ID string `json:"id"`
+	// UpdatedAt is the item's updated_at (or modification time) when it was last synced.
+	UpdatedAt time.Time `json:"updated_at"`
+	// OriginalHash is the content hash of the original text (see Hash).
+	OriginalHash string `json:"original_hash"`
+	// OptionsHash identifies the conversion options of a batch run (see Source).
+	OptionsHash string `json:"options_hash,omitempty"`
+	// Output is the path the item was written to in a batch run.
+	Output string `json:"output,omitempty"`
+	// CleanedHash is the content hash of the cleaned output of the last successful sync.
+	CleanedHash string    `json:"cleaned_hash,omitempty"`
+	Outcome     Outcome   `json:"outcome"`
+	Error       string    `json:"error,omitempty"`
+	SyncedAt    time.Time `json:"synced_at"`
+}
+
+// Source describes the version of an item being synced and how it is processed.
+// An item is synced again when any field differs from its last successful sync.
+type Source struct {
+	// UpdatedAt is the item's updated_at (or modification time).
+	UpdatedAt time.Time
+	// OriginalHash is the content hash of the original text (see Hash).
+	OriginalHash string
+	// OptionsHash identifies the conversion options (batch runs) or cleaning options (PCO
+	// syncs), so that changing them processes every item again.
+	OptionsHash string
+	// Output is the output path (batch runs); it is empty for PCO syncs.
+	Output string
+}
+
+// Store is a local embedded (bbolt) store of sync records. It is safe for concurrent use.
+type Store struct {
+	db  *bolt.DB
+	now func() time.Time
+}
+
+// Open opens (or creates) the store file at path. It fails if another process holds the file.
+func Open(path string) (*Store, error) {
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("state: open %s: %w", path, err)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(recordsBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("state: init %s: %w", path, err)
+	}
+	return &Store{db: db, now: time.Now}, nil
+}
+
+// Close closes the store file.
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// Hash returns the content hash used for original and cleaned texts (hex SHA-256).
+func Hash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Get returns the record of an item and whether it exists.
+func (s *Store) Get(id string) (Record, bool, error) {
+	var rec Record
+	found := false
+	err := s.db.View(func(tx *bolt.Tx) error {
+		v := tx.Bucket(recordsBucket).Get([]byte(id))
+		if v == nil {
+			return nil
+		}
+		found = true
+		return json.Unmarshal(v, &rec)
+	})
+	if err != nil {
+		return Record{}, false, fmt.Errorf("state: get %s: %w", id, err)
+	}
+	return rec, found, nil
+}
+
+// Put stores a record, replacing any previous record of the item.
+func (s *Store) Put(rec Record) error {
+	if rec.ID == "" {
+		return errors.New("state: record without ID")
+	}
+	v, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("state: put %s: %w", rec.ID, err)
+	}
+	err = s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(recordsBucket).Put([]byte(rec.ID), v)
+	})
+	if err != nil {
+		return fmt.Errorf("state: put %s: %w", rec.ID, err)
+	}
+	return nil
+}
+
+// Changed reports whether an item needs to be synced: it has no record, its last sync did not
+// succeed (failed, or interrupted while in progress), or its updated_at, original hash, options
+// hash or output path differ from the last successful sync.
+func (s *Store) Changed(id string, src Source) (bool, error) {
+	rec, ok, err := s.Get(id)
+	if err != nil || !ok {
+		return true, err
+	}
+	return rec.Outcome != Succeeded || !rec.UpdatedAt.Equal(src.UpdatedAt) || rec.OriginalHash != src.OriginalHash ||
+		rec.OptionsHash != src.OptionsHash || rec.Output != src.Output, nil
+}
+
+// Begin records that the sync of an item started. Until Finish is called the item stays
+// in progress, so a crashed run is resumed by syncing it again.
+func (s *Store) Begin(id string, src Source) error {
+	rec, _, err := s.Get(id)
+	if err != nil {
+		return err
+	}
+	rec.ID, rec.UpdatedAt, rec.OriginalHash = id, src.UpdatedAt, src.OriginalHash
+	rec.OptionsHash, rec.Output = src.OptionsHash, src.Output
+	rec.Outcome, rec.Error = InProgress, ""
+	return s.Put(rec)
+}
+
+// Written records that a sync started with Begin wrote the item back (a PATCH to PCO): the write
+// gave the item a new updated_at and the written text, so that version becomes the one the next
+// run compares against instead of counting as changed.
+func (s *Store) Written(id string, updatedAt time.Time, writtenHash string) error {
+	rec, ok, err := s.Get(id)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("state: written %s: sync was not begun", id)
+	}
+	rec.UpdatedAt, rec.OriginalHash = updatedAt, writtenHash
+	return s.Put(rec)
+}
+
+// Finish records the outcome of a sync started with Begin: the cleaned hash on success,
+// or the error on failure (keeping the cleaned hash of the last successful sync).
+func (s *Store) Finish(id, cleanedHash string, syncErr error) error {
+	rec, ok, err := s.Get(id)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("state: finish %s: sync was not begun", id)
+	}
+	rec.SyncedAt = s.now().UTC()
+	if syncErr != nil {
+		rec.Outcome, rec.Error = Failed, syncErr.Error()
+	} else {
+		rec.Outcome, rec.Error, rec.CleanedHash = Succeeded, "", cleanedHash
+	}
+	return s.Put(rec)
+}
+
+// Records returns all records sorted by ID.
+func (s *Store) Records() ([]Record, error) {
+	var recs []Record
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(recordsBucket).ForEach(func(_, v []byte) error {
+			var rec Record
+			if err := json.Unmarshal(v, &rec); err != nil {
+				return err
+			}
+			recs = append(recs, rec)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("state: records: %w", err)
+	}
+	sort.Slice(recs, func(i, j int) bool { return recs[i].ID < recs[j].ID })
+	return recs, nil
+}
+
+// Unfinished returns the records of items whose sync was interrupted (still in progress).
+func (s *Store) Unfinished() ([]Record, error) {
+	recs, err := s.Records()
+	if err != nil {
+		return nil, err
+	}
+	var out []Record
+	for _, r := range recs {
+		if r.Outcome == InProgress {
+			out = append(out, r)
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,175 @@
+package state
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func openTestStore(t *testing.T) (*Store, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "state.db")
+	s, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s, path
+}
+
+func TestStore_IncrementalSync(t *testing.T) {
+	t.Parallel()
+	s, _ := openTestStore(t)
+	updated := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	hash := Hash([]byte("Verse 1\nAmazing grace"))
+
+	if changed, err := s.Changed("123", Source{UpdatedAt: updated, OriginalHash: hash}); err != nil || !changed {
+		t.Fatalf("new item should be changed, got %v, %v", changed, err)
+	}
+	if err := s.Begin("123", Source{UpdatedAt: updated, OriginalHash: hash}); err != nil {
+		t.Fatalf("Begin: %v", err)
+	}
+	if err := s.Finish("123", Hash([]byte("clean")), nil); err != nil {
+		t.Fatalf("Finish: %v", err)
+	}
+	if changed, _ := s.Changed("123", Source{UpdatedAt: updated, OriginalHash: hash}); changed {
+		t.Fatalf("synced item should be unchanged")
+	}
+	if changed, _ := s.Changed("123", Source{UpdatedAt: updated.Add(time.Minute), OriginalHash: hash}); !changed {
+		t.Fatalf("newer updated_at should be changed")
+	}
+	if changed, _ := s.Changed("123", Source{UpdatedAt: updated, OriginalHash: Hash([]byte("edited"))}); !changed {
+		t.Fatalf("different original hash should be changed")
+	}
+
+	// Batch runs also record the conversion options and the output path
+	src := Source{UpdatedAt: updated, OriginalHash: hash, OptionsHash: "json", Output: "out/a.json"}
+	_ = s.Begin("/charts/a.cho", src)
+	_ = s.Finish("/charts/a.cho", "c", nil)
+	for _, changed := range []Source{
+		{UpdatedAt: updated, OriginalHash: hash, OptionsHash: "chordpro", Output: "out/a.json"},
+		{UpdatedAt: updated, OriginalHash: hash, OptionsHash: "json", Output: "other/a.json"},
+	} {
+		if ok, _ := s.Changed("/charts/a.cho", changed); !ok {
+			t.Fatalf("%+v should be changed", changed)
+		}
+	}
+	if ok, _ := s.Changed("/charts/a.cho", src); ok {
+		t.Fatalf("same options and output should be unchanged")
+	}
+
+	rec, ok, err := s.Get("123")
+	if err != nil || !ok {
+		t.Fatalf("Get: %v, %v", ok, err)
+	}
+	if rec.Outcome != Succeeded || rec.CleanedHash != Hash([]byte("clean")) || rec.SyncedAt.IsZero() {
+		t.Fatalf("unexpected record: %#v", rec)
+	}
+}
+
+func TestStore_WrittenItemIsUnchanged(t *testing.T) {
+	t.Parallel()
+	s, _ := openTestStore(t)
+	updated := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	original := Source{UpdatedAt: updated, OriginalHash: Hash([]byte("La (x2)")), OptionsHash: "nl"}
+
+	_ = s.Begin("123", original)
+	written := updated.Add(time.Second)
+	if err := s.Written("123", written, Hash([]byte("La"))); err != nil {
+		t.Fatalf("Written: %v", err)
+	}
+	_ = s.Finish("123", Hash([]byte("La")), nil)
+
+	// The next run sees the version the sync wrote, not a change
+	if changed, _ := s.Changed("123", Source{UpdatedAt: written, OriginalHash: Hash([]byte("La")), OptionsHash: "nl"}); changed {
+		t.Fatalf("the written version should be unchanged")
+	}
+	if changed, _ := s.Changed("123", original); !changed {
+		t.Fatalf("the version before the write should be changed")
+	}
+	if err := s.Written("456", written, ""); err == nil {
+		t.Fatalf("Written without Begin should fail")
+	}
+}
+
+func TestStore_FailureKeepsLastCleanedHash(t *testing.T) {
+	t.Parallel()
+	s, _ := openTestStore(t)
+	now := time.Now()
+	_ = s.Begin("a", Source{UpdatedAt: now, OriginalHash: "h1"})
+	_ = s.Finish("a", "c1", nil)
+	_ = s.Begin("a", Source{UpdatedAt: now, OriginalHash: "h2"})
+	if err := s.Finish("a", "", errors.New("PATCH 409")); err != nil {
+		t.Fatalf("Finish: %v", err)
+	}
+	rec, _, _ := s.Get("a")
+	if rec.Outcome != Failed || rec.Error != "PATCH 409" || rec.CleanedHash != "c1" {
+		t.Fatalf("unexpected record: %#v", rec)
+	}
+	if changed, _ := s.Changed("a", Source{UpdatedAt: now, OriginalHash: "h2"}); !changed {
+		t.Fatalf("failed item should be retried")
+	}
+}
+
+func TestStore_ResumeAfterCrash(t *testing.T) {
+	t.Parallel()
+	s, path := openTestStore(t)
+	now := time.Now()
+	_ = s.Begin("done", Source{UpdatedAt: now, OriginalHash: "h"})
+	_ = s.Finish("done", "c", nil)
+	_ = s.Begin("crashed", Source{UpdatedAt: now, OriginalHash: "h"})
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	s, err := Open(path)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer s.Close()
+	unfinished, err := s.Unfinished()
+	if err != nil {
+		t.Fatalf("Unfinished: %v", err)
+	}
+	if len(unfinished) != 1 || unfinished[0].ID != "crashed" {
+		t.Fatalf("unexpected unfinished records: %#v", unfinished)
+	}
+	if changed, _ := s.Changed("crashed", Source{UpdatedAt: now, OriginalHash: "h"}); !changed {
+		t.Fatalf("interrupted item should be synced again")
+	}
+}
+
+func TestStore_FinishWithoutBegin(t *testing.T) {
+	t.Parallel()
+	s, _ := openTestStore(t)
+	if err := s.Finish("x", "", nil); err == nil {
+		t.Fatalf("expected an error")
+	}
+}
+
+func TestStore_ConcurrentUse(t *testing.T) {
+	t.Parallel()
+	s, _ := openTestStore(t)
+	var wg sync.WaitGroup
+	for i := range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			id := fmt.Sprintf("item-%02d", i)
+			if err := s.Begin(id, Source{UpdatedAt: time.Now(), OriginalHash: "h"}); err != nil {
+				t.Error(err)
+			}
+			if err := s.Finish(id, "c", nil); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	recs, err := s.Records()
+	if err != nil || len(recs) != 20 || recs[0].ID != "item-00" {
+		t.Fatalf("Records = %d records, %v", len(recs), err)
+	}
+}
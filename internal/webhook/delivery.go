@@ -0,0 +1,72 @@
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// EventPrefix is the prefix of PCO Services event names.
+const EventPrefix = "services.v2.events."
+
+// Delivery returns a webhook request body in PCO's format for one event, e.g. "song.updated"
+// or "arrangement.created" (EventPrefix is added when missing). For arrangement events t must
+// name the arrangement and its song.
+func Delivery(event string, t Target) ([]byte, error) {
+	if !strings.HasPrefix(event, EventPrefix) {
+		event = EventPrefix + event
+	}
+	kind, _ := eventKind(event)
+	var data map[string]any
+	switch kind {
+	case "song":
+		data = map[string]any{
+			"type":  "Song",
+			"id":    t.SongID,
+			"links": map[string]string{"self": "https://api.planningcenteronline.com/services/v2/songs/" + t.SongID},
+		}
+	case "arrangement":
+		if t.ArrangementID == "" {
+			return nil, fmt.Errorf("webhook: %s needs an arrangement ID", event)
+		}
+		data = map[string]any{
+			"type": "Arrangement",
+			"id":   t.ArrangementID,
+			"relationships": map[string]any{
+				"song": map[string]any{"data": map[string]string{"type": "Song", "id": t.SongID}},
+			},
+			"links": map[string]string{"self": "https://api.planningcenteronline.com/services/v2/songs/" + t.SongID + "/arrangements/" + t.ArrangementID},
+		}
+	default:
+		return nil, fmt.Errorf("webhook: unsupported event %q (want song.* or arrangement.*)", event)
+	}
+	if t.SongID == "" {
+		return nil, fmt.Errorf("webhook: %s needs a song ID", event)
+	}
+	payload, err := json.Marshal(map[string]any{"data": data})
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(map[string]any{"data": []any{map[string]any{
+		"type": "EventDelivery",
+		"id":   "local",
+		"attributes": map[string]any{
+			"name":    event,
+			"attempt": 1,
+			"payload": string(payload),
+		},
+	}}})
+}
+
+// NewRequest returns a signed POST of body to url, as PCO would send it.
+func NewRequest(url, secret string, body []byte) (*http.Request, error) {
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(SignatureHeader, Sign(secret, body))
+	return req, nil
+}
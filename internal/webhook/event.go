@@ -0,0 +1,114 @@
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Target is what to clean and sync: one arrangement, or every arrangement of a song when
+// ArrangementID is empty.
+type Target struct {
+	SongID        string `json:"songId"`
+	ArrangementID string `json:"arrangementId,omitempty"`
+}
+
+func (t Target) String() string {
+	if t.ArrangementID == "" {
+		return "song " + t.SongID
+	}
+	return "song " + t.SongID + " arrangement " + t.ArrangementID
+}
+
+// Event is a song or arrangement change announced by a webhook.
+type Event struct {
+	// Name is the PCO event name, e.g. "services.v2.events.arrangement.updated".
+	Name   string
+	Target Target
+}
+
+// delivery is a PCO webhook request body: JSON:API EventDelivery resources whose payload is
+// the changed resource, encoded as a JSON string.
+type delivery struct {
+	Data []struct {
+		Type       string `json:"type"`
+		ID         string `json:"id"`
+		Attributes struct {
+			Name    string `json:"name"`
+			Attempt int    `json:"attempt"`
+			Payload string `json:"payload"`
+		} `json:"attributes"`
+	} `json:"data"`
+}
+
+// resource is the changed song or arrangement in a delivery payload.
+type resource struct {
+	Data struct {
+		Type          string `json:"type"`
+		ID            string `json:"id"`
+		Relationships struct {
+			Song struct {
+				Data *struct {
+					ID string `json:"id"`
+				} `json:"data"`
+			} `json:"song"`
+		} `json:"relationships"`
+		Links struct {
+			Self string `json:"self"`
+		} `json:"links"`
+	} `json:"data"`
+}
+
+// The song ID in an arrangement URL, e.g. ".../services/v2/songs/123/arrangements/456"
+var reSongLink = regexp.MustCompile(`/songs/([^/]+)/arrangements/`)
+
+// ParseDelivery reads the events of a webhook request body. Only created and updated songs and
+// arrangements are returned; other events (such as deletions) are ignored.
+func ParseDelivery(body []byte) ([]Event, error) {
+	var d delivery
+	if err := json.Unmarshal(body, &d); err != nil {
+		return nil, fmt.Errorf("webhook: %w", err)
+	}
+	var events []Event
+	for _, item := range d.Data {
+		name := item.Attributes.Name
+		kind, action := eventKind(name)
+		if action != "created" && action != "updated" {
+			continue
+		}
+		var r resource
+		if err := json.Unmarshal([]byte(item.Attributes.Payload), &r); err != nil {
+			return nil, fmt.Errorf("webhook: event %s: payload: %w", item.ID, err)
+		}
+		e := Event{Name: name}
+		switch kind {
+		case "song":
+			e.Target.SongID = r.Data.ID
+		case "arrangement":
+			e.Target.ArrangementID = r.Data.ID
+			if s := r.Data.Relationships.Song.Data; s != nil {
+				e.Target.SongID = s.ID
+			} else if m := reSongLink.FindStringSubmatch(r.Data.Links.Self); m != nil {
+				e.Target.SongID = m[1]
+			}
+		default:
+			continue
+		}
+		if e.Target.SongID == "" {
+			return nil, fmt.Errorf("webhook: event %s (%s) does not identify a song", item.ID, name)
+		}
+		events = append(events, e)
+	}
+	return events, nil
+}
+
+// eventKind splits an event name such as "services.v2.events.song.updated" into the resource
+// kind ("song") and action ("updated").
+func eventKind(name string) (string, string) {
+	parts := strings.Split(name, ".")
+	if len(parts) < 2 {
+		return "", ""
+	}
+	return parts[len(parts)-2], parts[len(parts)-1]
+}
@@ -0,0 +1,85 @@
+package webhook
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+)
+
+// DefaultMaxBody is the default request size limit in bytes.
+const DefaultMaxBody = 1 << 20
+
+// Options configures the webhook handler.
+type Options struct {
+	// Secrets are the authenticity secrets of the PCO webhook subscriptions.
+	Secrets []string
+	// MaxBody is the request size limit in bytes; zero means DefaultMaxBody.
+	MaxBody int64
+}
+
+// Error is the JSON body of every error response, as in the API.
+type Error struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Accepted is the JSON body of a handled delivery.
+type Accepted struct {
+	// Queued lists the targets the delivery queued; events merged into queued targets and
+	// ignored events are not listed.
+	Queued []Target `json:"queued"`
+}
+
+// NewHandler returns a handler for POSTed PCO webhook deliveries. It verifies the signature,
+// queues the song or arrangement of every created or updated event and answers 202 Accepted
+// without waiting for the sync.
+func NewHandler(q *Queue, opts Options) http.Handler {
+	if opts.MaxBody <= 0 {
+		opts.MaxBody = DefaultMaxBody
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			writeError(w, http.StatusMethodNotAllowed, "method_not_allowed", "method "+r.Method+" is not allowed on "+r.URL.Path)
+			return
+		}
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, opts.MaxBody))
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				writeError(w, http.StatusRequestEntityTooLarge, "too_large", "the request body is too large")
+				return
+			}
+			writeError(w, http.StatusBadRequest, "invalid_body", err.Error())
+			return
+		}
+		if !Verify(opts.Secrets, body, r.Header.Get(SignatureHeader)) {
+			writeError(w, http.StatusUnauthorized, "invalid_signature", "missing or invalid "+SignatureHeader+" header")
+			return
+		}
+		events, err := ParseDelivery(body)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid_json", err.Error())
+			return
+		}
+		resp := Accepted{Queued: []Target{}}
+		for _, e := range events {
+			if q.Add(e.Target) {
+				resp.Queued = append(resp.Queued, e.Target)
+			}
+		}
+		writeJSON(w, http.StatusAccepted, resp)
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	writeJSON(w, status, struct {
+		Error Error `json:"error"`
+	}{Error{Code: code, Message: message}})
+}
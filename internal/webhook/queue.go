@@ -0,0 +1,151 @@
+package webhook
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// SyncFunc cleans and syncs one target.
+type SyncFunc func(ctx context.Context, t Target) error
+
+// Queue collects targets and syncs each once its events have been quiet for a delay, so that a
+// burst of updates (PCO sends one per saved field) results in a single sync. A target that is
+// queued again while it syncs is synced once more afterwards. A song target covers the
+// arrangement targets of that song.
+type Queue struct {
+	delay time.Duration
+
+	mu      sync.Mutex
+	pending map[Target]time.Time // due time of every queued target
+	running map[Target]bool
+	wake    chan struct{}
+}
+
+// NewQueue returns a queue that syncs targets delay after their last event.
+func NewQueue(delay time.Duration) *Queue {
+	return &Queue{
+		delay:   delay,
+		pending: map[Target]time.Time{},
+		running: map[Target]bool{},
+		wake:    make(chan struct{}, 1),
+	}
+}
+
+// Add queues a target, or postpones it when it is already queued. It reports whether the target
+// was newly queued rather than merged into a queued one.
+func (q *Queue) Add(t Target) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	due := time.Now().Add(q.delay)
+	song := Target{SongID: t.SongID}
+	if t.ArrangementID != "" {
+		if _, ok := q.pending[song]; ok {
+			q.pending[song] = due
+			q.signal()
+			return false
+		}
+	} else {
+		for p := range q.pending {
+			if p.SongID == t.SongID && p.ArrangementID != "" {
+				delete(q.pending, p)
+			}
+		}
+	}
+	_, merged := q.pending[t]
+	q.pending[t] = due
+	q.signal()
+	return !merged
+}
+
+// Len returns the number of queued targets, not counting those syncing.
+func (q *Queue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.pending)
+}
+
+func (q *Queue) signal() {
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run syncs due targets with fn, at most workers at a time, until ctx is cancelled, then
+// waits for the syncs in progress. Failed syncs are passed to onError, which may be nil; they are not
+// retried, since the next event or the scheduled full run covers them.
+func (q *Queue) Run(ctx context.Context, workers int, fn SyncFunc, onError func(Target, error)) {
+	if workers < 1 {
+		workers = 1
+	}
+	slots := make(chan struct{}, workers)
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	timer := time.NewTimer(time.Hour)
+	defer timer.Stop()
+	for {
+		for _, t := range q.due(time.Now(), workers-len(slots)) {
+			slots <- struct{}{}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if err := fn(ctx, t); err != nil && onError != nil {
+					onError(t, err)
+				}
+				q.mu.Lock()
+				delete(q.running, t)
+				q.mu.Unlock()
+				<-slots
+				q.signal()
+			}()
+		}
+		timer.Reset(q.wait(time.Now()))
+		select {
+		case <-ctx.Done():
+			return
+		case <-q.wake:
+		case <-timer.C:
+		}
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+	}
+}
+
+// due takes up to n due targets that are not syncing off the queue and marks them running.
+func (q *Queue) due(now time.Time, n int) []Target {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	var out []Target
+	for t, at := range q.pending {
+		if len(out) == n {
+			break
+		}
+		if !at.After(now) && !q.running[t] {
+			delete(q.pending, t)
+			q.running[t] = true
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+// wait returns the time until the next queued target is due.
+func (q *Queue) wait(now time.Time) time.Duration {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	next := time.Hour
+	for t, at := range q.pending {
+		if q.running[t] {
+			continue // woken when the sync finishes
+		}
+		if d := at.Sub(now); d < next {
+			next = max(d, 0)
+		}
+	}
+	return next
+}
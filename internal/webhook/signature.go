@@ -0,0 +1,34 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// SignatureHeader is the header in which Planning Center sends the hex HMAC-SHA256 of the
+// request body, keyed with the webhook subscription's authenticity secret.
+const SignatureHeader = "X-PCO-Webhooks-Authenticity"
+
+// Sign returns the signature of a request body for a secret.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify reports whether signature is a valid signature of body for one of the secrets
+// (every PCO webhook subscription has its own secret). The comparison takes constant time.
+func Verify(secrets []string, body []byte, signature string) bool {
+	got, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+	for _, s := range secrets {
+		want, _ := hex.DecodeString(Sign(s, body))
+		if s != "" && hmac.Equal(got, want) {
+			return true
+		}
+	}
+	return false
+}
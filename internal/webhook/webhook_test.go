@@ -0,0 +1,252 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestVerify(t *testing.T) {
+	t.Parallel()
+	body := []byte(`{"data":[]}`)
+	sig := Sign("second", body)
+	if !Verify([]string{"first", "second"}, body, sig) {
+		t.Fatalf("signature of the second secret was rejected")
+	}
+	for name, c := range map[string]struct {
+		secrets []string
+		body    string
+		sig     string
+	}{
+		"wrong secret":   {[]string{"first"}, string(body), sig},
+		"changed body":   {[]string{"second"}, `{"data":[{}]}`, sig},
+		"no signature":   {[]string{"second"}, string(body), ""},
+		"not hex":        {[]string{"second"}, string(body), "zz" + sig[2:]},
+		"empty secret":   {[]string{""}, string(body), Sign("", body)},
+		"no secrets set": {nil, string(body), sig},
+	} {
+		if Verify(c.secrets, []byte(c.body), c.sig) {
+			t.Fatalf("%s: signature accepted", name)
+		}
+	}
+}
+
+func TestParseDelivery(t *testing.T) {
+	t.Parallel()
+	var events []Event
+	for _, c := range []struct {
+		event string
+		t     Target
+	}{
+		{"arrangement.updated", Target{SongID: "12", ArrangementID: "34"}},
+		{"services.v2.events.song.created", Target{SongID: "56"}},
+	} {
+		body, err := Delivery(c.event, c.t)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := ParseDelivery(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		events = append(events, got...)
+	}
+	want := []Event{
+		{Name: "services.v2.events.arrangement.updated", Target: Target{SongID: "12", ArrangementID: "34"}},
+		{Name: "services.v2.events.song.created", Target: Target{SongID: "56"}},
+	}
+	if !reflect.DeepEqual(events, want) {
+		t.Fatalf("events = %+v, want %+v", events, want)
+	}
+
+	// The song of an arrangement is taken from its link when the relationship is missing
+	payload := `{"data":{"type":"Arrangement","id":"34","links":{"self":"https://api.planningcenteronline.com/services/v2/songs/12/arrangements/34"}}}`
+	body, _ := json.Marshal(map[string]any{"data": []any{
+		map[string]any{"id": "1", "attributes": map[string]any{"name": "services.v2.events.arrangement.created", "payload": payload}},
+		map[string]any{"id": "2", "attributes": map[string]any{"name": "services.v2.events.arrangement.destroyed", "payload": payload}},
+		map[string]any{"id": "3", "attributes": map[string]any{"name": "services.v2.events.plan.updated", "payload": payload}},
+	}})
+	got, err := ParseDelivery(body)
+	if err != nil || len(got) != 1 || got[0].Target != (Target{SongID: "12", ArrangementID: "34"}) {
+		t.Fatalf("events = %+v, %v", got, err)
+	}
+
+	if _, err := Delivery("plan.updated", Target{SongID: "1"}); err == nil {
+		t.Fatalf("expected an error for an unsupported event")
+	}
+	if _, err := Delivery("arrangement.updated", Target{SongID: "1"}); err == nil {
+		t.Fatalf("expected an error for an arrangement event without an arrangement")
+	}
+}
+
+func TestQueue_MergesBursts(t *testing.T) {
+	t.Parallel()
+	q := NewQueue(time.Hour)
+	a := Target{SongID: "1", ArrangementID: "10"}
+	if !q.Add(a) || q.Add(a) {
+		t.Fatalf("a repeated arrangement was not merged")
+	}
+	if !q.Add(Target{SongID: "1", ArrangementID: "11"}) || q.Len() != 2 {
+		t.Fatalf("another arrangement was merged")
+	}
+	// A song target replaces its queued arrangements and covers later ones
+	if !q.Add(Target{SongID: "1"}) || q.Len() != 1 {
+		t.Fatalf("song target: %d queued", q.Len())
+	}
+	if q.Add(a) || q.Len() != 1 {
+		t.Fatalf("arrangement of a queued song was queued")
+	}
+	if !q.Add(Target{SongID: "2", ArrangementID: "20"}) || q.Len() != 2 {
+		t.Fatalf("arrangement of another song was merged")
+	}
+}
+
+func TestQueue_RunSyncsOncePerBurst(t *testing.T) {
+	t.Parallel()
+	q := NewQueue(30 * time.Millisecond)
+	var mu sync.Mutex
+	synced := map[Target]int{}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		q.Run(ctx, 2, func(ctx context.Context, t Target) error {
+			mu.Lock()
+			synced[t]++
+			mu.Unlock()
+			return nil
+		}, nil)
+	}()
+
+	a, b := Target{SongID: "1", ArrangementID: "10"}, Target{SongID: "2"}
+	for range 5 {
+		q.Add(a)
+		q.Add(b)
+		time.Sleep(5 * time.Millisecond)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		mu.Lock()
+		n := len(synced)
+		mu.Unlock()
+		if n == 2 && q.Len() == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("targets were not synced")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	time.Sleep(60 * time.Millisecond)
+	cancel()
+	<-done
+	if want := map[Target]int{a: 1, b: 1}; !reflect.DeepEqual(synced, want) {
+		t.Fatalf("synced = %v, want %v", synced, want)
+	}
+}
+
+func TestQueue_RequeueWhileSyncing(t *testing.T) {
+	t.Parallel()
+	q := NewQueue(0)
+	started, release := make(chan struct{}), make(chan struct{})
+	var mu sync.Mutex
+	runs := 0
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		q.Run(ctx, 4, func(ctx context.Context, t Target) error {
+			mu.Lock()
+			runs++
+			n := runs
+			mu.Unlock()
+			if n == 1 {
+				close(started)
+				<-release
+			}
+			return nil
+		}, nil)
+	}()
+
+	target := Target{SongID: "1"}
+	q.Add(target)
+	<-started
+	// Queued again during the sync: synced once more afterwards, never concurrently
+	q.Add(target)
+	time.Sleep(20 * time.Millisecond)
+	mu.Lock()
+	if runs != 1 {
+		t.Fatalf("target synced concurrently (%d runs)", runs)
+	}
+	mu.Unlock()
+	close(release)
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		mu.Lock()
+		n := runs
+		mu.Unlock()
+		if n == 2 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("target was not synced again")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	cancel()
+	<-done
+}
+
+func TestHandler(t *testing.T) {
+	t.Parallel()
+	q := NewQueue(time.Hour)
+	h := NewHandler(q, Options{Secrets: []string{"secret"}, MaxBody: 4096})
+	body, err := Delivery("arrangement.updated", Target{SongID: "12", ArrangementID: "34"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	send := func(method, sig, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/webhooks/pco", strings.NewReader(body))
+		req.Header.Set(SignatureHeader, sig)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := send("POST", Sign("secret", body), string(body))
+	var resp Accepted
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || rec.Code != http.StatusAccepted {
+		t.Fatalf("delivery: %d %s", rec.Code, rec.Body)
+	}
+	if want := []Target{{SongID: "12", ArrangementID: "34"}}; !reflect.DeepEqual(resp.Queued, want) || q.Len() != 1 {
+		t.Fatalf("queued %+v (%d)", resp.Queued, q.Len())
+	}
+	// A retried delivery is merged into the queued target
+	if rec := send("POST", Sign("secret", body), string(body)); rec.Code != http.StatusAccepted || !strings.Contains(rec.Body.String(), `"queued":[]`) {
+		t.Fatalf("retry: %d %s", rec.Code, rec.Body)
+	}
+
+	large := `{"data":"` + strings.Repeat("x", 5000) + `"}`
+	for _, c := range []struct {
+		name, method, sig, body string
+		status                  int
+		code                    string
+	}{
+		{"signature", "POST", Sign("other", body), string(body), 401, "invalid_signature"},
+		{"method", "GET", "", "", 405, "method_not_allowed"},
+		{"too large", "POST", Sign("secret", []byte(large)), large, 413, "too_large"},
+		{"invalid json", "POST", Sign("secret", []byte("{")), "{", 400, "invalid_json"},
+	} {
+		rec := send(c.method, c.sig, c.body)
+		var body struct{ Error Error }
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil || rec.Code != c.status || body.Error.Code != c.code {
+			t.Fatalf("%s: got %d %s, want %d %s", c.name, rec.Code, rec.Body, c.status, c.code)
+		}
+	}
+}